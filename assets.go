@@ -0,0 +1,13 @@
+// Package blog embeds the default static assets shipped with Statix, so
+// the admin binary can serve its own CSS and scripts from any working
+// directory.
+//
+// The selected theme, font and favicon symlinks and uploaded files are
+// site state and stay on disk only.
+package blog
+
+import "embed"
+
+//go:embed assets/css/style.css assets/css/themes assets/css/fonts
+//go:embed assets/js assets/prism assets/katex assets/fonts assets/favicons
+var Assets embed.FS
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 
@@ -10,7 +12,14 @@ import (
 )
 
 func main() {
+	dev := flag.Bool("dev", false, "reload templates from internal/templates on every request")
+	migrate := flag.Bool("migrate", true, "apply pending schema migrations on startup")
+	flag.Parse()
+
 	cfg := config.Load()
+	if *dev {
+		cfg.Dev = true
+	}
 
 	conn, err := db.Open(cfg.DB)
 	if err != nil {
@@ -18,10 +27,23 @@ func main() {
 	}
 	defer conn.Close()
 
-	router := admin.NewRouter(conn, cfg.AdminPass)
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(conn, cfg.DB.Driver, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := migrateOnStartup(conn, cfg.DB.Driver, *migrate); err != nil {
+		log.Fatal(err)
+	}
+
+	srv := admin.NewServer(conn, cfg, plugins)
+
+	go srv.RunScheduler(context.Background())
 
 	log.Printf("admin listening on %s\n", cfg.AdminAddr)
-	if err := http.ListenAndServe(cfg.AdminAddr, router); err != nil {
+	if err := http.ListenAndServe(cfg.AdminAddr, srv.Routes()); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"blog/internal/db"
+)
+
+const migrateUsage = `usage: admin migrate [-dry-run] status | up [VERSION] | down [STEPS]
+
+  status          show the applied version and the pending migrations
+  up [VERSION]    apply the pending migrations, up to VERSION if given
+  down [STEPS]    revert the last STEPS migrations (default 1)
+`
+
+// runMigrate implements "admin migrate". With -dry-run the migrations
+// that would run are listed with their statements, and nothing changes.
+func runMigrate(conn *sql.DB, driver string, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "list the migrations and their statements without running them")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	fs.Parse(args)
+
+	m := db.Migrator{DB: conn, Driver: driver}
+
+	arg := func(def int) (int, error) {
+		if fs.NArg() < 2 {
+			return def, nil
+		}
+		return strconv.Atoi(fs.Arg(1))
+	}
+
+	switch fs.Arg(0) {
+	case "status", "":
+		version, pending, err := m.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version %d, %d pending\n", version, len(pending))
+		printMigrations("pending", pending, nil)
+		return nil
+
+	case "up":
+		target, err := arg(0)
+		if err != nil {
+			return err
+		}
+		applied, err := m.Up(target, *dryRun)
+		if *dryRun {
+			printMigrations("would apply", applied, func(m db.Migration) string { return m.Up })
+		} else {
+			printMigrations("applied", applied, nil)
+		}
+		return err
+
+	case "down":
+		steps, err := arg(1)
+		if err != nil {
+			return err
+		}
+		reverted, err := m.Down(steps, *dryRun)
+		if *dryRun {
+			printMigrations("would revert", reverted, func(m db.Migration) string { return m.Down })
+		} else {
+			printMigrations("reverted", reverted, nil)
+		}
+		return err
+
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	return nil
+}
+
+// printMigrations lists migrations, with the statements of script when
+// it is not nil.
+func printMigrations(label string, migrations []db.Migration, script func(db.Migration) string) {
+	for _, mig := range migrations {
+		fmt.Printf("%s %04d_%s\n", label, mig.Version, mig.Name)
+
+		if script == nil {
+			continue
+		}
+		for _, stmt := range db.Statements(script(mig)) {
+			fmt.Printf("    %s\n", stmt)
+		}
+	}
+}
+
+// migrateOnStartup refuses to run against a schema newer than the binary
+// and, when apply is set, brings an older one up to date.
+func migrateOnStartup(conn *sql.DB, driver string, apply bool) error {
+	m := db.Migrator{DB: conn, Driver: driver}
+
+	if !apply {
+		_, pending, err := m.Status()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			log.Printf("%d pending migrations, run: admin migrate up", len(pending))
+		}
+		return nil
+	}
+
+	applied, err := m.Up(0, false)
+	for _, mig := range applied {
+		log.Printf("migrated up %04d_%s", mig.Version, mig.Name)
+	}
+	if errors.Is(err, db.ErrSchemaTooNew) {
+		return fmt.Errorf("%w: upgrade the admin binary", err)
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+package main
+
+import "blog/internal/generator"
+
+// plugins are the site-specific build stages: extra pages, HTML
+// post-processors and post-build hooks. They run on every build started
+// from the admin, after the built-in ones.
+//
+//	var plugins = generator.Plugins{
+//		Producers: []generator.PageProducer{talksPage},
+//	}
+var plugins generator.Plugins
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
 
 	"blog/internal/config"
 	"blog/internal/db"
@@ -11,25 +14,37 @@ import (
 func main() {
 	cfg := config.Load()
 
+	// an interrupt stops the build between pages
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	conn, err := db.Open(cfg.DB)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer conn.Close()
 
-	repo := db.ArticleRepo{DB: conn}
+	repos := db.NewRepos(conn, cfg.DB)
+
+	articles, err := repos.Articles.ListAll(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	articles, err := repo.ListAll()
+	authors, err := repos.Authors.ListAll(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	gen := generator.Generator{
-		Articles: articles,
-		OutDir:   "dist",
+		ArticleRepo: repos.Articles,
+		SubjectRepo: repos.Subjects,
+		Articles:    articles,
+		Authors:     authors,
+		OutDir:      "dist",
 	}
 
-	if err := gen.Build(); err != nil {
+	if err := gen.Build(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
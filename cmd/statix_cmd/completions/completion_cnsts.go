@@ -5,7 +5,7 @@ _stx_completion() {
     local cur prev words cword
     _init_completion || return
 
-    local commands="publish nickname articles subjects subject file dumpdb set-credentials"
+    local commands="publish nickname articles search subjects subject authors page file dumpdb restore export import build check set-credentials"
 
     if [[ ${cword} -eq 1 ]]; then
         COMPREPLY=( $(compgen -W "${commands}" -- "$cur") )
@@ -15,17 +15,29 @@ _stx_completion() {
     case "${words[1]}" in
 
         nickname)
-            COMPREPLY=( $(compgen -W "create import import-content edit remove rename list" -- "$cur") )
+            if [[ ${cword} -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "create import import-content edit remove rename list history restore" -- "$cur") )
+            elif [[ "${words[2]}" == edit && "$cur" == -* ]]; then
+                COMPREPLY=( $(compgen -W "--title --subject_id --author_id --is_public --publish_at --lang --translation_of --pinned --pinned_home --pinned_subject --featured" -- "$cur") )
+            fi
             ;;
 
         subject)
-            COMPREPLY=( $(compgen -W "add delete rename" -- "$cur") )
+            COMPREPLY=( $(compgen -W "add delete rename move edit" -- "$cur") )
+            ;;
+
+        page)
+            COMPREPLY=( $(compgen -W "list add edit get delete" -- "$cur") )
             ;;
 
         file)
             COMPREPLY=( $(compgen -W "upload delete list" -- "$cur") )
             ;;
 
+        build)
+            COMPREPLY=( $(compgen -W "--dry-run" -- "$cur") )
+            ;;
+
     esac
 }
 
@@ -42,10 +54,18 @@ _stx() {
         "publish:Publish an article"
         "nickname:Manage nicknames"
         "articles:List articles"
+        "search:Search articles"
         "subjects:List subjects"
         "subject:Manage subjects"
+        "authors:List authors"
+        "page:Manage standalone pages"
         "file:Manage files"
         "dumpdb:Download database dump"
+        "restore:Restore a database dump"
+        "export:Download a full site archive"
+        "import:Import a full site archive"
+        "build:Rebuild the whole site"
+        "check:Check internal links and assets"
         "completion:Generate shell completion"
     )
 
@@ -66,10 +86,26 @@ _stx() {
                 "remove:Remove nickname"
                 "rename:Rename nickname"
                 "list:List nicknames"
+                "history:List article revisions"
+                "restore:Restore an article revision"
             )
 
             if (( CURRENT == 3 )); then
                 _describe 'nickname command' subcmds
+            elif [[ "$words[3]" == edit ]]; then
+                _arguments \
+                    '--title[New title]:title' \
+                    '--subject_id[New subject ID]:id' \
+                    '--author_id[New author ID]:id' \
+                    '--is_public[Public or private]:value:(true false)' \
+                    '--publish_at[Schedule, YYYY-MM-DDTHH:MM]:time' \
+                    '--lang[Language]:lang' \
+                    '--translation_of[ID of the translated article]:id' \
+                    '--pinned[Pin on the index and subject pages]:value:(true false)' \
+                    '--pinned_home[Pin on the index]:value:(true false)' \
+                    '--pinned_subject[Pin on the subject pages]:value:(true false)' \
+                    '--featured[Larger card]:value:(true false)' \
+                    '*:nickname'
             fi
             ;;
 
@@ -79,6 +115,8 @@ _stx() {
                 "add:Add subject"
                 "delete:Delete subject"
                 "rename:Rename subject"
+                "move:Move subject under another"
+                "edit:Edit subject description, cover and position"
             )
 
             if (( CURRENT == 3 )); then
@@ -86,6 +124,21 @@ _stx() {
             fi
             ;;
 
+        page)
+            local -a subcmds
+            subcmds=(
+                "list:List pages"
+                "add:Add page"
+                "edit:Edit page"
+                "get:Print page HTML"
+                "delete:Delete page"
+            )
+
+            if (( CURRENT == 3 )); then
+                _describe 'page command' subcmds
+            fi
+            ;;
+
         file)
             local -a subcmds
             subcmds=(
@@ -99,6 +152,10 @@ _stx() {
             fi
             ;;
 
+        build)
+            _arguments '--dry-run[Show what a build would change]'
+            ;;
+
     esac
 }
 
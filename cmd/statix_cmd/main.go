@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"io"
@@ -92,7 +93,12 @@ func loadConfig() (*Config, error) {
 
 func publish(title string,
 	subjectID string,
+	authorID int64,
 	isPublic string,
+	publishAt string,
+	lang string,
+	translationOf int64,
+	pinnedHome, pinnedSubject, featured *bool,
 	filePath string) (int64, error) {
 
 	cfg, err := loadConfig()
@@ -118,7 +124,26 @@ func publish(title string,
 	data := url.Values{}
 	data.Set("title", title)
 	data.Set("subject_id", subjectID)
+	if authorID != 0 {
+		data.Set("author_id", strconv.FormatInt(authorID, 10))
+	}
 	data.Set("is_public", isPublic)
+	data.Set("publish_at", publishAt)
+	if lang != "" {
+		data.Set("lang", lang)
+	}
+	if translationOf != 0 {
+		data.Set("translation_of", strconv.FormatInt(translationOf, 10))
+	}
+	if pinnedHome != nil {
+		data.Set("pinned_home", strconv.FormatBool(*pinnedHome))
+	}
+	if pinnedSubject != nil {
+		data.Set("pinned_subject", strconv.FormatBool(*pinnedSubject))
+	}
+	if featured != nil {
+		data.Set("featured", strconv.FormatBool(*featured))
+	}
 	data.Set("html", content)
 
 	req, err := http.NewRequest("POST", cfg.URL+"/admin/new", strings.NewReader(data.Encode()))
@@ -153,7 +178,7 @@ func publish(title string,
 	return id, nil
 }
 
-func editArticle(id, title, subjectID, isPublic, filePath string) error {
+func editArticle(id, title, subjectID string, authorID int64, isPublic, publishAt, lang string, translationOf int64, pinnedHome, pinnedSubject, featured *bool, filePath string) error {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
@@ -179,7 +204,26 @@ func editArticle(id, title, subjectID, isPublic, filePath string) error {
 	data := url.Values{}
 	data.Set("title", title)
 	data.Set("subject_id", subjectID)
+	if authorID != 0 {
+		data.Set("author_id", strconv.FormatInt(authorID, 10))
+	}
 	data.Set("is_public", isPublic)
+	data.Set("publish_at", publishAt)
+	if lang != "" {
+		data.Set("lang", lang)
+	}
+	if translationOf != 0 {
+		data.Set("translation_of", strconv.FormatInt(translationOf, 10))
+	}
+	if pinnedHome != nil {
+		data.Set("pinned_home", strconv.FormatBool(*pinnedHome))
+	}
+	if pinnedSubject != nil {
+		data.Set("pinned_subject", strconv.FormatBool(*pinnedSubject))
+	}
+	if featured != nil {
+		data.Set("featured", strconv.FormatBool(*featured))
+	}
 	data.Set("html", content)
 
 	endpoint := fmt.Sprintf("%s/admin/articles/%s", cfg.URL, id)
@@ -236,11 +280,35 @@ func listNicknames() error {
 			status = green(fmt.Sprintf("id=%d", meta.ArticleID))
 		}
 
+		if meta.PublishAt != "" {
+			status += " " + cyan("publish_at="+meta.PublishAt)
+		}
+
+		if meta.Lang != "" {
+			status += " " + cyan("lang="+meta.Lang)
+		}
+
+		if meta.TranslationOf != 0 {
+			status += " " + cyan(fmt.Sprintf("translation_of=%d", meta.TranslationOf))
+		}
+
+		if meta.PinnedHome != nil && *meta.PinnedHome {
+			status += " " + cyan("pinned_home")
+		}
+
+		if meta.PinnedSubject != nil && *meta.PinnedSubject {
+			status += " " + cyan("pinned_subject")
+		}
+
+		if meta.Featured != nil && *meta.Featured {
+			status += " " + cyan("featured")
+		}
+
 		fmt.Printf(
 			"%s  %s  %s  %s\n",
 			bold(name),
 			cyan(meta.Title),
-			fmt.Sprintf("(subject=%d, public=%t)", meta.SubjectID, meta.IsPublic),
+			fmt.Sprintf("(subject=%d, author=%d, public=%t)", meta.SubjectID, meta.AuthorID, meta.IsPublic),
 			status,
 		)
 	}
@@ -281,6 +349,39 @@ func listSubjects() error {
 	return nil
 }
 
+func listAuthors() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/authors", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+    resp, err := httpClient.Do(req)
+	
+    if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
 func listArticles() error {
 	cfg, err := loadConfig()
 	if err != nil {
@@ -313,11 +414,90 @@ func listArticles() error {
 	return nil
 }
 
+// searchArticles prints the articles matching query, best first, with
+// the nicknames pointing at them.
+func searchArticles(query string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/search?q="+url.QueryEscape(query), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	store, err := loadNicknames()
+	if err != nil {
+		return err
+	}
+
+	nicknames := make(map[string][]string)
+	for name, meta := range store {
+		if meta.ArticleID != 0 {
+			id := strconv.FormatInt(meta.ArticleID, 10)
+			nicknames[id] = append(nicknames[id], name)
+		}
+	}
+
+	lines := strings.TrimSpace(string(body))
+	if lines == "" {
+		fmt.Println("No article matches.")
+		return nil
+	}
+
+	// ID  TITLE  NICKNAMES
+	for _, line := range strings.Split(lines, "\n") {
+		id, title, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		names := nicknames[id]
+		sort.Strings(names)
+
+		fmt.Printf("%s  %s  %s\n", bold(id), cyan(title), strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
 type ArticleMeta struct {
 	Title     string `json:"title"`
 	SubjectID int64  `json:"subject_id"`
+	AuthorID  int64  `json:"author_id,omitempty"` // 0: the server's default author
 	IsPublic  bool   `json:"is_public"`
-	ArticleID int64  `json:"article_id,omitempty"`
+	PublishAt string `json:"publish_at,omitempty"` // YYYY-MM-DDTHH:MM, server time
+	Lang      string `json:"lang,omitempty"`       // "": the server's default language
+
+	// TranslationOf is the id of an article this one translates.
+	TranslationOf int64 `json:"translation_of,omitempty"`
+
+	// PinnedHome, PinnedSubject and Featured are only sent when set, so
+	// toggles made in the admin survive a publish from a nickname that
+	// never set them.
+	PinnedHome    *bool `json:"pinned_home,omitempty"`
+	PinnedSubject *bool `json:"pinned_subject,omitempty"`
+	Featured      *bool `json:"featured,omitempty"`
+
+	ArticleID int64 `json:"article_id,omitempty"`
 }
 
 type NicknameStore map[string]ArticleMeta
@@ -372,7 +552,11 @@ func saveNicknames(store NicknameStore) error {
 func createNickname(name string, 
                     title string, 
                     subjectID int64, 
-                    isPublic bool) error {
+                    authorID int64,
+                    isPublic bool,
+                    publishAt string,
+                    lang string,
+                    translationOf int64) error {
 	store, err := loadNicknames()
 	if err != nil {
 		return err
@@ -385,7 +569,12 @@ func createNickname(name string,
 	store[name] = ArticleMeta{
 		Title:     title,
 		SubjectID: subjectID,
+		AuthorID:  authorID,
 		IsPublic:  isPublic,
+		PublishAt: publishAt,
+		Lang:      lang,
+
+		TranslationOf: translationOf,
 	}
 
 	return saveNicknames(store)
@@ -474,6 +663,135 @@ func renameNickname(oldName, newName string) error {
 	return saveNicknames(store)
 }
 
+// publishedNickname returns the article id behind a nickname, which must
+// have been published.
+func publishedNickname(name string) (int64, error) {
+	meta, err := getNickname(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if meta.ArticleID == 0 {
+		return 0, fmt.Errorf("nickname %s is not published", name)
+	}
+
+	return meta.ArticleID, nil
+}
+
+func historyNickname(name string) error {
+	id, err := publishedNickname(name)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/admin/api/history/%d", cfg.URL, id), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	// REV  TIME  ACTOR  VISIBILITY  TITLE, latest first
+	for i, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		fields := strings.SplitN(line, "\t", 5)
+		if len(fields) != 5 {
+			continue
+		}
+
+		current := ""
+		if i == 0 {
+			current = " " + green("(current)")
+		}
+
+		fmt.Printf("%s  %s  %-6s  %-7s  %s%s\n",
+			bold(fields[0]), fields[1], fields[2], fields[3], cyan(fields[4]), current)
+	}
+
+	return nil
+}
+
+// restoreNickname restores revision rev of the article behind name, then
+// brings the nickname and its local file in line with it, so the next
+// publish does not undo the restore.
+func restoreNickname(name string, rev int64) error {
+	id, err := publishedNickname(name)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/admin/restore/%d/%d", cfg.URL, id, rev), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	// SUBJECT_ID  IS_PUBLIC  TITLE
+	fields := strings.SplitN(strings.TrimSpace(string(body)), "\t", 3)
+	if len(fields) != 3 {
+		return fmt.Errorf("unexpected server reply %q", string(body))
+	}
+
+	subjectID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	isPublic, err := strconv.ParseBool(fields[1])
+	if err != nil {
+		return err
+	}
+
+	title := fields[2]
+
+	if err := editNickname(name, &title, &subjectID, nil, &isPublic, nil, nil, nil, nil, nil, nil); err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(name + ".md")
+	return importContent(id, name, statErr == nil)
+}
+
 func importNickname(articleID int64, nickname string) error {
 	cfg, err := loadConfig()
 	if err != nil {
@@ -504,8 +822,11 @@ func importNickname(articleID int64, nickname string) error {
 		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
 	}
 
+	// title, subject_id, is_public, then author_id, publish_at, lang,
+	// translation_group, pinned_home, pinned_subject and featured on newer
+	// servers
 	parts := strings.Split(strings.TrimSpace(string(body)), "\t")
-	if len(parts) != 3 {
+	if len(parts) < 3 || len(parts) > 10 {
 		return fmt.Errorf("invalid server response: %s", string(body))
 	}
 
@@ -521,6 +842,63 @@ func importNickname(articleID int64, nickname string) error {
 		return fmt.Errorf("invalid is_public from server")
 	}
 
+	var authorID int64
+	if len(parts) >= 4 {
+		authorID, err = strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid author_id from server")
+		}
+	}
+
+	var publishAt string
+	if len(parts) >= 5 && parts[4] != "" {
+		t, err := time.Parse(time.RFC3339, parts[4])
+		if err != nil {
+			return fmt.Errorf("invalid publish_at from server")
+		}
+		publishAt = t.Format("2006-01-02T15:04")
+	}
+
+	var lang string
+	if len(parts) >= 6 && parts[5] != "en" {
+		lang = parts[5]
+	}
+
+	// the group is the id of its first article, which is what
+	// translation_of expects; the first article itself needs none
+	var translationOf int64
+	if len(parts) >= 7 {
+		translationOf, err = strconv.ParseInt(parts[6], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid translation_group from server")
+		}
+		if translationOf == articleID {
+			translationOf = 0
+		}
+	}
+
+	// servers with a single pinned flag send 9 fields, pinning on both
+	var pinnedHome, pinnedSubject, featured *bool
+	if len(parts) >= 9 {
+		flags := parts[7:]
+		if len(flags) == 2 {
+			flags = []string{flags[0], flags[0], flags[1]}
+		}
+		h, err := strconv.ParseBool(flags[0])
+		if err != nil {
+			return fmt.Errorf("invalid pinned_home from server")
+		}
+		p, err := strconv.ParseBool(flags[1])
+		if err != nil {
+			return fmt.Errorf("invalid pinned_subject from server")
+		}
+		f, err := strconv.ParseBool(flags[2])
+		if err != nil {
+			return fmt.Errorf("invalid featured from server")
+		}
+		pinnedHome, pinnedSubject, featured = &h, &p, &f
+	}
+
 	store, err := loadNicknames()
 	if err != nil {
 		return err
@@ -533,8 +911,16 @@ func importNickname(articleID int64, nickname string) error {
 	store[nickname] = ArticleMeta{
 		Title:     title,
 		SubjectID: subjectID,
+		AuthorID:  authorID,
 		IsPublic:  isPublic,
+		PublishAt: publishAt,
+		Lang:      lang,
 		ArticleID: articleID,
+
+		TranslationOf: translationOf,
+		PinnedHome:    pinnedHome,
+		PinnedSubject: pinnedSubject,
+		Featured:      featured,
 	}
 
 	return saveNicknames(store)
@@ -734,15 +1120,25 @@ func listFilesRemote() error {
 	return nil
 }
 
-func addSubject(name string) error {
+// addSubject creates a subject, nested under parentSlug unless it is
+// empty.
+func addSubject(name, parentSlug string) error {
+	data := url.Values{}
+	data.Set("subject", name)
+
+	if parentSlug != "" {
+		parentID, err := getSubjectID(parentSlug)
+		if err != nil {
+			return err
+		}
+		data.Set("parent_id", parentID)
+	}
+
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	data := url.Values{}
-	data.Set("subject", name)
-
 	req, err := http.NewRequest(
 		"POST",
 		cfg.URL+"/admin/subjects/add",
@@ -807,25 +1203,40 @@ func getSubjectID(slug string) (string, error) {
 	return strings.TrimSpace(string(body)), nil
 }
 
-func deleteSubject(slug string) error {
-
+// moveSubject nests a subject under parentSlug, or moves it to the top
+// level when parentSlug is empty.
+func moveSubject(slug, parentSlug string) error {
 	id, err := getSubjectID(slug)
 	if err != nil {
 		return err
 	}
 
+	data := url.Values{}
+	data.Set("parent_id", "0")
+
+	if parentSlug != "" {
+		parentID, err := getSubjectID(parentSlug)
+		if err != nil {
+			return err
+		}
+		data.Set("parent_id", parentID)
+	}
+
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("%s/admin/subjects/delete/%s", cfg.URL, id)
-
-	req, err := http.NewRequest("POST", url, nil)
+	req, err := http.NewRequest(
+		"POST",
+		fmt.Sprintf("%s/admin/subjects/move/%s", cfg.URL, id),
+		strings.NewReader(data.Encode()),
+	)
 	if err != nil {
 		return err
 	}
 
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("X-Statix-Token", cfg.Token)
 
 	resp, err := httpClient.Do(req)
@@ -844,13 +1255,50 @@ func deleteSubject(slug string) error {
 	return nil
 }
 
-func editNickname(name string, title *string, subjectID *int64, isPublic *bool) error {
-	store, err := loadNicknames()
+func deleteSubject(slug string) error {
+
+	id, err := getSubjectID(slug)
 	if err != nil {
 		return err
 	}
 
-	meta, exists := store[name]
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/admin/subjects/delete/%s", cfg.URL, id)
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+func editNickname(name string, title *string, subjectID *int64, authorID *int64, isPublic *bool, publishAt *string, lang *string, translationOf *int64, pinnedHome, pinnedSubject, featured *bool) error {
+	store, err := loadNicknames()
+	if err != nil {
+		return err
+	}
+
+	meta, exists := store[name]
 	if !exists {
 		return fmt.Errorf("nickname not found")
 	}
@@ -863,6 +1311,34 @@ func editNickname(name string, title *string, subjectID *int64, isPublic *bool)
 		meta.SubjectID = *subjectID
 	}
 
+	if authorID != nil {
+		meta.AuthorID = *authorID
+	}
+
+	if publishAt != nil {
+		meta.PublishAt = *publishAt
+	}
+
+	if lang != nil {
+		meta.Lang = *lang
+	}
+
+	if translationOf != nil {
+		meta.TranslationOf = *translationOf
+	}
+
+	if pinnedHome != nil {
+		meta.PinnedHome = pinnedHome
+	}
+
+	if pinnedSubject != nil {
+		meta.PinnedSubject = pinnedSubject
+	}
+
+	if featured != nil {
+		meta.Featured = featured
+	}
+
 	if isPublic != nil {
 		meta.IsPublic = *isPublic
 	}
@@ -873,8 +1349,17 @@ func editNickname(name string, title *string, subjectID *int64, isPublic *bool)
 }
 
 func renameSubject(oldSlug, newName string) error {
+	data := url.Values{}
+	data.Set("subject", newName)
+
+	return editSubject(oldSlug, data)
+}
+
+// editSubject posts subject fields to the admin; only the fields set in
+// data are changed.
+func editSubject(slug string, data url.Values) error {
 
-	id, err := getSubjectID(oldSlug)
+	id, err := getSubjectID(slug)
 	if err != nil {
 		return err
 	}
@@ -884,52 +1369,408 @@ func renameSubject(oldSlug, newName string) error {
 		return err
 	}
 
-	data := url.Values{}
 	data.Set("subject_id", id)
-	data.Set("subject", newName)
 
-	req, err := http.NewRequest(
-		"POST",
-		cfg.URL+"/admin/subjects/edit",
-		strings.NewReader(data.Encode()),
-	)
-	if err != nil {
-		return err
+	req, err := http.NewRequest(
+		"POST",
+		cfg.URL+"/admin/subjects/edit",
+		strings.NewReader(data.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+func dumpDB() error {
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	url := cfg.URL + "/admin/dump"
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	filename := fmt.Sprintf(
+		"dump-%s.ndjson",
+		time.Now().Format("2006-01-02"),
+	)
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fmt.Println("Downloading database dump...")
+
+	_, err = io.Copy(file, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Saved to", filename)
+
+	return nil
+}
+
+// restoreDB replaces the blog's database with the dump in path, as
+// written by dumpDB. The server checks the whole file before loading it.
+func restoreDB(path string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest("POST", cfg.URL+"/admin/dump/restore", f)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+// exportSite saves the blog's site archive, database, uploaded files and
+// themes, to path, or to site-DATE.tar.gz when path is empty.
+func exportSite(path string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/archive", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	if path == "" {
+		path = fmt.Sprintf("site-%s.tar.gz", time.Now().Format("2006-01-02"))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Downloading site archive...")
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Println("Saved to", path)
+
+	return nil
+}
+
+// importSite loads the site archive in path, as written by exportSite,
+// into the blog. The server checks the whole archive before applying it.
+func importSite(path string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest("POST", cfg.URL+"/admin/archive/import", f)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+func BuildAll() error {
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", cfg.URL+"/admin/build_all", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+    if resp.StatusCode != http.StatusOK &&
+       resp.StatusCode != http.StatusSeeOther {
+        return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+    }
+
+    return nil
+}
+
+func dryRunBuild() error {
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/dry_run", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+func checkLinks() error {
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/check", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
+
+func listPages() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/pages", nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Statix-Token", cfg.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		fmt.Println("No pages.")
+		return nil
+	}
+
+	for _, line := range lines {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) != 4 {
+			continue
+		}
+
+		menu := "-"
+		if parts[2] != "0" {
+			menu = parts[2]
+		}
+
+		visibility := green("public")
+		if parts[3] != "true" {
+			visibility = yellow("private")
+		}
+
+		fmt.Printf("%s  %s  menu:%s  %s\n", bold(parts[0]), parts[1], menu, visibility)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("X-Statix-Token", cfg.Token)
+	return nil
+}
 
-	resp, err := httpClient.Do(req)
+// pageHTML reads a page body from disk, converting Markdown like publish.
+func pageHTML(filePath string) (string, error) {
+	raw, err := os.ReadFile(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	content := string(raw)
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	if strings.HasSuffix(strings.ToLower(filePath), ".md") {
+		htmlContent, err := mdtostatix.MarkdownToStatixHTML(content)
+		if err != nil {
+			return "", fmt.Errorf("markdown conversion failed: %w", err)
+		}
+		content = htmlContent
 	}
 
-	fmt.Print(string(body))
-	return nil
+	return content, nil
 }
 
-func dumpDB() error {
-
+// savePage posts page fields to the admin; only the fields set in data
+// are changed on edit.
+func savePage(endpoint string, data url.Values) error {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	url := cfg.URL + "/admin/dump"
-
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("POST", cfg.URL+endpoint, strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
 
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("X-Statix-Token", cfg.Token)
 
 	resp, err := httpClient.Do(req)
@@ -938,42 +1779,26 @@ func dumpDB() error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
-	}
-
-	filename := fmt.Sprintf(
-		"dump-%s.sql",
-		time.Now().Format("2006-01-02"),
-	)
-
-	file, err := os.Create(filename)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	fmt.Println("Downloading database dump...")
 
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
-		return err
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
 	}
 
-	fmt.Println("Saved to", filename)
-
+	fmt.Print(string(body))
 	return nil
 }
 
-func BuildAll() error {
-
+func getPage(slug string) error {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", cfg.URL+"/admin/build_all", nil)
+	req, err := http.NewRequest("GET", cfg.URL+"/admin/api/pages/"+url.PathEscape(slug), nil)
 	if err != nil {
 		return err
 	}
@@ -991,12 +1816,16 @@ func BuildAll() error {
 		return err
 	}
 
-    if resp.StatusCode != http.StatusOK &&
-       resp.StatusCode != http.StatusSeeOther {
-        return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
-    }
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s:\n%s", resp.Status, string(body))
+	}
 
-    return nil
+	fmt.Print(string(body))
+	return nil
+}
+
+func deletePage(slug string) error {
+	return savePage("/admin/pages/delete/"+url.PathEscape(slug), url.Values{})
 }
 
 func usage() {
@@ -1005,24 +1834,39 @@ func usage() {
 	fmt.Println("Commands:")
 	fmt.Println("  set-credentials --url URL --password TOKEN --server_username SERVERUSERNAME --internal_location BLOGPATHONSERVER")
 	fmt.Println("  publish --file FILE -m MESSAGE")
-	fmt.Println("  nickname create --title TITLE --subject_id ID --is_public true|false NAME")
+	fmt.Println("  nickname create --title TITLE --subject_id ID [--author_id ID] --is_public true|false [--publish_at YYYY-MM-DDTHH:MM] [--lang LANG] [--translation_of ID] NAME")
     fmt.Println("  nickname import ARTICLE_ID NAME")
     fmt.Println("  nickname import-content [--markdown] ARTICLE_ID NAME")
-    fmt.Println("  nickname edit [--title TITLE] [--subject_id ID] [--is_public true|false] NAME")
+    fmt.Println("  nickname edit [--title TITLE] [--subject_id ID] [--author_id ID] [--is_public true|false] [--publish_at YYYY-MM-DDTHH:MM] [--lang LANG] [--translation_of ID] [--pinned true|false] [--pinned_home true|false] [--pinned_subject true|false] [--featured true|false] NAME")
 	fmt.Println("  nickname remove [--sync] [-m MESSAGE] NAME")
     fmt.Println("  nickname list")
     fmt.Println("  nickname rename OLD_NAME NEW_NAME")
+    fmt.Println("  nickname history NAME")
+    fmt.Println("  nickname restore NAME REV")
     fmt.Println("  file upload -m MESSAGE FILE...")
     fmt.Println("  file delete [-m MESSAGE] FILE")
     fmt.Println("  file list")
 	fmt.Println("  articles")
+	fmt.Println("  search QUERY")
 	fmt.Println("  subjects")
-    fmt.Println("  subject add NAME")
+	fmt.Println("  authors")
+    fmt.Println("  subject add [--parent PARENT] NAME")
     fmt.Println("  subject delete NAME")
     fmt.Println("  subject rename OLD_NAME NEW_NAME")
+    fmt.Println("  subject move NAME [PARENT]")
+    fmt.Println("  subject edit [--title TITLE] [--description FILE] [--cover FILE] [--position N] NAME")
+    fmt.Println("  page list")
+    fmt.Println("  page add --title TITLE [--slug SLUG] [--nav N] [--is_public true|false] --file FILE")
+    fmt.Println("  page edit [--title TITLE] [--slug SLUG] [--nav N] [--is_public true|false] [--file FILE] SLUG")
+    fmt.Println("  page get SLUG")
+    fmt.Println("  page delete SLUG")
 	fmt.Println("  dumpdb")
+	fmt.Println("  restore [--yes] FILE")
+	fmt.Println("  export [FILE]")
+	fmt.Println("  import [--yes] FILE")
     fmt.Println("  rsync [-m MESSAGE] FOLDER")
-    fmt.Println("  build")
+    fmt.Println("  build [--dry-run]")
+    fmt.Println("  check")
 	fmt.Println("  completion [bash|zsh]")
 	fmt.Println()
 }
@@ -1060,6 +1904,17 @@ func main() {
 		fmt.Println("Credentials saved.")
 
     case "build":
+        cmd := flag.NewFlagSet("build", flag.ExitOnError)
+        dryRun := cmd.Bool("dry-run", false, "Show what a build would change without writing it")
+
+        cmd.Parse(os.Args[2:])
+
+        if *dryRun {
+            if err := dryRunBuild(); err != nil {
+                fmt.Println("Error: ", err)
+            }
+            return
+        }
 
         if err := BuildAll(); err != nil {
             fmt.Println("Error: ", err)
@@ -1067,6 +1922,13 @@ func main() {
         }
         fmt.Println("Blog built successfully")
 
+    case "check":
+
+        if err := checkLinks(); err != nil {
+            fmt.Println("Error: ", err)
+            return
+        }
+
 	// ---------------- publish ----------------
 
     case "publish":
@@ -1109,7 +1971,14 @@ func main() {
 				strconv.FormatInt(meta.ArticleID, 10),
 				meta.Title,
 				subIDStr,
+				meta.AuthorID,
 				publicStr,
+				meta.PublishAt,
+				meta.Lang,
+				meta.TranslationOf,
+				meta.PinnedHome,
+				meta.PinnedSubject,
+				meta.Featured,
 				*file,
 			)
 			if err != nil {
@@ -1151,7 +2020,7 @@ func main() {
 			return
 		}
 
-		newID, err := publish(meta.Title, subIDStr, publicStr, *file)
+		newID, err := publish(meta.Title, subIDStr, meta.AuthorID, publicStr, meta.PublishAt, meta.Lang, meta.TranslationOf, meta.PinnedHome, meta.PinnedSubject, meta.Featured, *file)
 		if err != nil {
 			fmt.Println("Error:", err)
 			return
@@ -1197,7 +2066,7 @@ func main() {
 
 	case "nickname":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: stx nickname [create|import|import-content|edit|remove|rename|list]")
+			fmt.Println("Usage: stx nickname [create|import|import-content|edit|remove|rename|list|history|restore]")
 			return
 		}
 
@@ -1208,12 +2077,20 @@ func main() {
         
         	title := cmd.String("title", "", "New title")
         	subjectID := cmd.String("subject_id", "", "New subject ID")
+        	authorID := cmd.String("author_id", "", "New author ID")
         	isPublic := cmd.String("is_public", "", "true|false")
+        	publishAt := cmd.String("publish_at", "", "YYYY-MM-DDTHH:MM, empty to unschedule")
+        	lang := cmd.String("lang", "", "Language, e.g. fr")
+        	translationOf := cmd.String("translation_of", "", "ID of the article this one translates")
+        	pinned := cmd.String("pinned", "", "true|false: shorthand for both --pinned_home and --pinned_subject")
+        	pinnedHome := cmd.String("pinned_home", "", "true|false: list first on the index")
+        	pinnedSubject := cmd.String("pinned_subject", "", "true|false: list first on the subject pages")
+        	featured := cmd.String("featured", "", "true|false: larger card")
         
         	cmd.Parse(os.Args[3:])
         
         	if cmd.NArg() < 1 {
-        		fmt.Println("Usage: stx nickname edit [--title ...] [--subject_id ...] [--is_public true|false] NAME")
+        		fmt.Println("Usage: stx nickname edit [--title ...] [--subject_id ...] [--author_id ...] [--is_public true|false] [--publish_at YYYY-MM-DDTHH:MM] [--lang LANG] [--translation_of ID] [--pinned true|false] [--pinned_home true|false] [--pinned_subject true|false] [--featured true|false] NAME")
         		return
         	}
         
@@ -1221,8 +2098,17 @@ func main() {
         
         	var titlePtr *string
         	var subjectPtr *int64
+        	var authorPtr *int64
         	var publicPtr *bool
+        	var publishAtPtr *string
+        	var langPtr *string
+        	var translationOfPtr *int64
+        	var pinnedHomePtr *bool
+        	var pinnedSubjectPtr *bool
+        	var featuredPtr *bool
         
+        	// Visit goes in lexical order, so --pinned_home and
+        	// --pinned_subject override the --pinned shorthand
         	cmd.Visit(func(f *flag.Flag) {
         		switch f.Name {
         
@@ -1236,6 +2122,14 @@ func main() {
         				os.Exit(1)
         			}
         			subjectPtr = &val
+
+        		case "author_id":
+        			val, err := strconv.ParseInt(*authorID, 10, 64)
+        			if err != nil {
+        				fmt.Println("Invalid author_id")
+        				os.Exit(1)
+        			}
+        			authorPtr = &val
         
         		case "is_public":
         			val, err := strconv.ParseBool(*isPublic)
@@ -1244,10 +2138,56 @@ func main() {
         				os.Exit(1)
         			}
         			publicPtr = &val
+
+        		case "publish_at":
+        			publishAtPtr = publishAt
+
+        		case "lang":
+        			langPtr = lang
+
+        		case "translation_of":
+        			val, err := strconv.ParseInt(*translationOf, 10, 64)
+        			if err != nil {
+        				fmt.Println("Invalid translation_of")
+        				os.Exit(1)
+        			}
+        			translationOfPtr = &val
+
+        		case "pinned":
+        			val, err := strconv.ParseBool(*pinned)
+        			if err != nil {
+        				fmt.Println("Invalid pinned value")
+        				os.Exit(1)
+        			}
+        			pinnedHomePtr, pinnedSubjectPtr = &val, &val
+
+        		case "pinned_home":
+        			val, err := strconv.ParseBool(*pinnedHome)
+        			if err != nil {
+        				fmt.Println("Invalid pinned_home value")
+        				os.Exit(1)
+        			}
+        			pinnedHomePtr = &val
+
+        		case "pinned_subject":
+        			val, err := strconv.ParseBool(*pinnedSubject)
+        			if err != nil {
+        				fmt.Println("Invalid pinned_subject value")
+        				os.Exit(1)
+        			}
+        			pinnedSubjectPtr = &val
+
+        		case "featured":
+        			val, err := strconv.ParseBool(*featured)
+        			if err != nil {
+        				fmt.Println("Invalid featured value")
+        				os.Exit(1)
+        			}
+        			featuredPtr = &val
         		}
         	})
         
-        	if err := editNickname(name, titlePtr, subjectPtr, publicPtr); err != nil {
+        	if err := editNickname(name, titlePtr, subjectPtr, authorPtr, publicPtr, publishAtPtr, langPtr, translationOfPtr, pinnedHomePtr, pinnedSubjectPtr, featuredPtr); err != nil {
         		fmt.Println("Error:", err)
         		return
         	}
@@ -1258,7 +2198,11 @@ func main() {
 			cmd := flag.NewFlagSet("nickname create", flag.ExitOnError)
 			title := cmd.String("title", "", "Article title")
 			subjectID := cmd.String("subject_id", "", "Subject ID")
+			authorID := cmd.Int64("author_id", 0, "Author ID (default: the server's default author)")
 			isPublic := cmd.String("is_public", "true", "Visibility")
+			publishAt := cmd.String("publish_at", "", "Publish at YYYY-MM-DDTHH:MM (server time)")
+			lang := cmd.String("lang", "", "Language, e.g. fr (default: the server's default)")
+			translationOf := cmd.Int64("translation_of", 0, "ID of the article this one translates")
 			cmd.Parse(os.Args[3:])
 
 			if cmd.NArg() < 1 {
@@ -1285,7 +2229,7 @@ func main() {
 				return
 			}
 
-			if err := createNickname(name, *title, subID, publicBool); err != nil {
+			if err := createNickname(name, *title, subID, *authorID, publicBool, *publishAt, *lang, *translationOf); err != nil {
 				fmt.Println("Error:", err)
 				return
 			}
@@ -1382,7 +2326,7 @@ func main() {
         			fmt.Println("Remote deletion failed:", err)
         			return
         		}
-        		fmt.Println("Remote article deleted on blog.")
+        		fmt.Println("Remote article moved to the trash on blog.")
 
         	} else {
         
@@ -1443,6 +2387,35 @@ func main() {
         
         	fmt.Println("Nickname renamed.")
 
+        case "history":
+        	if len(os.Args) < 4 {
+        		fmt.Println("Usage: stx nickname history NAME")
+        		return
+        	}
+
+        	if err := historyNickname(os.Args[3]); err != nil {
+        		fmt.Println("Error:", err)
+        	}
+
+        case "restore":
+        	if len(os.Args) < 5 {
+        		fmt.Println("Usage: stx nickname restore NAME REV")
+        		return
+        	}
+
+        	rev, err := strconv.ParseInt(os.Args[4], 10, 64)
+        	if err != nil {
+        		fmt.Println("Invalid revision")
+        		return
+        	}
+
+        	if err := restoreNickname(os.Args[3], rev); err != nil {
+        		fmt.Println("Error:", err)
+        		return
+        	}
+
+        	fmt.Printf("Revision %d restored; %s updated from it.\n", rev, os.Args[3])
+
 		default:
 			fmt.Println("Unknown nickname command.")
 		}
@@ -1454,6 +2427,18 @@ func main() {
 			fmt.Println("Error:", err)
 		}
 
+	// ---------------- search ----------------
+
+	case "search":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: stx search QUERY")
+			return
+		}
+
+		if err := searchArticles(strings.Join(os.Args[2:], " ")); err != nil {
+			fmt.Println("Error:", err)
+		}
+
 	// ---------------- subjects ----------------
 
 	case "subjects":
@@ -1461,11 +2446,18 @@ func main() {
 			fmt.Println("Error:", err)
 		}
 
+	// ---------------- authors ----------------
+
+	case "authors":
+		if err := listAuthors(); err != nil {
+			fmt.Println("Error:", err)
+		}
+
     // ---------------- subject ----------------
     
     case "subject":
     	if len(os.Args) < 3 {
-    		fmt.Println("Usage: stx subject [add|delete|rename]")
+    		fmt.Println("Usage: stx subject [add|delete|rename|move|edit]")
     		return
     	}
     
@@ -1473,16 +2465,22 @@ func main() {
     
     	case "add":
     		cmd := flag.NewFlagSet("subject add", flag.ExitOnError)
+    		parent := cmd.String("parent", "", "Name of the parent subject")
     		cmd.Parse(os.Args[3:])
     
     		if cmd.NArg() < 1 {
-    			fmt.Println("Usage: stx subject add NAME")
+    			fmt.Println("Usage: stx subject add [--parent PARENT] NAME")
     			return
     		}
     
     		name := cmd.Arg(0)
+
+    		parentSlug := ""
+    		if *parent != "" {
+    			parentSlug = Slugify(*parent)
+    		}
     
-    		if err := addSubject(name); err != nil {
+    		if err := addSubject(name, parentSlug); err != nil {
     			fmt.Println("Error:", err)
     			return
     		}
@@ -1524,11 +2522,170 @@ func main() {
         	}
         
         	fmt.Println("Subject renamed.")
+
+        case "edit":
+        	cmd := flag.NewFlagSet("subject edit", flag.ExitOnError)
+        	title := cmd.String("title", "", "New title")
+        	description := cmd.String("description", "", "Description file (.html or .md), empty to clear")
+        	cover := cmd.String("cover", "", "Cover image under common_files, empty to clear")
+        	position := cmd.Int("position", 0, "Position in the subject bar")
+        	cmd.Parse(os.Args[3:])
+
+        	if cmd.NArg() < 1 {
+        		fmt.Println("Usage: stx subject edit [--title TITLE] [--description FILE] [--cover FILE] [--position N] NAME")
+        		return
+        	}
+
+        	data := url.Values{}
+        	var readErr error
+
+        	cmd.Visit(func(f *flag.Flag) {
+        		switch f.Name {
+        		case "title":
+        			data.Set("subject", *title)
+        		case "description":
+        			content := ""
+        			if *description != "" {
+        				content, readErr = pageHTML(*description)
+        			}
+        			data.Set("description", content)
+        		case "cover":
+        			data.Set("cover", *cover)
+        		case "position":
+        			data.Set("position", strconv.Itoa(*position))
+        		}
+        	})
+
+        	if readErr != nil {
+        		fmt.Println("Error:", readErr)
+        		return
+        	}
+
+        	if len(data) == 0 {
+        		fmt.Println("Nothing to change.")
+        		return
+        	}
+
+        	if err := editSubject(Slugify(cmd.Arg(0)), data); err != nil {
+        		fmt.Println("Error:", err)
+        		return
+        	}
+
+        case "move":
+        	cmd := flag.NewFlagSet("subject move", flag.ExitOnError)
+        	cmd.Parse(os.Args[3:])
+
+        	if cmd.NArg() < 1 {
+        		fmt.Println("Usage: stx subject move NAME [PARENT]")
+        		return
+        	}
+
+        	parentSlug := ""
+        	if cmd.NArg() > 1 {
+        		parentSlug = Slugify(cmd.Arg(1))
+        	}
+
+        	if err := moveSubject(Slugify(cmd.Arg(0)), parentSlug); err != nil {
+        		fmt.Println("Error:", err)
+        		return
+        	}
         
         default:
         	fmt.Println("Unknown subject command.")
         }
 
+    // ---------------- page --------------------
+
+    case "page":
+    	if len(os.Args) < 3 {
+    		fmt.Println("Usage: stx page [list|add|edit|get|delete]")
+    		return
+    	}
+
+    	switch os.Args[2] {
+
+    	case "list":
+    		if err := listPages(); err != nil {
+    			fmt.Println("Error:", err)
+    			return
+    		}
+
+    	case "add", "edit":
+    		cmd := flag.NewFlagSet("page "+os.Args[2], flag.ExitOnError)
+    		title := cmd.String("title", "", "Page title")
+    		slug := cmd.String("slug", "", "Page slug, served at /SLUG.html")
+    		nav := cmd.String("nav", "", "Position in the topbar menu, 0 to hide it")
+    		isPublic := cmd.String("is_public", "", "true|false")
+    		file := cmd.String("file", "", "HTML or Markdown file")
+    		cmd.Parse(os.Args[3:])
+
+    		data := url.Values{}
+    		if *title != "" {
+    			data.Set("title", *title)
+    		}
+    		if *slug != "" {
+    			data.Set("slug", *slug)
+    		}
+    		if *nav != "" {
+    			data.Set("nav_order", *nav)
+    		}
+    		if *isPublic != "" {
+    			data.Set("is_public", *isPublic)
+    		}
+    		if *file != "" {
+    			content, err := pageHTML(*file)
+    			if err != nil {
+    				fmt.Println("Error:", err)
+    				return
+    			}
+    			data.Set("html", content)
+    		}
+
+    		endpoint := "/admin/pages/new"
+    		if os.Args[2] == "add" {
+    			if *title == "" || *file == "" {
+    				fmt.Println("Usage: stx page add --title TITLE [--slug SLUG] [--nav N] [--is_public true|false] --file FILE")
+    				return
+    			}
+    		} else {
+    			if cmd.NArg() < 1 {
+    				fmt.Println("Usage: stx page edit [--title TITLE] [--slug SLUG] [--nav N] [--is_public true|false] [--file FILE] SLUG")
+    				return
+    			}
+    			endpoint = "/admin/pages/edit/" + url.PathEscape(cmd.Arg(0))
+    		}
+
+    		if err := savePage(endpoint, data); err != nil {
+    			fmt.Println("Error:", err)
+    			return
+    		}
+
+    	case "get":
+    		if len(os.Args) < 4 {
+    			fmt.Println("Usage: stx page get SLUG")
+    			return
+    		}
+
+    		if err := getPage(os.Args[3]); err != nil {
+    			fmt.Println("Error:", err)
+    			return
+    		}
+
+    	case "delete":
+    		if len(os.Args) < 4 {
+    			fmt.Println("Usage: stx page delete SLUG")
+    			return
+    		}
+
+    		if err := deletePage(os.Args[3]); err != nil {
+    			fmt.Println("Error:", err)
+    			return
+    		}
+
+    	default:
+    		fmt.Println("Unknown page command.")
+    	}
+
     // ---------------- file --------------------
 
     case "file":
@@ -1659,6 +2816,75 @@ func main() {
 	    	fmt.Println("Error:", err)
 	    }
 
+    // ------------ Restore -------------------
+
+    case "restore":
+        cmd := flag.NewFlagSet("restore", flag.ExitOnError)
+        yes := cmd.Bool("yes", false, "Do not ask for confirmation")
+
+        cmd.Parse(os.Args[2:])
+
+        if cmd.NArg() < 1 {
+            fmt.Println("Usage: stx restore [--yes] FILE")
+            return
+        }
+
+        if !*yes {
+            fmt.Print("This replaces every article, subject, author and page on the blog. Continue? [y/N] ")
+
+            answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+            if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+                fmt.Println("Aborted.")
+                return
+            }
+        }
+
+        if err := restoreDB(cmd.Arg(0)); err != nil {
+            fmt.Println("Error:", err)
+        }
+
+    // ------------ Export / Import -----------
+
+    case "export":
+        if len(os.Args) > 3 {
+            fmt.Println("Usage: stx export [FILE]")
+            return
+        }
+
+        path := ""
+        if len(os.Args) == 3 {
+            path = os.Args[2]
+        }
+
+        if err := exportSite(path); err != nil {
+            fmt.Println("Error:", err)
+        }
+
+    case "import":
+        cmd := flag.NewFlagSet("import", flag.ExitOnError)
+        yes := cmd.Bool("yes", false, "Do not ask for confirmation")
+
+        cmd.Parse(os.Args[2:])
+
+        if cmd.NArg() < 1 {
+            fmt.Println("Usage: stx import [--yes] FILE")
+            return
+        }
+
+        if !*yes {
+            fmt.Print("This replaces the blog's content and adds the archive's files and themes. Continue? [y/N] ")
+
+            answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+            if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+                fmt.Println("Aborted.")
+                return
+            }
+        }
+
+        if err := importSite(cmd.Arg(0)); err != nil {
+            fmt.Println("Error:", err)
+        }
+
     // ------------- Completion Script --------
 
     case "rsync":
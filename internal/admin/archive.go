@@ -0,0 +1,430 @@
+package admin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"blog/internal/db"
+)
+
+// A site archive is a .tar.gz holding manifest.json, database.ndjson (a
+// db.Export), the uploaded files under common_files/, and every theme
+// under themes/ with its favicon under favicons/. The manifest names the
+// selected theme and font and lists every other entry with its size and
+// SHA-256; an import refuses an archive that does not match it.
+const (
+	archiveFormat  = "statix-site"
+	archiveVersion = 1
+
+	archiveManifest = "manifest.json"
+	archiveDatabase = "database.ndjson"
+
+	// maxArchiveUnpacked bounds what an import writes to disk: gzip
+	// makes a small upload unpack to far more.
+	maxArchiveUnpacked = 8 << 30
+)
+
+// archiveDirs maps the directories of an archive to where they live
+// under AssetsDir.
+var archiveDirs = map[string]string{
+	"common_files": "common_files",
+	"themes":       filepath.Join("css", "themes"),
+	"favicons":     "favicons",
+}
+
+type ArchiveManifest struct {
+	Format    string        `json:"format"`
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Theme     string        `json:"theme,omitempty"`
+	Font      string        `json:"font,omitempty"`
+	Files     []ArchiveFile `json:"files"`
+}
+
+type ArchiveFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// archiveSource is an entry to write: its archive path and where its
+// bytes come from.
+type archiveSource struct {
+	path string
+	open func() (io.ReadCloser, error)
+}
+
+// writeArchive writes the site archive of the database export and the
+// assets to w.
+func (s *Server) writeArchive(w io.Writer, export *db.Export) error {
+	sources := []archiveSource{{
+		path: archiveDatabase,
+		open: func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				_, err := export.WriteTo(pw)
+				pw.CloseWithError(err)
+			}()
+			return pr, nil
+		},
+	}}
+
+	dirs := make([]string, 0, len(archiveDirs))
+	for dir := range archiveDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		root := filepath.Join(s.AssetsDir, archiveDirs[dir])
+
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) && p == root {
+					return fs.SkipAll
+				}
+				return err
+			}
+
+			// hidden files are uploads in progress, as in the file list
+			if strings.HasPrefix(d.Name(), ".") && p != root {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+
+			sources = append(sources, archiveSource{
+				path: path.Join(dir, filepath.ToSlash(rel)),
+				open: func() (io.ReadCloser, error) { return os.Open(p) },
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest := ArchiveManifest{
+		Format:    archiveFormat,
+		Version:   archiveVersion,
+		CreatedAt: export.Header.CreatedAt,
+		Theme:     s.currentTheme(),
+		Font:      s.currentFont(),
+	}
+
+	// a first pass sums the entries, so the manifest can come first
+	for _, src := range sources {
+		f, err := src.open()
+		if err != nil {
+			return err
+		}
+
+		h := sha256.New()
+		n, err := io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", src.path, err)
+		}
+
+		manifest.Files = append(manifest.Files, ArchiveFile{
+			Path:   src.path,
+			Size:   n,
+			SHA256: hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, archiveManifest, int64(len(data)), strings.NewReader(string(data))); err != nil {
+		return err
+	}
+
+	for i, src := range sources {
+		f, err := src.open()
+		if err != nil {
+			return err
+		}
+
+		// a file that changed since it was summed would not match
+		err = writeTarFile(tw, src.path, manifest.Files[i].Size, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", src.path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.CopyN(tw, r, size)
+	return err
+}
+
+// readArchive unpacks a site archive into dir and checks it against its
+// manifest: format and version, and the size and checksum of every
+// entry, none missing or extra. The manifest comes first and is checked
+// before anything is written: its theme must be installed or archived,
+// its font installed, and its entries may add up to maxArchiveUnpacked.
+// It returns the manifest and the export it holds, itself checked by
+// db.ReadExport.
+func (s *Server) readArchive(r io.Reader, dir string) (*ArchiveManifest, *db.Export, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a .tar.gz: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	var manifest *ArchiveManifest
+	sums := make(map[string]ArchiveFile)
+	sizes := make(map[string]int64)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, nil, fmt.Errorf("%s: not a regular file", hdr.Name)
+		}
+
+		name := path.Clean(hdr.Name)
+		if !validArchivePath(name) {
+			return nil, nil, fmt.Errorf("%s: unexpected entry", hdr.Name)
+		}
+		if _, dup := sums[name]; dup {
+			return nil, nil, fmt.Errorf("%s: repeated entry", name)
+		}
+
+		if name == archiveManifest {
+			if manifest != nil || len(sums) > 0 {
+				return nil, nil, fmt.Errorf("%s is not the first entry", archiveManifest)
+			}
+			if hdr.Size > maxArchiveUnpacked {
+				return nil, nil, fmt.Errorf("%s: too large", archiveManifest)
+			}
+
+			manifest = &ArchiveManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", archiveManifest, err)
+			}
+			if err := s.checkArchiveManifest(manifest); err != nil {
+				return nil, nil, err
+			}
+			for _, f := range manifest.Files {
+				sizes[f.Path] = f.Size
+			}
+			sums[name] = ArchiveFile{}
+			continue
+		}
+
+		if manifest == nil {
+			return nil, nil, fmt.Errorf("%s is not the first entry", archiveManifest)
+		}
+		if size, ok := sizes[name]; !ok || size != hdr.Size {
+			return nil, nil, fmt.Errorf("%s: missing or does not match the manifest", name)
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, nil, err
+		}
+
+		f, err := os.Create(dst)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, h), tr)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		sums[name] = ArchiveFile{Path: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if manifest == nil {
+		return nil, nil, errors.New("not a Statix site archive: no manifest")
+	}
+	if manifest.Format != archiveFormat {
+		return nil, nil, fmt.Errorf("not a Statix site archive: format %q", manifest.Format)
+	}
+	if manifest.Version != archiveVersion {
+		return nil, nil, fmt.Errorf("archive version %d is not supported, want %d", manifest.Version, archiveVersion)
+	}
+
+	listed := make(map[string]bool)
+	for _, want := range manifest.Files {
+		if got, ok := sums[want.Path]; !ok || got != want {
+			return nil, nil, fmt.Errorf("%s: missing or does not match the manifest", want.Path)
+		}
+		listed[want.Path] = true
+	}
+	for name := range sums {
+		if name != archiveManifest && !listed[name] {
+			return nil, nil, fmt.Errorf("%s: not in the manifest", name)
+		}
+	}
+	if !listed[archiveDatabase] {
+		return nil, nil, fmt.Errorf("archive has no %s", archiveDatabase)
+	}
+
+	f, err := os.Open(filepath.Join(dir, archiveDatabase))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	export, err := db.ReadExport(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", archiveDatabase, err)
+	}
+
+	return manifest, export, nil
+}
+
+// checkArchiveManifest checks the manifest of an import before any entry
+// is unpacked: its settings, and the total size of its entries.
+func (s *Server) checkArchiveManifest(manifest *ArchiveManifest) error {
+	var total int64
+	for _, f := range manifest.Files {
+		if f.Size < 0 {
+			return fmt.Errorf("%s: negative size", f.Path)
+		}
+		total += f.Size
+		if total > maxArchiveUnpacked {
+			return fmt.Errorf("archive unpacks to more than %d bytes", int64(maxArchiveUnpacked))
+		}
+	}
+
+	// the theme may come with the archive, the font must be here
+	theme := manifest.Theme
+	for _, f := range manifest.Files {
+		if f.Path == "themes/"+theme+".css" && !strings.ContainsAny(theme, `/\`) {
+			theme = ""
+			break
+		}
+	}
+
+	return s.checkDumpSettings(theme, manifest.Font)
+}
+
+// validArchivePath reports whether name, cleaned, is the manifest, the
+// database or a file under one of archiveDirs.
+func validArchivePath(name string) bool {
+	if name == archiveManifest || name == archiveDatabase {
+		return true
+	}
+
+	top, rest, ok := strings.Cut(name, "/")
+	if !ok || rest == "" || strings.HasPrefix(rest, "../") || rest == ".." {
+		return false
+	}
+
+	_, ok = archiveDirs[top]
+	return ok
+}
+
+// installArchiveFiles copies the assets of an archive unpacked in dir
+// into AssetsDir, each file atomically. Files the archive does not hold
+// are left alone.
+func (s *Server) installArchiveFiles(dir string, manifest *ArchiveManifest) error {
+	for _, f := range manifest.Files {
+		if f.Path == archiveDatabase {
+			continue
+		}
+
+		top, rest, _ := strings.Cut(f.Path, "/")
+		dst := filepath.Join(s.AssetsDir, archiveDirs[top], filepath.FromSlash(rest))
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		if err := copyFileAtomic(filepath.Join(dir, filepath.FromSlash(f.Path)), dst); err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func copyFileAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp")
+
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
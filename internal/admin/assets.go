@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"blog"
+)
+
+// layeredFS serves files from the on-disk assets directory and falls back
+// to the defaults embedded in the binary.
+type layeredFS struct {
+	layers []fs.FS
+}
+
+func (l layeredFS) Open(name string) (fs.File, error) {
+	var firstErr error
+
+	for _, layer := range l.layers {
+		f, err := layer.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+func assetsHandler(dir string) http.Handler {
+	embedded, err := fs.Sub(blog.Assets, "assets")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.FileServer(http.FS(layeredFS{
+		layers: []fs.FS{os.DirFS(dir), embedded},
+	}))
+}
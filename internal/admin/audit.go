@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"blog/internal/model"
+)
+
+// auditedDownloads are the GET routes recorded in the audit log along
+// with every request that changes something: they hand out the whole
+// site.
+var auditedDownloads = map[string]bool{
+	"/admin/dump":         true,
+	"/admin/archive":      true,
+	"/admin/audit/export": true,
+}
+
+// auditTargets name the entity a route acts on by its next path segment,
+// e.g. "/admin/articles/12" acts on "article 12".
+var auditTargets = []struct{ prefix, kind string }{
+	{"/admin/articles/", "article"},
+	{"/admin/delete/", "article"},
+	{"/admin/pin/home/", "article"},
+	{"/admin/pin/subject/", "article"},
+	{"/admin/feature/", "article"},
+	{"/admin/restore/", "article"},
+	{"/admin/trash/restore/", "article"},
+	{"/admin/trash/purge/", "article"},
+	{"/admin/subjects/delete/", "subject"},
+	{"/admin/subjects/move/", "subject"},
+	{"/admin/authors/edit/", "author"},
+	{"/admin/authors/delete/", "author"},
+	{"/admin/pages/edit/", "page"},
+	{"/admin/pages/delete/", "page"},
+	{"/admin/files/delete/", "file"},
+}
+
+type auditKey struct{}
+
+// auditRecord is what handlers add to the audit entry of their request.
+type auditRecord struct {
+	target  string
+	summary []string
+}
+
+// statusWriter remembers the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// audit serves an authenticated request and, when it changes or exports
+// something, records it in the audit log once answered. auth is how the
+// request signed in, "session" or "token". A failure to record is
+// logged: it does not fail the request, which already happened.
+func (s *Server) audit(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, auth string) {
+	if s.Repos.Audit == nil || (r.Method == http.MethodGet || r.Method == http.MethodHead) && !auditedDownloads[r.URL.Path] {
+		next(w, r)
+		return
+	}
+
+	rec := &auditRecord{target: auditTarget(r.URL.Path)}
+	sw := &statusWriter{ResponseWriter: w}
+
+	next(sw, r.WithContext(context.WithValue(r.Context(), auditKey{}, rec)))
+
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	entry := model.AuditEntry{
+		CreatedAt: time.Now(),
+		Actor:     actor(r),
+		Auth:      auth,
+		Method:    r.Method,
+		Route:     r.URL.Path,
+		Status:    sw.status,
+		Target:    rec.target,
+		Summary:   strings.Join(rec.summary, "; "),
+		IP:        clientIP(r),
+	}
+
+	// the request happened even if its client went away meanwhile
+	if err := s.Repos.Audit.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+		log.Printf("audit: %s %s by %s: %v", entry.Method, entry.Route, entry.Actor, err)
+	}
+}
+
+func auditTarget(path string) string {
+	for _, t := range auditTargets {
+		if rest, ok := strings.CutPrefix(path, t.prefix); ok && rest != "" {
+			id, _, _ := strings.Cut(rest, "/")
+			return t.kind + " " + id
+		}
+	}
+	return ""
+}
+
+// setAuditTarget names the entity the request acts on, where the route
+// does not, e.g. an article just created.
+func setAuditTarget(r *http.Request, kind string, id any) {
+	if rec, ok := r.Context().Value(auditKey{}).(*auditRecord); ok {
+		rec.target = fmt.Sprintf("%s %v", kind, id)
+	}
+}
+
+// auditf adds a line to the summary of the request's audit entry.
+func auditf(r *http.Request, format string, args ...any) {
+	if rec, ok := r.Context().Value(auditKey{}).(*auditRecord); ok {
+		rec.summary = append(rec.summary, fmt.Sprintf(format, args...))
+	}
+}
+
+// auditChange adds "field: before → after" to the summary when the
+// value changed.
+func auditChange(r *http.Request, field string, before, after any) {
+	if b, a := fmt.Sprint(before), fmt.Sprint(after); b != a {
+		auditf(r, "%s: %s → %s", field, b, a)
+	}
+}
+
+// clientIP is the address of the client, as told by nginx when the
+// request comes through it on the loopback.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if real := r.Header.Get("X-Real-IP"); real != "" {
+			return real
+		}
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			first, _, _ := strings.Cut(fwd, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	return host
+}
+
+// auditArticle summarizes what an edit changed in an article. The body
+// is only reported by size: the revision history keeps it.
+func auditArticle(r *http.Request, before, after model.Article) {
+	auditChange(r, "title", before.Title, after.Title)
+	auditChange(r, "subject", before.SubjectId, after.SubjectId)
+	auditChange(r, "author", before.AuthorId, after.AuthorId)
+	auditChange(r, "lang", before.Lang, after.Lang)
+	auditChange(r, "public", before.IsPublic, after.IsPublic)
+	auditChange(r, "pinned_home", before.PinnedHome, after.PinnedHome)
+	auditChange(r, "pinned_subject", before.PinnedSubject, after.PinnedSubject)
+	auditChange(r, "featured", before.Featured, after.Featured)
+	auditChange(r, "publish_at", formatPublishAt(before.PublishAt), formatPublishAt(after.PublishAt))
+
+	if before.HTML != after.HTML {
+		auditf(r, "html: %d → %d bytes", len(before.HTML), len(after.HTML))
+	}
+}
+
+func formatPublishAt(t *time.Time) string {
+	if t == nil {
+		return "none"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -61,12 +61,12 @@ func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 		if publishToken != "" &&
 		   r.Header.Get("X-Statix-Token") == publishToken {
 
-			next(w, r)
+			s.audit(w, r, next, "token")
 			return
 		}
 
 		if isAuthenticated(r, s.AdminPass) {
-			next(w, r)
+			s.audit(w, r, next, "session")
 			return
 		}
 
@@ -74,6 +74,16 @@ func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// actor names who made an authenticated request, for the revision
+// history: "stx" when it carries the publish token, "admin" otherwise.
+func actor(r *http.Request) string {
+	publishToken := os.Getenv("STATIX_PUBLISH_TOKEN")
+	if publishToken != "" && r.Header.Get("X-Statix-Token") == publishToken {
+		return "stx"
+	}
+	return "admin"
+}
+
 
 
 
@@ -0,0 +1,864 @@
+package admin_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/admin"
+	"blog/internal/config"
+	"blog/internal/db"
+	"blog/internal/db/memdb"
+	"blog/internal/generator"
+	"blog/internal/model"
+)
+
+const testToken = "e2e-token"
+
+// post sends form with the publish token and returns the status and body.
+func post(t *testing.T, ts *httptest.Server, path string, form url.Values) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Statix-Token", testToken)
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// get fetches path with the publish token and returns the status and body.
+func get(t *testing.T, ts *httptest.Server, path string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Statix-Token", testToken)
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// newTestServer runs the admin over memdb, building into a temporary
+// directory.
+func newTestServer(t *testing.T) (*admin.Server, *httptest.Server) {
+	t.Helper()
+	t.Setenv("STATIX_PUBLISH_TOKEN", testToken)
+
+	dir := t.TempDir()
+
+	srv := admin.NewServerWithRepos(memdb.New(), config.Config{AdminPass: "secret"}, generator.Plugins{})
+	srv.OutDir = filepath.Join(dir, "dist")
+	srv.NginxConf = filepath.Join(dir, "error_pages.conf")
+
+	ts := httptest.NewServer(srv.Routes())
+	t.Cleanup(ts.Close)
+
+	return srv, ts
+}
+
+// newSQLiteServer starts a server on a migrated SQLite database, with
+// its output and assets under dir.
+func newSQLiteServer(t *testing.T, dir string) (*admin.Server, *httptest.Server) {
+	t.Helper()
+
+	conn, err := db.Open(db.Config{Driver: db.SQLite, Path: filepath.Join(dir, "statix.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if _, err := (&db.Migrator{DB: conn, Driver: db.SQLite}).Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := admin.NewServer(conn, config.Config{AdminPass: "secret", DB: db.Config{Driver: db.SQLite}}, generator.Plugins{})
+	srv.OutDir = filepath.Join(dir, "dist")
+	srv.AssetsDir = filepath.Join(dir, "assets")
+	srv.NginxConf = filepath.Join(dir, "error_pages.conf")
+
+	ts := httptest.NewServer(srv.Routes())
+	t.Cleanup(ts.Close)
+
+	return srv, ts
+}
+
+func TestAdminWithoutDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ts := newTestServer(t)
+
+	if code, body := post(t, ts, "/admin/subjects/add", url.Values{"subject": {"Go Tips"}}); code != http.StatusOK {
+		t.Fatalf("add subject: %d %s", code, body)
+	}
+
+	subjectID, err := srv.Repos.Subjects.GetIDBySlug(ctx, "go-tips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := strconv.FormatInt(subjectID, 10)
+
+	code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Hello World"},
+		"subject_id": {id},
+		"is_public":  {"true"},
+		"html":       {"<p>hello from memory</p>"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+
+	if code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"hello world"},
+		"subject_id": {id},
+		"is_public":  {"true"},
+	}); code != http.StatusConflict {
+		t.Errorf("duplicate title: %d %s, want 409", code, body)
+	}
+
+	// build_all redirects to the article list once the site is written
+	if code, body := post(t, ts, "/admin/build_all", nil); code != http.StatusOK {
+		t.Fatalf("build all: %d %s", code, body)
+	}
+
+	page, err := os.ReadFile(filepath.Join(srv.OutDir, "articles", "hello-world.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "hello from memory") {
+		t.Error("article page lacks its content")
+	}
+
+	for _, name := range []string{"index.html", "sub/go-tips.html", "sub/go-tips.epub", "sitemap.xml", "404.html"} {
+		if _, err := os.Stat(filepath.Join(srv.OutDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s not built: %v", name, err)
+		}
+	}
+
+	if code, body := post(t, ts, "/admin/subjects/delete/"+id, nil); code != http.StatusConflict {
+		t.Errorf("delete used subject: %d %s, want 409", code, body)
+	}
+}
+
+// TestMoveArticleSubject moves an article out of a nested subject: the
+// old subject and its parent must stop listing it.
+func TestMoveArticleSubject(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ts := newTestServer(t)
+
+	subjectID := func(slug string) string {
+		t.Helper()
+		id, err := srv.Repos.Subjects.GetIDBySlug(ctx, slug)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strconv.FormatInt(id, 10)
+	}
+
+	for _, form := range []url.Values{
+		{"subject": {"Go"}},
+		{"subject": {"Rust"}},
+	} {
+		if code, body := post(t, ts, "/admin/subjects/add", form); code != http.StatusOK {
+			t.Fatalf("add subject: %d %s", code, body)
+		}
+	}
+	if code, body := post(t, ts, "/admin/subjects/add", url.Values{"subject": {"Concurrency"}, "parent_id": {subjectID("go")}}); code != http.StatusOK {
+		t.Fatalf("add subject: %d %s", code, body)
+	}
+
+	code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Channels"},
+		"subject_id": {subjectID("concurrency")},
+		"is_public":  {"true"},
+		"html":       {"<p>chan</p>"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+	id := strings.TrimSpace(body)
+
+	if code, body := post(t, ts, "/admin/build_all", nil); code != http.StatusOK {
+		t.Fatalf("build all: %d %s", code, body)
+	}
+
+	if code, body := post(t, ts, "/admin/articles/"+id, url.Values{
+		"title":      {"Channels"},
+		"subject_id": {subjectID("rust")},
+		"is_public":  {"true"},
+		"html":       {"<p>chan</p>"},
+	}); code != http.StatusOK {
+		t.Fatalf("edit article: %d %s", code, body)
+	}
+
+	for slug, listed := range map[string]bool{"go": false, "concurrency": false, "rust": true} {
+		page, err := os.ReadFile(filepath.Join(srv.OutDir, "sub", slug+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(page), "/articles/channels.html") != listed {
+			t.Errorf("sub/%s.html lists the moved article: %v, want %v", slug, !listed, listed)
+		}
+	}
+}
+
+func TestArticleHistory(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ts := newTestServer(t)
+
+	code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Notes"},
+		"subject_id": {"1"},
+		"is_public":  {"false"},
+		"html":       {"<p>first draft</p>\n"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+	id := strings.TrimSpace(body)
+
+	if code, body := post(t, ts, "/admin/articles/"+id, url.Values{
+		"title":      {"Notes"},
+		"subject_id": {"1"},
+		"is_public":  {"true"},
+		"html":       {"<p>bad paste</p>\n"},
+	}); code != http.StatusOK {
+		t.Fatalf("edit article: %d %s", code, body)
+	}
+
+	code, body = get(t, ts, "/admin/api/history/"+id)
+	if code != http.StatusOK {
+		t.Fatalf("history: %d %s", code, body)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "\tstx\tpublic\tNotes") {
+		t.Fatalf("history:\n%s", body)
+	}
+	first, _, _ := strings.Cut(lines[1], "\t")
+
+	if code, body := get(t, ts, "/admin/history/"+id); code != http.StatusOK || !strings.Contains(body, "bad paste") {
+		t.Errorf("history page: %d\n%s", code, body)
+	}
+	if code, body := get(t, ts, "/admin/history/"+id+"?rev="+first); code != http.StatusOK || !strings.Contains(body, "the first") {
+		t.Errorf("history page of the first revision: %d\n%s", code, body)
+	}
+
+	code, body = post(t, ts, "/admin/restore/"+id+"/"+first, nil)
+	if code != http.StatusOK || body != "1\tfalse\tNotes\n" {
+		t.Fatalf("restore: %d %q", code, body)
+	}
+
+	articleID, _ := strconv.ParseInt(id, 10, 64)
+	a, err := srv.Repos.Articles.GetByID(ctx, articleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.HTML != "<p>first draft</p>\n" || a.IsPublic {
+		t.Errorf("restored article: %+v", a)
+	}
+
+	if revisions, _ := srv.Repos.Articles.ListRevisions(ctx, articleID); len(revisions) != 3 {
+		t.Errorf("restoring recorded %d revisions, want 3", len(revisions))
+	}
+
+	if code, _ := post(t, ts, "/admin/restore/"+id+"/999", nil); code != http.StatusNotFound {
+		t.Errorf("restore of an unknown revision: %d, want 404", code)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ts := newTestServer(t)
+
+	code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Doomed"},
+		"subject_id": {"1"},
+		"is_public":  {"true"},
+		"html":       {"<p>still here</p>"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+	id := strings.TrimSpace(body)
+	page := filepath.Join(srv.OutDir, "articles", "doomed.html")
+
+	if code, body := post(t, ts, "/admin/delete/"+id, nil); code != http.StatusOK {
+		t.Fatalf("delete: %d %s", code, body)
+	}
+	if _, err := os.Stat(page); !os.IsNotExist(err) {
+		t.Errorf("page of a trashed article: %v", err)
+	}
+	if code, body := get(t, ts, "/admin/trash"); code != http.StatusOK || !strings.Contains(body, "Doomed") {
+		t.Errorf("trash page: %d\n%s", code, body)
+	}
+
+	// the title stays taken while the article is in the trash
+	if code, _ := post(t, ts, "/admin/new", url.Values{"title": {"Doomed"}, "subject_id": {"1"}, "is_public": {"false"}}); code != http.StatusConflict {
+		t.Errorf("reusing a trashed title: %d, want 409", code)
+	}
+
+	if code, body := post(t, ts, "/admin/trash/restore/"+id, nil); code != http.StatusOK {
+		t.Fatalf("restore: %d %s", code, body)
+	}
+	if b, err := os.ReadFile(page); err != nil || !strings.Contains(string(b), "still here") {
+		t.Errorf("page of a restored article: %v", err)
+	}
+
+	// live articles cannot be purged
+	if code, _ := post(t, ts, "/admin/trash/purge/"+id, nil); code != http.StatusNotFound {
+		t.Errorf("purge of a live article: %d, want 404", code)
+	}
+
+	if code, body := post(t, ts, "/admin/delete/"+id, nil); code != http.StatusOK {
+		t.Fatalf("delete: %d %s", code, body)
+	}
+	if code, body := post(t, ts, "/admin/trash/purge/"+id, nil); code != http.StatusOK {
+		t.Fatalf("purge: %d %s", code, body)
+	}
+	if trash, _ := srv.Repos.Articles.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("trash after purging: %+v", trash)
+	}
+	if exists, _ := srv.Repos.Articles.ExistsByTitleRaw(ctx, "Doomed"); exists {
+		t.Error("a purged article kept its title")
+	}
+}
+
+// TestDeleteSubjectWithTrash deletes a subject whose only article is in
+// the trash: the article moves to the default subject.
+func TestDeleteSubjectWithTrash(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("STATIX_PUBLISH_TOKEN", testToken)
+
+	for name, start := range map[string]func(t *testing.T) (*admin.Server, *httptest.Server){
+		"memdb":  newTestServer,
+		"sqlite": func(t *testing.T) (*admin.Server, *httptest.Server) { return newSQLiteServer(t, t.TempDir()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv, ts := start(t)
+
+			subjectID, err := srv.Repos.Subjects.Create(ctx, "Doomed", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, body := post(t, ts, "/admin/new", url.Values{
+				"title":      {"Trashed"},
+				"subject_id": {strconv.FormatInt(subjectID, 10)},
+				"is_public":  {"true"},
+				"html":       {"<p>trashed</p>"},
+			})
+			if code != http.StatusOK {
+				t.Fatalf("new article: %d %s", code, body)
+			}
+			id := strings.TrimSpace(body)
+
+			// a live article still holds the subject
+			path := "/admin/subjects/delete/" + strconv.FormatInt(subjectID, 10)
+			if code, _ := post(t, ts, path, nil); code != http.StatusConflict {
+				t.Errorf("deleting a subject with a live article: %d, want 409", code)
+			}
+
+			if code, body := post(t, ts, "/admin/delete/"+id, nil); code != http.StatusOK {
+				t.Fatalf("delete: %d %s", code, body)
+			}
+			if code, body := post(t, ts, path, nil); code != http.StatusOK {
+				t.Fatalf("deleting a subject with a trashed article: %d %s", code, body)
+			}
+
+			defaultID, err := srv.Repos.Articles.GetDefaultSubjectID(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			trash, err := srv.Repos.Articles.ListTrash(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trash) != 1 || trash[0].SubjectId != defaultID {
+				t.Errorf("trash after deleting its subject: %+v", trash)
+			}
+
+			if code, body := post(t, ts, "/admin/trash/restore/"+id, nil); code != http.StatusOK {
+				t.Errorf("restore: %d %s", code, body)
+			}
+		})
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	srv, ts := newTestServer(t)
+
+	code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Audited"},
+		"subject_id": {"1"},
+		"is_public":  {"false"},
+		"html":       {"<p>one</p>"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+	id := strings.TrimSpace(body)
+
+	if code, body := post(t, ts, "/admin/articles/"+id, url.Values{
+		"title":      {"Audited Twice"},
+		"subject_id": {"1"},
+		"is_public":  {"false"},
+		"html":       {"<p>one, two</p>"},
+	}); code != http.StatusOK {
+		t.Fatalf("edit: %d %s", code, body)
+	}
+
+	// reads are not recorded
+	if code, _ := get(t, ts, "/admin/history/"+id); code != http.StatusOK {
+		t.Fatalf("history: %d", code)
+	}
+
+	// the browser signs in with the session cookie
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{Jar: jar}
+	if resp, err := browser.PostForm(ts.URL+"/admin/login", url.Values{"password": {"secret"}}); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+	if resp, err := browser.PostForm(ts.URL+"/admin/pin/home/"+id, nil); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	entries, err := srv.Repos.Audit.List(ctx, db.AuditFilter{Target: "article " + id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entries for article %s: %+v", id, entries)
+	}
+
+	pin, edit, create := entries[0], entries[1], entries[2]
+	if pin.Actor != "admin" || pin.Auth != "session" || pin.Summary != "pinned_home: false → true" || pin.Status != http.StatusSeeOther {
+		t.Errorf("pin entry: %+v", pin)
+	}
+	if edit.Actor != "stx" || edit.Auth != "token" || edit.Route != "/admin/articles/"+id ||
+		!strings.Contains(edit.Summary, "title: Audited → Audited Twice") || !strings.Contains(edit.Summary, "html: 10 → 15 bytes") {
+		t.Errorf("edit entry: %+v", edit)
+	}
+	if create.Route != "/admin/new" || create.Summary != `created "Audited"` || create.IP != "127.0.0.1" {
+		t.Errorf("create entry: %+v", create)
+	}
+
+	if code, body := get(t, ts, "/admin/audit?auth=session"); code != http.StatusOK || !strings.Contains(body, "pinned_home: false → true") || strings.Contains(body, "Audited Twice") {
+		t.Errorf("audit page filtered by session: %d\n%s", code, body)
+	}
+
+	code, csv := get(t, ts, "/admin/audit/export?actor=stx")
+	if lines := strings.Split(strings.TrimSpace(csv), "\n"); code != http.StatusOK || len(lines) != 3 || !strings.HasPrefix(lines[0], "id,time,actor") {
+		t.Errorf("export: %d\n%s", code, csv)
+	}
+
+	// the export is itself recorded
+	if exports, _ := srv.Repos.Audit.List(ctx, db.AuditFilter{Route: "/admin/audit/export"}); len(exports) != 1 {
+		t.Errorf("export entries: %+v", exports)
+	}
+}
+
+func TestSchedulerPublish(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	due := time.Now().Add(-time.Minute)
+	id, err := srv.Repos.Articles.Create(ctx, model.Article{
+		Title:     "Scheduled",
+		SubjectId: 1,
+		AuthorId:  1,
+		Lang:      model.DefaultLang,
+		HTML:      "<p>later</p>",
+		PublishAt: &due,
+	}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.RunScheduler(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		a, err := srv.Repos.Articles.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.IsPublic {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the scheduler did not publish the due article")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	revisions, err := srv.Repos.Articles.ListRevisions(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Actor != "scheduler" || !revisions[0].IsPublic {
+		t.Errorf("revisions after publishing: %+v", revisions)
+	}
+
+	target := "article " + strconv.FormatInt(id, 10)
+	entries, err := srv.Repos.Audit.List(context.Background(), db.AuditFilter{Target: target})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "scheduler" || entries[0].Summary != "public: false → true" {
+		t.Errorf("audit entries for %s: %+v", target, entries)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	var ids []string
+	for _, a := range []struct{ title, html string }{
+		{"Go Generics", "<p>Type parameters, at last.</p>"},
+		{"Gardening", `<p class="lead">Notes on generic seeds &amp; soil.</p>`},
+	} {
+		code, body := post(t, ts, "/admin/new", url.Values{
+			"title":      {a.title},
+			"subject_id": {"1"},
+			"is_public":  {"true"},
+			"html":       {a.html},
+		})
+		if code != http.StatusOK {
+			t.Fatalf("new article: %d %s", code, body)
+		}
+		ids = append(ids, strings.TrimSpace(body))
+	}
+
+	// the title hit ranks first
+	code, body := get(t, ts, "/admin/api/search?q=GENERIC")
+	if want := ids[0] + "\tGo Generics\n" + ids[1] + "\tGardening\n"; code != http.StatusOK || body != want {
+		t.Errorf("search API: %d %q, want %q", code, body, want)
+	}
+
+	code, body = get(t, ts, "/admin/search?q=generic+seed")
+	if code != http.StatusOK || !strings.Contains(body, "Notes on <mark>generic</mark> <mark>seed</mark>s &amp; soil.") ||
+		strings.Contains(body, "Go <mark>") {
+		t.Errorf("search page: %d\n%s", code, body)
+	}
+
+	// markup is not searched
+	if code, body := get(t, ts, "/admin/api/search?q=class+lead"); code != http.StatusOK || body != "" {
+		t.Errorf("search API matched markup: %d %q", code, body)
+	}
+}
+
+func TestDumpAndRestore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("STATIX_PUBLISH_TOKEN", testToken)
+
+	srv, ts := newSQLiteServer(t, t.TempDir())
+
+	if code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Kept"},
+		"subject_id": {"1"},
+		"is_public":  {"true"},
+		"html":       {"<p>kept</p>"},
+	}); code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+
+	code, dump := get(t, ts, "/admin/dump")
+	if code != http.StatusOK || !strings.HasPrefix(dump, `{"type":"header"`) {
+		t.Fatalf("dump: %d %s", code, dump)
+	}
+
+	if code, body := post(t, ts, "/admin/new", url.Values{
+		"title":      {"Lost"},
+		"subject_id": {"1"},
+		"is_public":  {"true"},
+	}); code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+
+	restore := func(body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/admin/dump/restore", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		req.Header.Set("X-Statix-Token", testToken)
+
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	// a cut dump is refused before anything changes
+	if code, _ := restore(dump[:len(dump)/2]); code != http.StatusBadRequest {
+		t.Errorf("truncated dump: %d, want 400", code)
+	}
+
+	// settings must name an installed theme or font, never a path
+	export, err := db.ReadExport(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, settings := range []map[string]string{
+		{"theme": "../../../tmp/evil"},
+		{"font": "missing"},
+	} {
+		export.Settings = settings
+
+		var bad strings.Builder
+		if _, err := export.WriteTo(&bad); err != nil {
+			t.Fatal(err)
+		}
+		if code, body := restore(bad.String()); code != http.StatusBadRequest {
+			t.Errorf("dump with settings %v: %d %s, want 400", settings, code, body)
+		}
+	}
+	if exists, _ := srv.Repos.Articles.ExistsByTitleRaw(ctx, "Lost"); !exists {
+		t.Fatal("a refused restore changed the database")
+	}
+
+	if code, body := restore(dump); code != http.StatusOK || !strings.Contains(body, "1 articles") {
+		t.Fatalf("restore: %d %s", code, body)
+	}
+
+	if exists, _ := srv.Repos.Articles.ExistsByTitleRaw(ctx, "Lost"); exists {
+		t.Error("an article created after the dump survived the restore")
+	}
+	if _, err := os.Stat(filepath.Join(srv.OutDir, "articles", "kept.html")); err != nil {
+		t.Errorf("site not rebuilt after the restore: %v", err)
+	}
+}
+
+func TestSiteArchive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("STATIX_PUBLISH_TOKEN", testToken)
+
+	src, srcTS := newSQLiteServer(t, t.TempDir())
+
+	for name, data := range map[string]string{
+		"common_files/docs/guide.pdf": "%PDF guide",
+		"css/themes/custom.css":       "body { color: teal; }",
+		"favicons/custom.svg":         "<svg/>",
+		"css/fonts/serif.css":         "body { font-family: serif; }",
+	} {
+		path := filepath.Join(src.AssetsDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, form := range []struct{ path, key, value string }{
+		{"/admin/theme", "theme", "custom"},
+		{"/admin/font", "font", "serif"},
+	} {
+		if code, body := post(t, srcTS, form.path, url.Values{form.key: {form.value}}); code >= 400 {
+			t.Fatalf("%s: %d %s", form.path, code, body)
+		}
+	}
+
+	if code, body := post(t, srcTS, "/admin/new", url.Values{
+		"title":      {"Moved"},
+		"subject_id": {"1"},
+		"is_public":  {"true"},
+		"html":       {"<p>moved</p>"},
+	}); code != http.StatusOK {
+		t.Fatalf("new article: %d %s", code, body)
+	}
+
+	code, archive := get(t, srcTS, "/admin/archive")
+	if code != http.StatusOK {
+		t.Fatalf("archive: %d %s", code, archive)
+	}
+
+	// the target is fresh, with only the shipped font
+	dst, dstTS := newSQLiteServer(t, t.TempDir())
+
+	fonts := filepath.Join(dst.AssetsDir, "css", "fonts")
+	if err := os.MkdirAll(fonts, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fonts, "serif.css"), []byte("body { font-family: serif; }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	importArchive := func(body string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, dstTS.URL+"/admin/archive/import", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/gzip")
+		req.Header.Set("X-Statix-Token", testToken)
+
+		resp, err := dstTS.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	if code, _ := importArchive(archive[:len(archive)/2]); code != http.StatusBadRequest {
+		t.Errorf("truncated archive: %d, want 400", code)
+	}
+
+	// a bad manifest is refused before anything is written
+	for name, edit := range map[string]func(*admin.ArchiveManifest){
+		"theme path":      func(m *admin.ArchiveManifest) { m.Theme = "../../../tmp/evil" },
+		"missing font":    func(m *admin.ArchiveManifest) { m.Font = "missing" },
+		"oversized entry": func(m *admin.ArchiveManifest) { m.Files[0].Size = 1 << 40 },
+	} {
+		if code, body := importArchive(rewriteManifest(t, archive, edit)); code != http.StatusBadRequest {
+			t.Errorf("%s: %d %s, want 400", name, code, body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst.AssetsDir, "common_files")); !os.IsNotExist(err) {
+		t.Errorf("a refused import installed files: %v", err)
+	}
+
+	if code, body := importArchive(archive); code != http.StatusOK || !strings.Contains(body, "1 articles") {
+		t.Fatalf("import: %d %s", code, body)
+	}
+
+	if exists, _ := dst.Repos.Articles.ExistsByTitleRaw(ctx, "Moved"); !exists {
+		t.Error("article missing after the import")
+	}
+	if data, err := os.ReadFile(filepath.Join(dst.AssetsDir, "common_files", "docs", "guide.pdf")); err != nil || string(data) != "%PDF guide" {
+		t.Errorf("uploaded file after the import: %q, %v", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst.AssetsDir, "css", "theme.css")); err != nil || string(data) != "body { color: teal; }" {
+		t.Errorf("theme after the import: %q, %v", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst.AssetsDir, "css", "font.css")); err != nil || link != filepath.Join("fonts", "serif.css") {
+		t.Errorf("font after the import: %q, %v", link, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst.OutDir, "articles", "moved.html")); err != nil {
+		t.Errorf("site not rebuilt after the import: %v", err)
+	}
+}
+
+// rewriteManifest returns archive with its manifest changed by edit.
+func rewriteManifest(t *testing.T, archive string, edit func(*admin.ArchiveManifest)) string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hdr.Name == "manifest.json" {
+			var m admin.ArchiveManifest
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			edit(&m)
+			if data, err = json.Marshal(m); err != nil {
+				t.Fatal(err)
+			}
+			hdr.Size = int64(len(data))
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
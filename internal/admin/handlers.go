@@ -1,32 +1,36 @@
 package admin
 
 import (
-	"html/template"
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
-    "os/exec"
     "os"
     "path/filepath"
     "sort"
-    "io"
     "bytes"
     "database/sql"
     "errors"
     "fmt"
+    "time"
+    "html/template"
 
-	"blog/internal/db"
 	"blog/internal/generator"
     "blog/internal/model"
 )
 
+// nginxErrorPagesConf is included by the nginx site config written by
+// quickstart.sh.
+const nginxErrorPagesConf = "nginx_error_pages.conf"
+
 type EditArticleView struct {
 	Article  model.Article
 	Subjects []model.Subject
+	Authors  []model.Author
 }
 
 func (s *Server) listThemes() ([]string, error) {
-	base := "/var/www/go_blog/assets/css/themes"
+	base := filepath.Join(s.AssetsDir, "css", "themes")
 
 	entries, err := os.ReadDir(base)
 	if err != nil {
@@ -46,7 +50,7 @@ func (s *Server) listThemes() ([]string, error) {
 }
 
 func (s *Server) listFonts() ([]string, error) {
-	base := "/var/www/go_blog/assets/css/fonts"
+	base := filepath.Join(s.AssetsDir, "css", "fonts")
 
 	entries, err := os.ReadDir(base)
 	if err != nil {
@@ -66,7 +70,7 @@ func (s *Server) listFonts() ([]string, error) {
 }
 
 func (s *Server) currentTheme() string {
-	link := "/var/www/go_blog/assets/css/theme.css"
+	link := filepath.Join(s.AssetsDir, "css", "theme.css")
 
 	target, err := os.Readlink(link)
 	if err != nil {
@@ -78,7 +82,7 @@ func (s *Server) currentTheme() string {
 }
 
 func (s *Server) currentFont() string {
-	link := "/var/www/go_blog/assets/css/font.css"
+	link := filepath.Join(s.AssetsDir, "css", "font.css")
 
 	target, err := os.Readlink(link)
 	if err != nil {
@@ -90,24 +94,26 @@ func (s *Server) currentFont() string {
 }
 
 func (s *Server) applyTheme(name string) error {
-	baseCSS := "/var/www/go_blog/assets/css"
-	baseFavicon := "/var/www/go_blog/assets"
+	baseCSS := filepath.Join(s.AssetsDir, "css")
+	baseFavicon := s.AssetsDir
 
 	// ---- CSS ----
-	cssTarget := filepath.Join(baseCSS, "themes", name+".css")
+	// links are relative to their directory, so they survive a move of
+	// the site, e.g. by an archive import
+	cssTarget := filepath.Join("themes", name+".css")
 	cssLink := filepath.Join(baseCSS, "theme.css")
 	cssTmp := cssLink + ".tmp"
 
 	// ---- Favicon ----
-	favTarget := filepath.Join(baseFavicon, "favicons", name+".svg")
+	favTarget := filepath.Join("favicons", name+".svg")
 	favLink := filepath.Join(baseFavicon, "favicon.svg")
 	favTmp := favLink + ".tmp"
 
 	// Validate existence
-	if _, err := os.Stat(cssTarget); err != nil {
+	if _, err := os.Stat(filepath.Join(baseCSS, cssTarget)); err != nil {
 		return err
 	}
-	if _, err := os.Stat(favTarget); err != nil {
+	if _, err := os.Stat(filepath.Join(baseFavicon, favTarget)); err != nil {
 		return err
 	}
 
@@ -133,15 +139,15 @@ func (s *Server) applyTheme(name string) error {
 }
 
 func (s *Server) applyFont(name string) error {
-	baseCSS := "/var/www/go_blog/assets/css"
+	baseCSS := filepath.Join(s.AssetsDir, "css")
 
 	// ---- CSS ----
-	fontTarget := filepath.Join(baseCSS, "fonts", name+".css")
+	fontTarget := filepath.Join("fonts", name+".css")
 	fontLink := filepath.Join(baseCSS, "font.css")
 	cssTmp := fontLink + ".tmp"
 
 	// Validate existence
-	if _, err := os.Stat(fontTarget); err != nil {
+	if _, err := os.Stat(filepath.Join(baseCSS, fontTarget)); err != nil {
 		return err
 	}
 
@@ -157,192 +163,184 @@ func (s *Server) applyFont(name string) error {
 	return nil
 }
 
-func (s *Server) rebuildSite() error {
-	articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
-	authorRepo  := db.AuthorRepo{DB: s.DB}
+// siteGenerator loads everything a full build needs.
+func (s *Server) siteGenerator(ctx context.Context) (*generator.Generator, error) {
+	articleRepo := s.Repos.Articles
+	subjectRepo := s.Repos.Subjects
+	pageRepo    := s.Repos.Pages
+	authorRepo  := s.Repos.Authors
 
-	articles, err := articleRepo.ListAll()
+	articles, err := articleRepo.ListAll(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	subjects, err := subjectRepo.ListAll()
+	subjects, err := subjectRepo.ListAll(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	content, err := authorRepo.GetContent()
+	pages, err := pageRepo.ListAll(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	gen := generator.Generator{
-        AuthorContent: template.HTML(content),
+	authors, err := authorRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generator.Generator{
         ArticleRepo: articleRepo,
         SubjectRepo: subjectRepo,
 		Articles: articles,
 		Subjects: subjects,
-		OutDir:   "dist",
-	}
+		Pages:    pages,
+		Authors:  authors,
+		OutDir:   s.OutDir,
+		AssetsDir: s.AssetsDir,
+		Templates: s.Templates,
+		Plugins:   s.Plugins,
+		StrictLinks: s.StrictLinks,
+		NginxConf: s.NginxConf,
+	}, nil
+}
 
-	if err := gen.Build(); err != nil {
-		return err
-	}
+// detached keeps a rebuild going when the request that asked for it goes
+// away: the change it publishes is already committed, and the pages must
+// follow it.
+func detached(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
 
-    err = gen.BuildRSS()
-    if err != nil {
-        return err
-    }
+func (s *Server) rebuildSite(ctx context.Context) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-    err = gen.BuildAuthor()
-    if err != nil {
-        return err
-    }
+	ctx = detached(ctx)
+
+	gen, err := s.siteGenerator(ctx)
+	if err != nil {
+		return err
+	}
 
-	return gen.BuildSitemap()
+	return gen.BuildAll(ctx)
 }
 
-func (s *Server) rebuildSiteLocalize(title string, 
+func (s *Server) rebuildSiteLocalize(ctx context.Context, title string, 
+                                     lang string,
                                      subject_id int64,
+                                     old_subject_id int64,
                                      sitemap_build bool,
                                      is_deletion bool) error {
-	articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-	articles, err := articleRepo.ListAll()
-	if err != nil {
-		return err
-	}
+	ctx = detached(ctx)
 
-	subjects, err := subjectRepo.ListAll()
+	gen, err := s.siteGenerator(ctx)
 	if err != nil {
 		return err
 	}
 
-	gen := generator.Generator{
-        AuthorContent: template.HTML(""),
-        ArticleRepo: articleRepo,
-        SubjectRepo: subjectRepo,
-		Articles: articles,
-		Subjects: subjects,
-		OutDir:   "dist",
+	if err := gen.LocalizedBuild(ctx, title, lang, subject_id, old_subject_id, is_deletion); err != nil {
+		return err
 	}
 
-	if err := gen.LocalizedBuild(title, subject_id, is_deletion); err != nil {
+	// keeps the recent articles on 404.html/410.html current
+	if err := gen.BuildErrorPages(ctx); err != nil {
 		return err
 	}
 
     if sitemap_build {
 
-        err = gen.BuildRSS()
+        err = gen.BuildRSS(ctx)
         if err != nil {
             return err
         }
 
-	    return gen.BuildSitemap()
+	    return gen.BuildSitemap(ctx)
     }
 
     return nil
 
 }
 
-func (s *Server) rebuildSubjectEvent() error {
-	
-	articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+// rebuildTranslations rebuilds the whole site after a change to a
+// translation group. When gone is set, oldPath is marked gone first: the
+// article was deleted or moved to another language.
+func (s *Server) rebuildTranslations(ctx context.Context, oldPath string, gone bool) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-	articles, err := articleRepo.ListAll()
-	if err != nil {
-		return err
-	}
+	ctx = detached(ctx)
 
-	subjects, err := subjectRepo.ListAll()
+	gen, err := s.siteGenerator(ctx)
 	if err != nil {
 		return err
 	}
 
-	gen := generator.Generator{
-        AuthorContent: template.HTML(""),
-        ArticleRepo: articleRepo,
-        SubjectRepo: subjectRepo,
-		Articles: articles,
-		Subjects: subjects,
-		OutDir:   "dist",
+	if gone {
+		if err := gen.MarkGone(oldPath); err != nil {
+			return err
+		}
 	}
 
-    err = gen.SubjectEventBuild()
-    if err != nil {
-        return err
-    }
-
-    return gen.BuildSitemap()
+	return gen.BuildAll(ctx)
+}
 
+// rebuildEdited rebuilds after article, formerly old, was saved.
+func (s *Server) rebuildEdited(ctx context.Context, old, article model.Article) error {
+	// translation links show up on every page of the group, and a
+	// language change moves the page, so both rebuild the whole site
+	if article.Lang != old.Lang || article.TranslationGroup != old.TranslationGroup || old.TranslationGroup != 0 {
+		return s.rebuildTranslations(ctx, old.Path(), article.Lang != old.Lang)
+	}
+	return s.rebuildSiteLocalize(ctx, article.Title, article.Lang, article.SubjectId, old.SubjectId, false, false)
 }
 
-func (s *Server) rebuildSubjectEdit(subject_id int64) error {
-  
-    articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+func (s *Server) rebuildSubjectEvent(ctx context.Context) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-	articles, err := articleRepo.ListAll()
-	if err != nil {
-		return err
-	}
+	ctx = detached(ctx)
 
-	subjects, err := subjectRepo.ListAll()
+	gen, err := s.siteGenerator(ctx)
 	if err != nil {
 		return err
 	}
 
-	gen := generator.Generator{
-        AuthorContent: template.HTML(""),
-        ArticleRepo: articleRepo,
-        SubjectRepo: subjectRepo,
-		Articles: articles,
-		Subjects: subjects,
-		OutDir:   "dist",
-	}
-
-    err = gen.SubjectEditBuild(subject_id)
+    err = gen.SubjectEventBuild(ctx)
     if err != nil {
         return err
     }
 
-    err = gen.BuildRSS()
-    if err != nil {
-        return err
-    }
-
-    return gen.BuildSitemap()
+    return gen.BuildSitemap(ctx)
 
 }
 
-func (s *Server) rebuildAuthor() error {
+func (s *Server) rebuildSubjectEdit(ctx context.Context, subject_id int64) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
 
-	articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
-	authorRepo  := db.AuthorRepo{DB: s.DB}
+	ctx = detached(ctx)
 
-	content, err := authorRepo.GetContent()
+	gen, err := s.siteGenerator(ctx)
 	if err != nil {
 		return err
 	}
 
-	gen := generator.Generator{
-        AuthorContent: template.HTML(content),
-        ArticleRepo:   articleRepo,
-        SubjectRepo:   subjectRepo,
-		Articles:      []model.Article{},
-		Subjects:      []model.Subject{},
-		OutDir:        "dist",
-	}
+    err = gen.SubjectEditBuild(ctx, subject_id)
+    if err != nil {
+        return err
+    }
 
-	if err := gen.BuildAuthor(); err != nil {
-		return err
-	}
+    err = gen.BuildRSS(ctx)
+    if err != nil {
+        return err
+    }
 
-    return nil
+    return gen.BuildSitemap(ctx)
 
 }
 
@@ -353,61 +351,33 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
     	return
     }
 
-    articleRepo := db.ArticleRepo{DB: s.DB}
+    articleRepo := s.Repos.Articles
 
-	articles, err := articleRepo.ListAll()
+	articles, err := articleRepo.ListAll(r.Context())
 	if err != nil {
     	http.Error(w, "error occured in articleRepo.ListAll()", http.StatusBadRequest)
 		return
 	}
 
-    tmpl, err := template.New("base").
-        ParseFiles(
-            "internal/templates/base.html",
-            "internal/templates/admin/index.html",
-        )
+    tmpl, err := s.Templates.Parse(template.FuncMap{"countdown": countdown},
+        "base.html",
+        "admin/index.html",
+    )
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := tmpl.ExecuteTemplate(w, "base", articles); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-}
-
-func (s *Server) handleAuthor(w http.ResponseWriter, r *http.Request) {
-
-    if r.Method != http.MethodGet {
-    	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-    	return
-    }
-
-    authorRepo := db.AuthorRepo{DB: s.DB}
+	order := r.URL.Query().Get("sort")
+	sortArticles(articles, order)
 
-	content, err := authorRepo.GetContent()
-	if err != nil {
-    	http.Error(w, "error occured in authorRepo.GetContent()", http.StatusBadRequest)
-		return
-	}
-
-    data := struct {
-		Content string
+	data := struct {
+		Articles []model.Article
+		Sort     string
 	}{
-		Content: content,
-	}
-
-    tmpl, err := template.New("base").
-        ParseFiles(
-            "internal/templates/base.html",
-            "internal/templates/admin/edit_author.html",
-        )
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		Articles: articles,
+		Sort:     order,
 	}
 
 	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
@@ -416,33 +386,34 @@ func (s *Server) handleAuthor(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleUpdateAuthor(w http.ResponseWriter, r *http.Request) {
-
-    if r.Method != http.MethodPost {
-    	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
+// countdown renders the time left before a scheduled publication.
+func countdown(t time.Time) string {
+	d := time.Until(t).Round(time.Minute)
+	if d <= 0 {
+		return "due"
+	}
 
-    repo := db.AuthorRepo{DB: s.DB}
+	if days := d / (24 * time.Hour); days > 0 {
+		return fmt.Sprintf("in %dd %dh", days, (d%(24*time.Hour))/time.Hour)
+	}
 
-    if err := r.ParseForm(); err != nil {
-    	http.Error(w, err.Error(), http.StatusBadRequest)
-    	return
-    }
-    
-    html := r.FormValue("html")
-    
-    if err := repo.Update(html); err != nil {
-    	http.Error(w, err.Error(), http.StatusInternalServerError)
-    	return
-    }
+	return "in " + strings.TrimSuffix(d.String(), "0s")
+}
 
-    if err := s.rebuildAuthor(); err != nil {
-    	http.Error(w, err.Error(), http.StatusInternalServerError)
-    	return
-    }
-    
-    http.Redirect(w, r, "/admin", http.StatusSeeOther)
+// sortArticles orders the admin article list: "updated" puts the last
+// edited first, "title" is alphabetical, anything else keeps the newest
+// first.
+func sortArticles(articles []model.Article, order string) {
+	switch order {
+	case "updated":
+		sort.SliceStable(articles, func(i, j int) bool {
+			return articles[i].UpdatedAt.After(articles[j].UpdatedAt)
+		})
+	case "title":
+		sort.SliceStable(articles, func(i, j int) bool {
+			return strings.ToLower(articles[i].Title) < strings.ToLower(articles[j].Title)
+		})
+	}
 }
 
 func (s *Server) handleEditArticle(w http.ResponseWriter, r *http.Request) {
@@ -458,7 +429,7 @@ func (s *Server) handleEditArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repo := db.ArticleRepo{DB: s.DB}
+	repo := s.Repos.Articles
 
 	// -------- POST: save + build --------
 
@@ -491,7 +462,74 @@ func (s *Server) handleEditArticle(w http.ResponseWriter, r *http.Request) {
             return
         }
 
-        exists, err := repo.ExistsByTitle(title, id)
+        old, err := repo.GetByID(r.Context(), id)
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                http.NotFound(w, r)
+                return
+            }
+	    	http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        authorId, err := s.authorFromForm(r, old.AuthorId)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        publishAt, isPublic, err := scheduleFromForm(r, old.PublishAt, isPublic)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        lang, err := langFromForm(r, old.Lang)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        pinnedHome, err := flagFromForm(r, "pinned_home", old.PinnedHome)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        pinnedSubject, err := flagFromForm(r, "pinned_subject", old.PinnedSubject)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        featured, err := flagFromForm(r, "featured", old.Featured)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        article := model.Article{
+            ID:        id,
+            Title:     title,
+            SubjectId: subjectId,
+            AuthorId:  authorId,
+            Lang:      lang,
+            IsPublic:  isPublic,
+            PinnedHome:    pinnedHome,
+            PinnedSubject: pinnedSubject,
+            Featured:  featured,
+            PublishAt: publishAt,
+            HTML:      html,
+            TranslationGroup: old.TranslationGroup,
+        }
+
+        article.TranslationGroup, err = s.translationGroupFromForm(r, article)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        exists, err := repo.ExistsByTitle(r.Context(), title, id)
         if err != nil {
 	    	http.Error(w, err.Error(), http.StatusInternalServerError)
             return
@@ -503,12 +541,18 @@ func (s *Server) handleEditArticle(w http.ResponseWriter, r *http.Request) {
         }
 
     	// 1. Update DB
-    	if err := repo.Update(id, title, subjectId, isPublic, html); err != nil {
+    	if err := repo.Update(r.Context(), article, actor(r)); err != nil {
     		http.Error(w, err.Error(), http.StatusInternalServerError)
     		return
     	}
+        auditArticle(r, old, article)
 
-        if err := s.rebuildSiteLocalize(title, subjectId, false, false); err != nil {
+        if err := s.rootTranslationGroup(r.Context(), article.TranslationGroup); err != nil {
+        	http.Error(w, err.Error(), http.StatusInternalServerError)
+        	return
+        }
+
+        if err := s.rebuildEdited(r.Context(), old, article); err != nil {
         	http.Error(w, err.Error(), http.StatusInternalServerError)
         	return
         }
@@ -524,31 +568,39 @@ func (s *Server) handleEditArticle(w http.ResponseWriter, r *http.Request) {
     }
 
 	// -------- GET: render edit page --------
-	article, err := repo.GetByID(id)
+	article, err := repo.GetByID(r.Context(), id)
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/edit.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/edit.html",
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-    subjectRepo := db.SubjectRepo{DB: s.DB}
-    subjects, err := subjectRepo.ListAll()
+    subjectRepo := s.Repos.Subjects
+    subjects, err := subjectRepo.ListAll(r.Context())
     if err != nil {
     	http.Error(w, err.Error(), http.StatusInternalServerError)
     	return
     }
     
+    authorRepo := s.Repos.Authors
+    authors, err := authorRepo.ListAll(r.Context())
+    if err != nil {
+    	http.Error(w, err.Error(), http.StatusInternalServerError)
+    	return
+    }
+
     data := EditArticleView{
     	Article:  article,
     	Subjects: subjects,
+    	Authors:  authors,
     }
     
     if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
@@ -571,9 +623,9 @@ func (s *Server) handleDeleteArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	articleRepo := db.ArticleRepo{DB: s.DB}
+	articleRepo := s.Repos.Articles
 
-    article, err := articleRepo.GetByID(id)
+    article, err := articleRepo.GetByID(r.Context(), id)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             http.NotFound(w, r)
@@ -583,12 +635,19 @@ func (s *Server) handleDeleteArticle(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    if err := articleRepo.Delete(id); err != nil {
+    // the article moves to the trash, its page goes as with a deletion
+    if err := articleRepo.Trash(r.Context(), id, time.Now()); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+    auditf(r, "moved %q to the trash", article.Title)
 
-    if err := s.rebuildSiteLocalize(article.Title, article.SubjectId, true, true); err != nil {
+    if article.TranslationGroup != 0 {
+        err = s.rebuildTranslations(r.Context(), article.Path(), true)
+    } else {
+        err = s.rebuildSiteLocalize(r.Context(), article.Title, article.Lang, article.SubjectId, 0, true, true)
+    }
+    if err != nil {
     	http.Error(w, err.Error(), http.StatusInternalServerError)
     	return
     }
@@ -596,9 +655,89 @@ func (s *Server) handleDeleteArticle(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin", http.StatusSeeOther)
 }
 
+// flagFromForm returns the boolean form field name, or fallback when the
+// form has none.
+func flagFromForm(r *http.Request, name string, fallback bool) (bool, error) {
+	if _, ok := r.Form[name]; !ok {
+		return fallback, nil
+	}
+
+	v, err := strconv.ParseBool(r.FormValue(name))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value", name)
+	}
+
+	return v, nil
+}
+
+// handleToggleArticle flips the pinned_home (/admin/pin/home/<id>),
+// pinned_subject (/admin/pin/subject/<id>) or featured
+// (/admin/feature/<id>) flag of an article and rebuilds the listings.
+func (s *Server) handleToggleArticle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var flag, idStr string
+	for _, prefix := range []string{"/admin/pin/home/", "/admin/pin/subject/", "/admin/feature/"} {
+		if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+			flag, idStr = prefix, rest
+			break
+		}
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	repo := s.Repos.Articles
+
+	article, err := repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch flag {
+	case "/admin/pin/home/":
+		err = repo.SetPinnedHome(r.Context(), id, !article.PinnedHome)
+	case "/admin/pin/subject/":
+		err = repo.SetPinnedSubject(r.Context(), id, !article.PinnedSubject)
+	default:
+		err = repo.SetFeatured(r.Context(), id, !article.Featured)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch flag {
+	case "/admin/pin/home/":
+		auditChange(r, "pinned_home", article.PinnedHome, !article.PinnedHome)
+	case "/admin/pin/subject/":
+		auditChange(r, "pinned_subject", article.PinnedSubject, !article.PinnedSubject)
+	default:
+		auditChange(r, "featured", article.Featured, !article.Featured)
+	}
+
+	if err := s.rebuildSiteLocalize(r.Context(), article.Title, article.Lang, article.SubjectId, 0, false, false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
 func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
-	articleRepo := db.ArticleRepo{DB: s.DB}
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+	articleRepo := s.Repos.Articles
+	subjectRepo := s.Repos.Subjects
 
 	// -------- POST: create + rebuild --------
 	if r.Method == http.MethodPost {
@@ -631,7 +770,62 @@ func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
             return
         }
 
-        exists, err := articleRepo.ExistsByTitleRaw(title)
+        authorId, err := s.authorFromForm(r, 0)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        publishAt, isPublic, err := scheduleFromForm(r, nil, isPublic)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        lang, err := langFromForm(r, "")
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        pinnedHome, err := flagFromForm(r, "pinned_home", false)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        pinnedSubject, err := flagFromForm(r, "pinned_subject", false)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        featured, err := flagFromForm(r, "featured", false)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        article := model.Article{
+            Title:     title,
+            SubjectId: subjectId,
+            AuthorId:  authorId,
+            Lang:      lang,
+            IsPublic:  isPublic,
+            PinnedHome:    pinnedHome,
+            PinnedSubject: pinnedSubject,
+            Featured:  featured,
+            PublishAt: publishAt,
+            HTML:      html,
+        }
+
+        article.TranslationGroup, err = s.translationGroupFromForm(r, article)
+        if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        exists, err := articleRepo.ExistsByTitleRaw(r.Context(), title)
         if err != nil {
 	    	http.Error(w, err.Error(), http.StatusInternalServerError)
             return
@@ -643,13 +837,25 @@ func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
         }
 
 		// 1️⃣ Insert into DB
-        newID, err := articleRepo.Create(title, subjectId, isPublic, html)
+        newID, err := articleRepo.Create(r.Context(), article, actor(r))
         if err != nil {
         	http.Error(w, err.Error(), http.StatusInternalServerError)
         	return
         }
+        setAuditTarget(r, "article", newID)
+        auditf(r, "created %q", title)
 
-        if err := s.rebuildSiteLocalize(title, subjectId, true, false); err != nil {
+        if err := s.rootTranslationGroup(r.Context(), article.TranslationGroup); err != nil {
+        	http.Error(w, err.Error(), http.StatusInternalServerError)
+        	return
+        }
+
+        if article.TranslationGroup != 0 {
+            err = s.rebuildTranslations(r.Context(), "", false)
+        } else {
+            err = s.rebuildSiteLocalize(r.Context(), title, lang, subjectId, 0, true, false)
+        }
+        if err != nil {
         	http.Error(w, err.Error(), http.StatusInternalServerError)
         	return
         }
@@ -668,15 +874,22 @@ func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// -------- GET: render form --------
-	subjects, err := subjectRepo.ListAll()
+	subjects, err := subjectRepo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	authorRepo := s.Repos.Authors
+	authors, err := authorRepo.ListAll(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/new.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/new.html",
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -686,8 +899,10 @@ func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
 	// You may want to pass subjects to template for the <select>
 	data := struct {
 		Subjects []model.Subject
+		Authors  []model.Author
 	}{
 		Subjects: subjects,
+		Authors:  authors,
 	}
 
 	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
@@ -696,43 +911,8 @@ func (s *Server) handleNewArticle(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleDumpDb(w http.ResponseWriter, r *http.Request) {
-
-    cmd := exec.Command(
-        "/usr/bin/mysqldump",
-        "-u", "goblog",
-        "-pPASSWORD",
-        "go_blog",
-    )
-    
-    stderr, err := cmd.StderrPipe()
-    if err != nil {
-        http.Error(w, "failed to create stderr pipe", 500)
-        return
-    }
-    
-    stdout, err := cmd.StdoutPipe()
-    if err != nil {
-        http.Error(w, "failed to create stdout pipe", 500)
-        return
-    }
-    
-    if err := cmd.Start(); err != nil {
-        http.Error(w, "failed to start dump", 500)
-        return
-    }
-    
-    w.Header().Set("Content-Type", "application/sql")
-    w.Header().Set("Content-Disposition", "attachment; filename=\"go_blog.sql\"")
-    
-    go io.Copy(io.Discard, stderr) // consume stderr to avoid blocking
-    
-    io.Copy(w, stdout)
-    
-    cmd.Wait()
-
-}
-
+// dumpSQLite sends a consistent copy of the SQLite database, taken with
+// VACUUM INTO while the admin keeps running.
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
 		if r.ParseForm() != nil {
@@ -750,9 +930,9 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tmpl, _ := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/login.html",
+	tmpl, _ := s.Templates.Parse(nil,
+		"base.html",
+		"admin/login.html",
 	)
 	tmpl.ExecuteTemplate(w, "base", nil)
 }
@@ -779,9 +959,9 @@ func (s *Server) handleNewSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+	subjectRepo := s.Repos.Subjects
 
-    exists, err := subjectRepo.ExistsByNameRaw(name)
+    exists, err := subjectRepo.ExistsByNameRaw(r.Context(), name)
     if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
         return
@@ -792,12 +972,22 @@ func (s *Server) handleNewSubject(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	if _, err := subjectRepo.Create(name); err != nil {
+    parentId, err := s.subjectParentFromForm(r, 0)
+    if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+	newID, err := subjectRepo.Create(r.Context(), name, parentId)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := s.rebuildSubjectEvent(); err != nil {
+	setAuditTarget(r, "subject", newID)
+	auditf(r, "created %q", name)
+
+	if err := s.rebuildSubjectEvent(r.Context()); err != nil {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -811,9 +1001,44 @@ func (s *Server) handleNewSubject(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/subjects", http.StatusSeeOther)
 }
 
+// subjectFromForm applies the submitted subject fields over base. Missing
+// fields keep their value, so `stx subject rename` only sends the title.
+func (s *Server) subjectFromForm(r *http.Request, base model.Subject) (model.Subject, error) {
+	sub := base
+
+	if _, ok := r.Form["subject"]; ok {
+		sub.Title = strings.TrimSpace(r.FormValue("subject"))
+		if sub.Title == "" {
+			return sub, errors.New("subject is required")
+		}
+	}
+
+	if _, ok := r.Form["description"]; ok {
+		sub.Description = r.FormValue("description")
+	}
+
+	if _, ok := r.Form["cover"]; ok {
+		cover, err := s.commonFileName(r.FormValue("cover"))
+		if err != nil {
+			return sub, err
+		}
+		sub.Cover = cover
+	}
+
+	if v, ok := r.Form["position"]; ok && strings.TrimSpace(v[0]) != "" {
+		position, err := strconv.Atoi(strings.TrimSpace(v[0]))
+		if err != nil {
+			return sub, errors.New("invalid position")
+		}
+		sub.Position = position
+	}
+
+	return sub, nil
+}
+
 func (s *Server) handleEditSubject(w http.ResponseWriter, r *http.Request) {
 
-    subjectRepo := db.SubjectRepo{DB: s.DB}
+    subjectRepo := s.Repos.Subjects
 
 	if r.Method == http.MethodPost {
 
@@ -822,12 +1047,6 @@ func (s *Server) handleEditSubject(w http.ResponseWriter, r *http.Request) {
 	    	return
 	    }
 
-	    name := r.FormValue("subject")
-	    if name == "" {
-	    	http.Error(w, "subject is required", http.StatusBadRequest)
-	    	return
-	    }
-
         subjectIdStr := r.FormValue("subject_id")
     	subjectId64, err := strconv.ParseInt(subjectIdStr, 10, 64)
     	if err != nil {
@@ -836,7 +1055,23 @@ func (s *Server) handleEditSubject(w http.ResponseWriter, r *http.Request) {
     	}
     	subjectId := int64(subjectId64)
 
-        exists, err := subjectRepo.ExistsByName(name, subjectId)
+        old, err := subjectRepo.GetByID(r.Context(), subjectId)
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                http.NotFound(w, r)
+                return
+            }
+	    	http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        subject, err := s.subjectFromForm(r, old)
+        if err != nil {
+	    	http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        exists, err := subjectRepo.ExistsByName(r.Context(), subject.Title, subjectId)
         if err != nil {
 	    	http.Error(w, err.Error(), http.StatusInternalServerError)
             return
@@ -847,12 +1082,21 @@ func (s *Server) handleEditSubject(w http.ResponseWriter, r *http.Request) {
             return
         }
 
-	    if err := subjectRepo.Update(subjectId, name); err != nil {
+	    if err := subjectRepo.Update(r.Context(), subject); err != nil {
 	    	http.Error(w, err.Error(), http.StatusInternalServerError)
 	    	return
 	    }
 
-        if err := s.rebuildSubjectEdit(subjectId); err != nil {
+        setAuditTarget(r, "subject", subjectId)
+        auditChange(r, "title", old.Title, subject.Title)
+        auditChange(r, "parent", old.ParentId, subject.ParentId)
+        auditChange(r, "cover", old.Cover, subject.Cover)
+        auditChange(r, "position", old.Position, subject.Position)
+        if old.Description != subject.Description {
+            auditf(r, "description: %d → %d bytes", len(old.Description), len(subject.Description))
+        }
+
+        if err := s.rebuildSubjectEdit(r.Context(), subjectId); err != nil {
         	http.Error(w, err.Error(), http.StatusInternalServerError)
         	return
         }
@@ -868,21 +1112,55 @@ func (s *Server) handleEditSubject(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    subjects, err := subjectRepo.ListAll()
+    subjects, err := subjectRepo.ListAll(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+    // ?id=N opens the full form of one subject, otherwise the quick
+    // rename form
+    var subject *model.Subject
+    var covers []string
+
+    if idStr := r.URL.Query().Get("id"); idStr != "" {
+        id, err := strconv.ParseInt(idStr, 10, 64)
+        if err != nil {
+            http.NotFound(w, r)
+            return
+        }
+
+        found, err := subjectRepo.GetByID(r.Context(), id)
+        if err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                http.NotFound(w, r)
+                return
+            }
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        subject = &found
+
+        covers, err = s.listCommonFileNames()
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+    }
+
     data := struct {
 		Subjects []model.Subject
+		Subject  *model.Subject
+		Covers   []string
 	}{
 		Subjects: subjects,
+		Subject:  subject,
+		Covers:   covers,
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/edit_subject.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/edit_subject.html",
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -909,9 +1187,9 @@ func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id := int64(id64)
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+	subjectRepo := s.Repos.Subjects
 
-	subject, err := subjectRepo.GetByID(id)
+	subject, err := subjectRepo.GetByID(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.NotFound(w, r)
@@ -926,12 +1204,27 @@ func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := subjectRepo.Delete(id); err != nil {
+	subjects, err := subjectRepo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := subjectRepo.Delete(r.Context(), id); err != nil {
 		http.Error(w, "cannot delete subject with existing articles", http.StatusConflict)
 		return
 	}
 
-	if err := s.rebuildSubjectEvent(); err != nil {
+	auditf(r, "deleted %q", subject.Title)
+
+	// its children moved up a level, which changes their pages and the
+	// breadcrumbs of their articles
+	if len(model.SubjectChildren(subjects, id)) > 0 {
+		err = s.rebuildSite(r.Context())
+	} else {
+		err = s.rebuildSubjectEvent(r.Context())
+	}
+	if err != nil {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -945,6 +1238,97 @@ func (s *Server) handleDeleteSubject(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/subjects", http.StatusSeeOther)
 }
 
+// subjectParentFromForm returns the parent_id of a subject form, 0 for the
+// top level. The parent must exist and, when moving subject id, must not
+// be id itself or one of its descendants.
+func (s *Server) subjectParentFromForm(r *http.Request, id int64) (int64, error) {
+	v := strings.TrimSpace(r.FormValue("parent_id"))
+	if v == "" || v == "0" {
+		return 0, nil
+	}
+
+	parentId, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid parent id")
+	}
+
+	subjectRepo := s.Repos.Subjects
+
+	subjects, err := subjectRepo.ListAll(r.Context())
+	if err != nil {
+		return 0, err
+	}
+
+	if len(model.SubjectTrail(subjects, parentId)) == 0 {
+		return 0, errors.New("unknown parent id")
+	}
+
+	if id != 0 && model.SubjectDescendants(subjects, id)[parentId] {
+		return 0, errors.New("a subject cannot be nested under itself")
+	}
+
+	return parentId, nil
+}
+
+func (s *Server) handleMoveSubject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/admin/subjects/move/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subjectRepo := s.Repos.Subjects
+
+	subject, err := subjectRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	parentId, err := s.subjectParentFromForm(r, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if parentId != subject.ParentId {
+		if err := subjectRepo.SetParent(r.Context(), id, parentId); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		auditChange(r, "parent", subject.ParentId, parentId)
+
+		// subject pages and breadcrumbs all along both trails change
+		if err := s.rebuildSite(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("subject moved\n"))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/subjects", http.StatusSeeOther)
+}
+
 func (s *Server) handleSubject(w http.ResponseWriter, r *http.Request) {
 
     if r.Method != http.MethodGet {
@@ -952,26 +1336,50 @@ func (s *Server) handleSubject(w http.ResponseWriter, r *http.Request) {
     	return
     }
 
-	repo := db.SubjectRepo{DB: s.DB}
+	repo := s.Repos.Subjects
 
-	subjects, err := repo.ListAll()
+	subjects, err := repo.ListAll(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/subjects.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/subjects.html",
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+    // listed depth-first, so children sit under their parent
+    type subjectRow struct {
+        model.Subject
+        Depth int
+    }
+
+    var rows []subjectRow
+    var walk func(parent int64, depth int)
+    walk = func(parent int64, depth int) {
+        for _, sub := range model.SubjectChildren(subjects, parent) {
+            rows = append(rows, subjectRow{Subject: sub, Depth: depth})
+            walk(sub.Id, depth+1)
+        }
+    }
+    walk(0, 0)
+
+    data := struct {
+        Rows     []subjectRow
+        Subjects []model.Subject
+    }{
+        Rows:     rows,
+        Subjects: subjects,
+    }
+
     var buf bytes.Buffer
     
-    if err := tmpl.ExecuteTemplate(&buf, "base", subjects); err != nil {
+    if err := tmpl.ExecuteTemplate(&buf, "base", data); err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
@@ -987,9 +1395,9 @@ func (s *Server) handleReSlugAll(w http.ResponseWriter, r *http.Request) {
     	return
     }
 
-	articleRepo := db.ArticleRepo{DB: s.DB}
+	articleRepo := s.Repos.Articles
     
-    err := articleRepo.ReslugAll()
+    err := articleRepo.ReslugAll(r.Context())
 	if err != nil {
         http.Error(w, "internal server error: ReslugAll", http.StatusInternalServerError)
 		return
@@ -1005,7 +1413,7 @@ func (s *Server) handleBuildAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-    if err := s.rebuildSite(); err != nil {
+    if err := s.rebuildSite(r.Context()); err != nil {
     	http.Error(w, err.Error(), http.StatusInternalServerError)
     	return
     }
@@ -1042,11 +1450,21 @@ func (s *Server) handleCustomTheme(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		previous := s.currentTheme()
+
 		if err := s.applyTheme(selected); err != nil {
 			http.Error(w, "failed to apply theme", 500)
 			return
 		}
 
+		auditChange(r, "theme", previous, selected)
+
+		// the subject EPUBs embed the theme's code colors
+		if err := s.rebuildSite(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		http.Redirect(w, r, "/admin/theme", http.StatusSeeOther)
 		return
 	}
@@ -1062,9 +1480,9 @@ func (s *Server) handleCustomTheme(w http.ResponseWriter, r *http.Request) {
 		"CurrentTheme": s.currentTheme(),
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/custom_theme.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/custom_theme.html",
 	)
 	if err != nil {
 		http.Error(w, "template error", 500)
@@ -1103,11 +1521,15 @@ func (s *Server) handleCustomFont(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		previous := s.currentFont()
+
 		if err := s.applyFont(selected); err != nil {
 			http.Error(w, "failed to apply font", 500)
 			return
 		}
 
+		auditChange(r, "font", previous, selected)
+
 		http.Redirect(w, r, "/admin/font", http.StatusSeeOther)
 		return
 	}
@@ -1123,9 +1545,9 @@ func (s *Server) handleCustomFont(w http.ResponseWriter, r *http.Request) {
 		"CurrentFont": s.currentFont(),
 	}
 
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base.html",
-		"internal/templates/admin/custom_font.html",
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/custom_font.html",
 	)
 	if err != nil {
 		http.Error(w, "template error", 500)
@@ -1141,9 +1563,9 @@ func (s *Server) handleRequestArticles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repo := db.ArticleRepo{DB: s.DB}
+	repo := s.Repos.Articles
 
-	articles, err := repo.ListIDAndTitle()
+	articles, err := repo.ListIDAndTitle(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1163,9 +1585,9 @@ func (s *Server) handleRequestSubjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repo := db.SubjectRepo{DB: s.DB}
+	repo := s.Repos.Subjects
 
-	subjects, err := repo.ListIDAndTitle()
+	subjects, err := repo.ListIDAndTitle(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1175,7 +1597,7 @@ func (s *Server) handleRequestSubjects(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	for _, s := range subjects {
-		fmt.Fprintf(w, "%d\t%s\n", s.ID, s.Title)
+		fmt.Fprintf(w, "%d\t%s\t%d\n", s.ID, s.Title, s.ParentID)
 	}
 }
 
@@ -1197,9 +1619,9 @@ func (s *Server) handleImportArticle(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	repo := db.ArticleRepo{DB: s.DB}
+	repo := s.Repos.Articles
 
-	title, subject_id, is_public, err := repo.ImportByID(id)
+	article, err := repo.GetByID(r.Context(), id)
 	if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             http.NotFound(w, r)
@@ -1212,7 +1634,14 @@ func (s *Server) handleImportArticle(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 
-	fmt.Fprintf(w, "%s\t%d\t%t\n", title, subject_id, is_public)
+	publishAt := ""
+	if article.PublishAt != nil {
+		publishAt = article.PublishAt.Format(time.RFC3339)
+	}
+
+	fmt.Fprintf(w, "%s\t%d\t%t\t%d\t%s\t%s\t%d\t%t\t%t\t%t\n",
+		article.Title, article.SubjectId, article.IsPublic, article.AuthorId, publishAt,
+		article.Lang, article.TranslationGroup, article.PinnedHome, article.PinnedSubject, article.Featured)
 }
 
 func (s *Server) handleImportArticleContent(w http.ResponseWriter, r *http.Request) {
@@ -1234,9 +1663,9 @@ func (s *Server) handleImportArticleContent(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	repo := db.ArticleRepo{DB: s.DB}
+	repo := s.Repos.Articles
 
-	html, err := repo.GetHTMLByID(id)
+	html, err := repo.GetHTMLByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -1259,9 +1688,9 @@ func (s *Server) handleRequestSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	subjectRepo := db.SubjectRepo{DB: s.DB}
+	subjectRepo := s.Repos.Subjects
 
-	id, err := subjectRepo.GetIDBySlug(slug)
+	id, err := subjectRepo.GetIDBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			http.NotFound(w, r)
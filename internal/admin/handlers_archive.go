@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"blog/internal/db"
+)
+
+// maxArchiveSize bounds the site archive an import accepts.
+const maxArchiveSize = 2 << 30
+
+// handleArchive sends the whole site as an archive, see writeArchive. It
+// is written to a temporary file before the response starts, so a
+// failure answers 500 instead of a truncated archive.
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.DB == nil {
+		http.Error(w, "no SQL database to archive", http.StatusNotImplemented)
+		return
+	}
+
+	now := time.Now()
+
+	export, err := db.LoadExport(r.Context(), s.DB, s.dumpSettings(), now)
+	if err != nil {
+		http.Error(w, "failed to archive the site: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "statix-archive-*.tar.gz")
+	if err != nil {
+		http.Error(w, "failed to archive the site: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := s.writeArchive(tmp, export); err != nil {
+		http.Error(w, "failed to archive the site: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		http.Error(w, "failed to archive the site: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"statix-site-%s.tar.gz\"", now.Format("2006-01-02")))
+	w.Header().Set("Content-Length", fmt.Sprint(size))
+
+	io.Copy(w, tmp)
+}
+
+// handleImportArchive loads a site archive, sent as the request body by
+// stx or as the archive file of the admin form. The archive is unpacked
+// and checked in full first; the database is then replaced in one
+// transaction, the files are installed, the theme and font applied and
+// the site rebuilt.
+func (s *Server) handleImportArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.DB == nil {
+		http.Error(w, "no SQL database to import into", http.StatusNotImplemented)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize)
+
+	var body io.Reader = r.Body
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, _, err := r.FormFile("archive")
+		if err != nil {
+			http.Error(w, "missing archive file", http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	dir, err := os.MkdirTemp("", "statix-import-")
+	if err != nil {
+		http.Error(w, "import failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, export, err := s.readArchive(body, dir)
+	if err != nil {
+		http.Error(w, "invalid archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := db.RestoreExport(r.Context(), s.DB, export); err != nil {
+		http.Error(w, "import failed, nothing was changed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditf(r, "imported the archive of %s: %d articles, %d pages, %d files",
+		manifest.CreatedAt.UTC().Format(time.RFC3339), len(export.Articles), len(export.Pages), len(manifest.Files)-1)
+
+	if err := s.installArchiveFiles(dir, manifest); err != nil {
+		http.Error(w, "database imported, but installing the files failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.applyDumpSettings(manifest.Theme, manifest.Font)
+
+	if err := s.rebuildSite(r.Context()); err != nil {
+		http.Error(w, "imported, but the rebuild failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Imported %d articles, %d pages and %d files from %s.\n",
+			len(export.Articles), len(export.Pages), len(manifest.Files)-1,
+			manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"blog/internal/db"
+	"blog/internal/model"
+)
+
+// auditPageSize caps the entries the audit page lists; the export has
+// them all.
+const auditPageSize = 200
+
+type AuditView struct {
+	Entries []model.AuditEntry
+
+	// Actor to Until echo the filter form; ExportURL exports the same
+	// entries.
+	Actor     string
+	Auth      string
+	Route     string
+	Target    string
+	Since     string
+	Until     string
+	ExportURL string
+
+	// Truncated is set when more entries match than the page lists.
+	Truncated bool
+}
+
+// auditFilterFromQuery reads the filter of the audit page and export:
+// actor, auth, route, target, and since and until as YYYY-MM-DD local
+// days, until included.
+func auditFilterFromQuery(q url.Values) (db.AuditFilter, error) {
+	f := db.AuditFilter{
+		Actor:  q.Get("actor"),
+		Auth:   q.Get("auth"),
+		Route:  q.Get("route"),
+		Target: q.Get("target"),
+	}
+
+	if v := q.Get("since"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid since date %q", v)
+		}
+		f.Since = t
+	}
+
+	if v := q.Get("until"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("invalid until date %q", v)
+		}
+		f.Until = t.AddDate(0, 0, 1)
+	}
+
+	return f, nil
+}
+
+// handleAudit lists the audit log, latest first, filtered by the query.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+
+	filter, err := auditFilterFromQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = auditPageSize + 1
+
+	entries, err := s.Repos.Audit.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := AuditView{
+		Entries: entries,
+		Actor:   q.Get("actor"),
+		Auth:    q.Get("auth"),
+		Route:   q.Get("route"),
+		Target:  q.Get("target"),
+		Since:   q.Get("since"),
+		Until:   q.Get("until"),
+	}
+
+	data.ExportURL = "/admin/audit/export"
+	if r.URL.RawQuery != "" {
+		data.ExportURL += "?" + r.URL.RawQuery
+	}
+
+	if len(entries) > auditPageSize {
+		data.Entries = entries[:auditPageSize]
+		data.Truncated = true
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/audit.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleAuditExport sends the audit log entries matching the query as
+// CSV, latest first.
+func (s *Server) handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := auditFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := s.Repos.Audit.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"statix-audit-%s.csv\"", time.Now().Format("2006-01-02")))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "time", "actor", "auth", "method", "route", "status", "target", "summary", "ip"})
+
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			e.Actor,
+			e.Auth,
+			e.Method,
+			e.Route,
+			strconv.Itoa(e.Status),
+			e.Target,
+			e.Summary,
+			e.IP,
+		})
+	}
+
+	cw.Flush()
+}
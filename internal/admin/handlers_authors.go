@@ -0,0 +1,369 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/internal/model"
+	"blog/internal/utils"
+)
+
+// authorFromForm returns the author_id of an article form, or fallback
+// when the form has none. A zero fallback means the default author.
+func (s *Server) authorFromForm(r *http.Request, fallback int64) (int64, error) {
+	repo := s.Repos.Authors
+
+	v := r.FormValue("author_id")
+	if v == "" {
+		if fallback != 0 {
+			return fallback, nil
+		}
+		return repo.GetDefaultID(r.Context())
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid author id")
+	}
+
+	if _, err := repo.GetByID(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("unknown author id")
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// authorFromRequest applies the submitted author fields over base.
+func authorFromRequest(r *http.Request, base model.Author) (model.Author, error) {
+	a := base
+
+	if err := r.ParseForm(); err != nil {
+		return a, err
+	}
+
+	a.Name = strings.TrimSpace(r.PostFormValue("name"))
+	if a.Name == "" || utils.Slugify(a.Name) == "" {
+		return a, errors.New("name is required")
+	}
+
+	a.Bio = r.PostFormValue("bio")
+	a.Avatar = strings.TrimSpace(r.PostFormValue("avatar"))
+	a.Links = model.ParseAuthorLinks(r.PostFormValue("links"))
+
+	return a, nil
+}
+
+// rebuildAuthors writes the author pages after a change. Bylines are on
+// every article page, so a renamed author rebuilds the whole site.
+func (s *Server) rebuildAuthors(ctx context.Context, oldSlug string, renamed bool) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	ctx = detached(ctx)
+
+	gen, err := s.siteGenerator(ctx)
+	if err != nil {
+		return err
+	}
+
+	if oldSlug != "" {
+		if err := gen.RemoveAuthor(oldSlug); err != nil {
+			return err
+		}
+	}
+
+	if renamed {
+		return gen.BuildAll(ctx)
+	}
+
+	if err := gen.BuildAuthors(ctx); err != nil {
+		return err
+	}
+
+	return gen.BuildSitemap(ctx)
+}
+
+func (s *Server) renderAuthorForm(w http.ResponseWriter, author model.Author, isNew bool) {
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/edit_author.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	action := "/admin/authors/new"
+	if !isNew {
+		action = fmt.Sprintf("/admin/authors/edit/%d", author.ID)
+	}
+
+	data := struct {
+		Author model.Author
+		Links  string
+		IsNew  bool
+		Action string
+	}{
+		Author: author,
+		Links:  model.FormatAuthorLinks(author.Links),
+		IsNew:  isNew,
+		Action: action,
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleAuthors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo := s.Repos.Authors
+
+	authors, err := repo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	defaultID, err := repo.GetDefaultID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/authors.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Authors   []model.Author
+		DefaultID int64
+	}{
+		Authors:   authors,
+		DefaultID: defaultID,
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleNewAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.renderAuthorForm(w, model.Author{}, true)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	author, err := authorFromRequest(r, model.Author{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repo := s.Repos.Authors
+
+	exists, err := repo.ExistsByName(r.Context(), author.Name, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if exists {
+		http.Error(w, "Author already exists", http.StatusConflict)
+		return
+	}
+
+	newID, err := repo.Create(r.Context(), author)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setAuditTarget(r, "author", newID)
+	auditf(r, "created %q", author.Name)
+
+	if err := s.rebuildAuthors(r.Context(), "", false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/authors", http.StatusSeeOther)
+}
+
+func (s *Server) handleEditAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/admin/authors/edit/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	repo := s.Repos.Authors
+
+	old, err := repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.renderAuthorForm(w, old, false)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	author, err := authorFromRequest(r, old)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exists, err := repo.ExistsByName(r.Context(), author.Name, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if exists {
+		http.Error(w, "Author already exists", http.StatusConflict)
+		return
+	}
+
+	if err := repo.Update(r.Context(), author); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditChange(r, "name", old.Name, author.Name)
+	auditChange(r, "avatar", old.Avatar, author.Avatar)
+	if old.Bio != author.Bio {
+		auditf(r, "bio: %d → %d bytes", len(old.Bio), len(author.Bio))
+	}
+	auditChange(r, "links", len(old.Links), len(author.Links))
+
+	oldSlug := ""
+	if utils.Slugify(author.Name) != old.Slug {
+		oldSlug = old.Slug
+	}
+
+	if err := s.rebuildAuthors(r.Context(), oldSlug, author.Name != old.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/authors", http.StatusSeeOther)
+}
+
+func (s *Server) handleDeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/admin/authors/delete/"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	repo := s.Repos.Authors
+
+	author, err := repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	defaultID, err := repo.GetDefaultID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if id == defaultID {
+		http.Error(w, "The default author cannot be deleted", http.StatusConflict)
+		return
+	}
+
+	n, err := repo.CountArticles(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if n > 0 {
+		http.Error(w, fmt.Sprintf("%s still has %d articles", author.Name, n), http.StatusConflict)
+		return
+	}
+
+	if err := repo.Delete(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditf(r, "deleted %q", author.Name)
+
+	if err := s.rebuildAuthors(r.Context(), author.Slug, false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/authors", http.StatusSeeOther)
+}
+
+func (s *Server) handleListAuthorsAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo := s.Repos.Authors
+
+	authors, err := repo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for _, a := range authors {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", a.ID, a.Name, a.Slug)
+	}
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"blog/internal/generator"
+)
+
+func (s *Server) checkLinks(ctx context.Context) (*generator.LinkReport, error) {
+	articleRepo := s.Repos.Articles
+
+	articles, err := articleRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generator.Generator{
+		Articles:  articles,
+		OutDir:    s.OutDir,
+		AssetsDir: s.AssetsDir,
+	}
+
+	return gen.CheckLinks()
+}
+
+func (s *Server) handleCheckLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := s.checkLinks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/check.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Report      *generator.LinkReport
+		Broken      int
+		Missing     int
+		Private     int
+		StrictLinks bool
+	}{
+		Report:      report,
+		Broken:      report.Count(generator.BrokenLink),
+		Missing:     report.Count(generator.MissingImage),
+		Private:     report.Count(generator.PrivateArticle),
+		StrictLinks: s.StrictLinks,
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleCheckLinksAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := s.checkLinks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	report.WriteText(w)
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"blog/internal/generator"
+)
+
+func (s *Server) dryRun(ctx context.Context) (*generator.BuildDiff, error) {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	gen, err := s.siteGenerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return gen.DryRun(ctx)
+}
+
+func (s *Server) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	diff, err := s.dryRun(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/dry_run.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Diff     *generator.BuildDiff
+		Added    int
+		Removed  int
+		Modified int
+	}{
+		Diff:     diff,
+		Added:    diff.Count(generator.FileAdded),
+		Removed:  diff.Count(generator.FileRemoved),
+		Modified: diff.Count(generator.FileModified),
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleDryRunAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	diff, err := s.dryRun(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	diff.WriteText(w)
+}
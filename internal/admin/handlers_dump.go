@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"blog/internal/db"
+)
+
+// maxDumpSize bounds the export a restore accepts.
+const maxDumpSize = 256 << 20
+
+// dumpSettings are the site settings kept outside the database that an
+// export carries: the theme and font symlinks.
+func (s *Server) dumpSettings() map[string]string {
+	settings := make(map[string]string)
+
+	if theme := s.currentTheme(); theme != "" {
+		settings["theme"] = theme
+	}
+	if font := s.currentFont(); font != "" {
+		settings["font"] = font
+	}
+
+	return settings
+}
+
+// applyDumpSettings selects the theme and font a restore carries. One
+// missing here, or not installed, leaves the current one.
+func (s *Server) applyDumpSettings(theme, font string) {
+	if theme != "" && theme != s.currentTheme() {
+		err := s.checkDumpSettings(theme, "")
+		if err == nil {
+			err = s.applyTheme(theme)
+		}
+		if err != nil {
+			log.Printf("restore: theme %q: %v", theme, err)
+		}
+	}
+	if font != "" && font != s.currentFont() {
+		err := s.checkDumpSettings("", font)
+		if err == nil {
+			err = s.applyFont(font)
+		}
+		if err != nil {
+			log.Printf("restore: font %q: %v", font, err)
+		}
+	}
+}
+
+// checkDumpSettings refuses a theme or font that is not installed, as
+// handleCustomTheme does. The names come from an upload and end up in
+// symlink targets. An empty name is not checked.
+func (s *Server) checkDumpSettings(theme, font string) error {
+	if theme != "" {
+		themes, err := s.listThemes()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := checkSettingName("theme", theme, themes); err != nil {
+			return err
+		}
+	}
+
+	if font != "" {
+		fonts, err := s.listFonts()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := checkSettingName("font", font, fonts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSettingName reports an error unless name is one of installed.
+func checkSettingName(kind, name string, installed []string) error {
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+
+	for _, n := range installed {
+		if n == name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s %q is not installed", kind, name)
+}
+
+// handleDumpDb sends the whole database as an export, see db.Export. It
+// is written out in full before the response starts, so a failure
+// answers 500 instead of a truncated file.
+func (s *Server) handleDumpDb(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.DB == nil {
+		http.Error(w, "no SQL database to dump", http.StatusNotImplemented)
+		return
+	}
+
+	now := time.Now()
+
+	export, err := db.LoadExport(r.Context(), s.DB, s.dumpSettings(), now)
+	if err != nil {
+		http.Error(w, "failed to dump database: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err := export.WriteTo(&buf); err != nil {
+		http.Error(w, "failed to dump database: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"statix-%s.ndjson\"", now.Format("2006-01-02")))
+	w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+
+	buf.WriteTo(w)
+}
+
+// handleRestoreDump replaces the database with an export, sent as the
+// request body by stx or as the dump file of the admin form. The export
+// is checked in full before anything is loaded, and loaded in one
+// transaction; the site is then rebuilt and the settings applied.
+func (s *Server) handleRestoreDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.DB == nil {
+		http.Error(w, "no SQL database to restore", http.StatusNotImplemented)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDumpSize)
+
+	var body io.Reader = r.Body
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, _, err := r.FormFile("dump")
+		if err != nil {
+			http.Error(w, "missing dump file", http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	export, err := db.ReadExport(body)
+	if err != nil {
+		http.Error(w, "invalid dump: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.checkDumpSettings(export.Settings["theme"], export.Settings["font"]); err != nil {
+		http.Error(w, "invalid dump: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := db.RestoreExport(r.Context(), s.DB, export); err != nil {
+		http.Error(w, "restore failed, nothing was changed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditf(r, "restored the dump of %s: %d articles, %d pages",
+		export.Header.CreatedAt.UTC().Format(time.RFC3339), len(export.Articles), len(export.Pages))
+
+	s.applyDumpSettings(export.Settings["theme"], export.Settings["font"])
+
+	if err := s.rebuildSite(r.Context()); err != nil {
+		http.Error(w, "restored, but the rebuild failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Restored %d subjects, %d authors, %d pages, %d articles and %d revisions from %s.\n",
+			len(export.Subjects), len(export.Authors), len(export.Pages), len(export.Articles), len(export.Revisions),
+			export.Header.CreatedAt.Local().Format("2006-01-02 15:04"))
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
@@ -2,19 +2,25 @@ package admin
 
 import (
 	"fmt"
-	"html/template"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"mime/multipart"
-)
+	"strings"
 
-const (
-	stagingDir = "_uploads"
-	publicDir  = "assets/common_files"
+	"blog/internal/model"
 )
 
+const stagingDir = "_uploads"
+
+// filesDir is where uploaded files are published, served under
+// model.CommonFilesURL.
+func (s *Server) filesDir() string {
+	return filepath.Join(s.AssetsDir, "common_files")
+}
+
 type UploadedFile struct {
 	Name string
 	Size string
@@ -25,15 +31,15 @@ func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
 
 	// -------- GET: list + form --------
 	case http.MethodGet:
-		files, err := listPublishedFiles(publicDir)
+		files, err := listPublishedFiles(s.filesDir())
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		tmpl, err := template.ParseFiles(
-			"internal/templates/base.html",
-			"internal/templates/admin/files.html",
+		tmpl, err := s.Templates.Parse(nil,
+			"base.html",
+			"admin/files.html",
 		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -62,17 +68,18 @@ func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := os.MkdirAll(publicDir, 0o755); err != nil {
+		if err := os.MkdirAll(s.filesDir(), 0o755); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		// publish files ONE BY ONE, atomically
 		for _, fh := range files {
-			if err := publishFileAtomically(fh); err != nil {
+			if err := publishFileAtomically(fh, s.filesDir()); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+			auditf(r, "uploaded %s (%d bytes)", filepath.Base(fh.Filename), fh.Size)
 		}
 
         if r.Header.Get("X-Statix-Token") != "" {
@@ -89,7 +96,7 @@ func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func publishFileAtomically(fh *multipart.FileHeader) error {
+func publishFileAtomically(fh *multipart.FileHeader, publicDir string) error {
 	// 1. Sanitize filename (security boundary)
 	name := filepath.Base(fh.Filename)
 	if name == "" || name == "." || name == ".." {
@@ -154,7 +161,7 @@ func (s *Server) handleListFilesAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, err := listPublishedFiles(publicDir)
+	files, err := listPublishedFiles(s.filesDir())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -248,6 +255,51 @@ func listPublishedFiles(path string) ([]UploadedFile, error) {
 	return files, nil
 }
 
+// listCommonFileNames returns the published files as names relative to
+// common_files, as stored in a subject's cover.
+func (s *Server) listCommonFileNames() ([]string, error) {
+	publicDir := s.filesDir()
+
+	files, err := listPublishedFiles(publicDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		rel, err := filepath.Rel(publicDir, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, filepath.ToSlash(rel))
+	}
+
+	return names, nil
+}
+
+// commonFileName checks that v names a published file and returns its
+// name relative to common_files. v may also be the file's URL. An empty
+// v is returned as is.
+func (s *Server) commonFileName(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	v = strings.TrimPrefix(v, model.CommonFilesURL)
+	if v == "" {
+		return "", nil
+	}
+
+	name := path.Clean(v)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("%s is not in common_files", v)
+	}
+
+	info, err := os.Stat(filepath.Join(s.filesDir(), filepath.FromSlash(name)))
+	if err != nil || info.IsDir() {
+		return "", fmt.Errorf("%s is not in common_files", v)
+	}
+
+	return name, nil
+}
+
 func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -262,7 +314,7 @@ func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	path := filepath.Join(publicDir, name)
+	path := filepath.Join(s.filesDir(), name)
 
 	if err := os.Remove(path); err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,244 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/internal/generator"
+	"blog/internal/model"
+)
+
+// diffContext is how many unchanged lines the revision diff keeps around
+// each change.
+const diffContext = 3
+
+// articleFromPath loads the article whose id is idStr, a URL path
+// segment. It writes the error response itself and reports whether it
+// found the article.
+func (s *Server) articleFromPath(w http.ResponseWriter, r *http.Request, idStr string) (model.Article, bool) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return model.Article{}, false
+	}
+
+	a, err := s.Repos.Articles.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return model.Article{}, false
+	}
+
+	return a, true
+}
+
+// handleHistory lists the revisions of an article and shows one, the
+// latest unless ?rev= picks another, side by side with the one before.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	article, ok := s.articleFromPath(w, r, strings.TrimPrefix(r.URL.Path, "/admin/history/"))
+	if !ok {
+		return
+	}
+
+	revisions, err := s.Repos.Articles.ListRevisions(r.Context(), article.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(revisions) == 0 {
+		http.Error(w, "article has no revisions", http.StatusNotFound)
+		return
+	}
+
+	selected := 0
+	if v := r.URL.Query().Get("rev"); v != "" {
+		selected = -1
+		for i, rev := range revisions {
+			if strconv.FormatInt(rev.ID, 10) == v {
+				selected = i
+			}
+		}
+		if selected < 0 {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	var previous model.Revision
+	if selected+1 < len(revisions) {
+		previous = revisions[selected+1]
+	}
+
+	subjects, err := s.Repos.Subjects.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	subjectTitles := make(map[int64]string)
+	for _, sub := range subjects {
+		subjectTitles[sub.Id] = sub.Title
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/history.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Article   model.Article
+		Revisions []model.Revision
+		Selected  model.Revision
+		Previous  model.Revision
+		Rows      []generator.DiffRow
+		Subjects  map[int64]string
+	}{
+		Article:   article,
+		Revisions: revisions,
+		Selected:  revisions[selected],
+		Previous:  previous,
+		Rows:      generator.SideBySide(previous.HTML, revisions[selected].HTML, diffContext),
+		Subjects:  subjectTitles,
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleHistoryAPI lists the revisions of an article for `stx nickname
+// history`, latest first: id, time, actor, visibility and title.
+func (s *Server) handleHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	article, ok := s.articleFromPath(w, r, strings.TrimPrefix(r.URL.Path, "/admin/api/history/"))
+	if !ok {
+		return
+	}
+
+	revisions, err := s.Repos.Articles.ListRevisions(r.Context(), article.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for _, rev := range revisions {
+		visibility := "private"
+		if rev.IsPublic {
+			visibility = "public"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			rev.ID, rev.CreatedAt.Local().Format("2006-01-02 15:04"), rev.Actor, visibility, rev.Title)
+	}
+}
+
+// handleRestoreRevision saves the title, subject, visibility and content
+// of /admin/restore/<article>/<rev> over the article, as a new revision,
+// and rebuilds it like an edit.
+func (s *Server) handleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articlePart, revPart, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/restore/"), "/")
+
+	revID, err := strconv.ParseInt(revPart, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	old, ok := s.articleFromPath(w, r, articlePart)
+	if !ok {
+		return
+	}
+
+	repo := s.Repos.Articles
+
+	rev, err := repo.GetRevision(r.Context(), revID)
+	if err != nil || rev.ArticleID != old.ID {
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.Repos.Subjects.GetByID(r.Context(), rev.SubjectId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "the subject of this revision was deleted", http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	exists, err := repo.ExistsByTitle(r.Context(), rev.Title, old.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		http.Error(w, "Article Title already exists", http.StatusConflict)
+		return
+	}
+
+	article := old
+	article.Title = rev.Title
+	article.SubjectId = rev.SubjectId
+	article.IsPublic = rev.IsPublic
+	article.HTML = rev.HTML
+
+	// a public revision ends a pending schedule, as publishing by hand does
+	if article.IsPublic {
+		article.PublishAt = nil
+	}
+
+	if err := repo.Update(r.Context(), article, actor(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditf(r, "restored revision %d", revID)
+	auditArticle(r, old, article)
+
+	if err := s.rebuildEdited(r.Context(), old, article); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// stx updates the nickname from this line
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%d\t%t\t%s\n", article.SubjectId, article.IsPublic, article.Title)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/admin/history/%d", old.ID), http.StatusSeeOther)
+}
@@ -0,0 +1,392 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/internal/generator"
+	"blog/internal/model"
+	"blog/internal/utils"
+)
+
+// rebuildPage writes a created, edited or deleted page. The topbar menu
+// is on every page, so a change to it rebuilds the whole site.
+func (s *Server) rebuildPage(ctx context.Context, oldSlug, newSlug string, menuChanged bool) error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	ctx = detached(ctx)
+
+	gen, err := s.siteGenerator(ctx)
+	if err != nil {
+		return err
+	}
+
+	if oldSlug != "" && oldSlug != newSlug {
+		if err := gen.RemovePage(oldSlug); err != nil {
+			return err
+		}
+	}
+
+	if menuChanged {
+		return gen.BuildAll(ctx)
+	}
+
+	if newSlug != "" {
+		if err := gen.BuildPage(ctx, newSlug); err != nil {
+			return err
+		}
+	}
+
+	return gen.BuildSitemap(ctx)
+}
+
+func inMenu(p model.Page) bool {
+	return p.IsPublic && p.NavOrder > 0
+}
+
+func menuChanged(old, new model.Page) bool {
+	if !inMenu(old) && !inMenu(new) {
+		return false
+	}
+	return inMenu(old) != inMenu(new) ||
+		old.Title != new.Title ||
+		old.Slug != new.Slug ||
+		old.NavOrder != new.NavOrder
+}
+
+// pageFromForm applies the submitted page fields over base and validates
+// the result. Fields missing from the form keep their value, so stx can
+// send only what changes.
+func pageFromForm(r *http.Request, base model.Page) (model.Page, error) {
+	p := base
+
+	if err := r.ParseForm(); err != nil {
+		return p, err
+	}
+
+	if _, ok := r.PostForm["title"]; ok {
+		p.Title = strings.TrimSpace(r.PostFormValue("title"))
+	}
+	if p.Title == "" {
+		return p, errors.New("title is required")
+	}
+
+	if v := strings.TrimSpace(r.PostFormValue("slug")); v != "" {
+		p.Slug = v
+	}
+	if p.Slug == "" {
+		p.Slug = utils.Slugify(p.Title)
+	}
+	if err := generator.ValidPageSlug(p.Slug); err != nil {
+		return p, err
+	}
+
+	if v := r.PostFormValue("nav_order"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, errors.New("invalid menu position")
+		}
+		p.NavOrder = n
+	}
+
+	if v := r.PostFormValue("is_public"); v != "" {
+		isPublic, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, errors.New("invalid visibility")
+		}
+		p.IsPublic = isPublic
+	}
+
+	if _, ok := r.PostForm["html"]; ok {
+		p.HTML = r.PostFormValue("html")
+	}
+
+	return p, nil
+}
+
+func (s *Server) renderPageForm(w http.ResponseWriter, page model.Page, isNew bool) {
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/edit_page.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	action := "/admin/pages/new"
+	if !isNew {
+		action = "/admin/pages/edit/" + page.Slug
+	}
+
+	data := struct {
+		Page   model.Page
+		IsNew  bool
+		Action string
+	}{
+		Page:   page,
+		IsNew:  isNew,
+		Action: action,
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handlePages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo := s.Repos.Pages
+
+	pages, err := repo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/pages.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", pages); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleNewPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		s.renderPageForm(w, model.Page{IsPublic: true}, true)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := pageFromForm(r, model.Page{IsPublic: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repo := s.Repos.Pages
+
+	exists, err := repo.ExistsBySlug(r.Context(), page.Slug, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if exists {
+		http.Error(w, "Page slug already exists", http.StatusConflict)
+		return
+	}
+
+	if _, err := repo.Create(r.Context(), page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setAuditTarget(r, "page", page.Slug)
+	auditf(r, "created %q", page.Title)
+
+	if err := s.rebuildPage(r.Context(), "", page.Slug, inMenu(page)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%s\n", page.Slug)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+func (s *Server) handleEditPage(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/admin/pages/edit/")
+	if slug == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	repo := s.Repos.Pages
+
+	old, err := repo.GetBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.renderPageForm(w, old, false)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := pageFromForm(r, old)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exists, err := repo.ExistsBySlug(r.Context(), page.Slug, page.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if exists {
+		http.Error(w, "Page slug already exists", http.StatusConflict)
+		return
+	}
+
+	if err := repo.Update(r.Context(), page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditChange(r, "title", old.Title, page.Title)
+	auditChange(r, "slug", old.Slug, page.Slug)
+	auditChange(r, "nav", old.NavOrder, page.NavOrder)
+	auditChange(r, "public", old.IsPublic, page.IsPublic)
+	if old.HTML != page.HTML {
+		auditf(r, "html: %d → %d bytes", len(old.HTML), len(page.HTML))
+	}
+
+	if err := s.rebuildPage(r.Context(), old.Slug, page.Slug, menuChanged(old, page)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("page edited\n"))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+func (s *Server) handleDeletePage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	slug := strings.TrimPrefix(r.URL.Path, "/admin/pages/delete/")
+
+	repo := s.Repos.Pages
+
+	page, err := repo.GetBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := repo.Delete(r.Context(), page.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditf(r, "deleted %q", page.Title)
+
+	if err := s.rebuildPage(r.Context(), page.Slug, "", inMenu(page)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("X-Statix-Token") != "" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("page deleted\n"))
+		return
+	}
+
+	http.Redirect(w, r, "/admin/pages", http.StatusSeeOther)
+}
+
+// handleAuthor keeps the old entry point: the author page is the page
+// with slug "author".
+func (s *Server) handleAuthor(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/admin/pages/edit/author", http.StatusSeeOther)
+}
+
+func (s *Server) handleListPagesAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo := s.Repos.Pages
+
+	pages, err := repo.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for _, p := range pages {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%t\n", p.Slug, p.Title, p.NavOrder, p.IsPublic)
+	}
+}
+
+func (s *Server) handlePageContentAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	slug := strings.TrimPrefix(r.URL.Path, "/admin/api/pages/")
+
+	repo := s.Repos.Pages
+
+	page, err := repo.GetBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write([]byte(page.HTML))
+}
@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"blog/internal/db"
+	"blog/internal/generator"
+	"blog/internal/model"
+)
+
+const (
+	// searchLimit caps the hits of one search, best first.
+	searchLimit = 50
+
+	// snippetWidth is about how many characters of the body a hit shows.
+	snippetWidth = 200
+)
+
+type SearchHit struct {
+	model.Article
+	TitleHTML template.HTML
+	Snippet   template.HTML
+}
+
+type SearchView struct {
+	Query string
+	Hits  []SearchHit
+}
+
+// handleSearch shows the articles matching ?q=, their title and a
+// snippet of their body with the matching words highlighted.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	articles, err := s.Repos.Articles.Search(r.Context(), query, searchLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	terms := db.SearchTerms(query)
+
+	data := SearchView{Query: query}
+	for _, a := range articles {
+		data.Hits = append(data.Hits, SearchHit{
+			Article:   a,
+			TitleHTML: generator.Highlight(a.Title, terms),
+			Snippet:   generator.Snippet(a.HTML, terms, snippetWidth),
+		})
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/search.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleSearchAPI lists the articles matching ?q= for `stx search`, best
+// first: id and title.
+func (s *Server) handleSearchAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articles, err := s.Repos.Articles.Search(r.Context(), r.URL.Query().Get("q"), searchLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	for _, a := range articles {
+		fmt.Fprintf(w, "%d\t%s\n", a.ID, a.Title)
+	}
+}
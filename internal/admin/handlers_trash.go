@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/internal/model"
+)
+
+// TrashedArticle is an article in the trash and when the scheduler will
+// purge it, nil when TrashRetention is zero.
+type TrashedArticle struct {
+	model.Article
+	PurgeAt *time.Time
+}
+
+type TrashView struct {
+	Articles      []TrashedArticle
+	Subjects      map[int64]string
+	RetentionDays int
+}
+
+// handleTrash lists the deleted articles, last deleted first.
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trash, err := s.Repos.Articles.ListTrash(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	subjects, err := s.Repos.Subjects.ListAll(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := TrashView{
+		Subjects:      make(map[int64]string),
+		RetentionDays: int(s.TrashRetention / (24 * time.Hour)),
+	}
+
+	for _, sub := range subjects {
+		data.Subjects[sub.Id] = sub.Title
+	}
+
+	for _, a := range trash {
+		t := TrashedArticle{Article: a}
+		if s.TrashRetention > 0 {
+			purgeAt := a.DeletedAt.Add(s.TrashRetention)
+			t.PurgeAt = &purgeAt
+		}
+		data.Articles = append(data.Articles, t)
+	}
+
+	tmpl, err := s.Templates.Parse(nil,
+		"base.html",
+		"admin/trash.html",
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "base", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleTrashAction restores (/admin/trash/restore/<id>) or purges
+// (/admin/trash/purge/<id>) a trashed article, or purges them all
+// (/admin/trash/empty).
+func (s *Server) handleTrashAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	repo := s.Repos.Articles
+
+	action, idStr, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/trash/"), "/")
+
+	if action == "empty" {
+		// every trashed article was trashed before now
+		n, err := repo.PurgeTrashedBefore(r.Context(), time.Now().Add(time.Second))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		auditf(r, "purged %d articles", n)
+		http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || (action != "restore" && action != "purge") {
+		http.NotFound(w, r)
+		return
+	}
+
+	trash, err := repo.ListTrash(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var article model.Article
+	for _, a := range trash {
+		if a.ID == id {
+			article = a
+		}
+	}
+	if article.ID == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if action == "purge" {
+		// the page already went with the deletion
+		if err := repo.Purge(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		auditf(r, "purged %q", article.Title)
+		http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+		return
+	}
+
+	// the article kept its title, subject and author in the trash, so it
+	// comes back as it was and is rebuilt like a new one
+	if err := repo.Untrash(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	auditf(r, "restored %q from the trash", article.Title)
+
+	if article.TranslationGroup != 0 {
+		err = s.rebuildTranslations(r.Context(), "", false)
+	} else {
+		err = s.rebuildSiteLocalize(r.Context(), article.Title, article.Lang, article.SubjectId, 0, true, false)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
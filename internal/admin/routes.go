@@ -3,17 +3,72 @@ package admin
 import (
 	"database/sql"
 	"net/http"
+	"sync"
+	"time"
+
+	"blog/internal/config"
+	"blog/internal/db"
+	"blog/internal/generator"
+	"blog/internal/templates"
 )
 
 type Server struct {
+    Repos db.Repos
+
+    // DB and DBDriver are the SQL database behind Repos, used by the
+    // database dump. DB is nil when Repos live in memory.
 	DB *sql.DB
+    DBDriver string
     AdminPass string
+    StrictLinks bool
+    Templates *templates.Loader
+    Plugins generator.Plugins
+
+    // OutDir is where the site is built and NginxConf where the error
+    // pages snippet is written. NewServerWithRepos sets these paths as
+    // quickstart.sh expects them, relative to the service's directory.
+    OutDir string
+    NginxConf string
+
+    // AssetsDir holds the uploaded files (common_files), the themes and
+    // fonts, and the symlinks selecting one of each.
+    AssetsDir string
+
+    // TrashRetention is how long a deleted article stays in the trash
+    // before the scheduler purges it. Zero keeps it until purged by hand.
+    TrashRetention time.Duration
+
+    // buildMu serializes writes to dist/ between handlers and the
+    // publishing scheduler.
+    buildMu sync.Mutex
 }
 
-func NewRouter(db *sql.DB, adminPass string) http.Handler {
-	s := &Server{DB: db,
-                 AdminPass: adminPass}
+func NewServer(conn *sql.DB, cfg config.Config, plugins generator.Plugins) *Server {
+	s := NewServerWithRepos(db.NewRepos(conn, cfg.DB), cfg, plugins)
+	s.DB = conn
+	s.DBDriver = cfg.DB.Driver
+	return s
+}
+
+// NewServerWithRepos returns a server over any storage, e.g. memdb in
+// tests.
+func NewServerWithRepos(repos db.Repos, cfg config.Config, plugins generator.Plugins) *Server {
+	return &Server{Repos: repos,
+                 AdminPass: cfg.AdminPass,
+                 StrictLinks: cfg.StrictLinks,
+                 Templates: &templates.Loader{Dir: cfg.TemplateDir, Dev: cfg.Dev},
+                 Plugins: plugins,
+                 OutDir: "dist",
+                 NginxConf: nginxErrorPagesConf,
+                 AssetsDir: "assets",
+                 TrashRetention: time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour}
+}
 
+func NewRouter(db *sql.DB, cfg config.Config, plugins generator.Plugins) http.Handler {
+	return NewServer(db, cfg, plugins).Routes()
+}
+
+func (s *Server) Routes() http.Handler {
 	mux := http.NewServeMux()
 	
     mux.HandleFunc("/admin/login",  s.handleLogin)
@@ -23,22 +78,46 @@ func NewRouter(db *sql.DB, adminPass string) http.Handler {
     mux.HandleFunc("/admin/new",       s.requireAuth(s.handleNewArticle))
     mux.HandleFunc("/admin/articles/", s.requireAuth(s.handleEditArticle))
     mux.HandleFunc("/admin/delete/",   s.requireAuth(s.handleDeleteArticle))
+    mux.HandleFunc("/admin/pin/",      s.requireAuth(s.handleToggleArticle))
+    mux.HandleFunc("/admin/feature/",  s.requireAuth(s.handleToggleArticle))
+    mux.HandleFunc("/admin/history/",  s.requireAuth(s.handleHistory))
+    mux.HandleFunc("/admin/restore/",  s.requireAuth(s.handleRestoreRevision))
+    mux.HandleFunc("/admin/trash",     s.requireAuth(s.handleTrash))
+    mux.HandleFunc("/admin/trash/",    s.requireAuth(s.handleTrashAction))
+    mux.HandleFunc("/admin/search",    s.requireAuth(s.handleSearch))
+    mux.HandleFunc("/admin/audit",     s.requireAuth(s.handleAudit))
+    mux.HandleFunc("/admin/audit/export", s.requireAuth(s.handleAuditExport))
     
     mux.HandleFunc("/admin/subjects",         s.requireAuth(s.handleSubject))
     mux.HandleFunc("/admin/subjects/delete/", s.requireAuth(s.handleDeleteSubject))
     mux.HandleFunc("/admin/subjects/edit",    s.requireAuth(s.handleEditSubject))
     mux.HandleFunc("/admin/subjects/add",     s.requireAuth(s.handleNewSubject))
+    mux.HandleFunc("/admin/subjects/move/",   s.requireAuth(s.handleMoveSubject))
 
     mux.HandleFunc("/admin/files",         s.requireAuth(s.handleFiles))
 	mux.HandleFunc("/admin/files/delete/", s.requireAuth(s.handleDeleteFile))
 
     mux.HandleFunc("/admin/dump",          s.requireAuth(s.handleDumpDb))
+    mux.HandleFunc("/admin/dump/restore",  s.requireAuth(s.handleRestoreDump))
+    mux.HandleFunc("/admin/archive",       s.requireAuth(s.handleArchive))
+    mux.HandleFunc("/admin/archive/import", s.requireAuth(s.handleImportArchive))
     mux.HandleFunc("/admin/build_all",     s.requireAuth(s.handleBuildAll))
 
     mux.HandleFunc("/admin/reslug",        s.requireAuth(s.handleReSlugAll))
+    mux.HandleFunc("/admin/check",         s.requireAuth(s.handleCheckLinks))
+    mux.HandleFunc("/admin/dry_run",       s.requireAuth(s.handleDryRun))
 
     mux.HandleFunc("/admin/author",         s.requireAuth(s.handleAuthor))
-	mux.HandleFunc("/admin/author/update",  s.requireAuth(s.handleUpdateAuthor))
+
+    mux.HandleFunc("/admin/authors",         s.requireAuth(s.handleAuthors))
+    mux.HandleFunc("/admin/authors/new",     s.requireAuth(s.handleNewAuthor))
+    mux.HandleFunc("/admin/authors/edit/",   s.requireAuth(s.handleEditAuthor))
+    mux.HandleFunc("/admin/authors/delete/", s.requireAuth(s.handleDeleteAuthor))
+
+    mux.HandleFunc("/admin/pages",          s.requireAuth(s.handlePages))
+    mux.HandleFunc("/admin/pages/new",      s.requireAuth(s.handleNewPage))
+    mux.HandleFunc("/admin/pages/edit/",    s.requireAuth(s.handleEditPage))
+    mux.HandleFunc("/admin/pages/delete/",  s.requireAuth(s.handleDeletePage))
 
 	mux.HandleFunc("/admin/theme",  s.requireAuth(s.handleCustomTheme))
 	mux.HandleFunc("/admin/font",   s.requireAuth(s.handleCustomFont))
@@ -48,14 +127,21 @@ func NewRouter(db *sql.DB, adminPass string) http.Handler {
     mux.HandleFunc("/admin/api/articles-content/", s.requireAuth(s.handleImportArticleContent))
     mux.HandleFunc("/admin/api/subjects",          s.requireAuth(s.handleRequestSubjects))
     mux.HandleFunc("/admin/api/files",             s.requireAuth(s.handleListFilesAPI))
+    mux.HandleFunc("/admin/api/check",             s.requireAuth(s.handleCheckLinksAPI))
+    mux.HandleFunc("/admin/api/dry_run",           s.requireAuth(s.handleDryRunAPI))
+    mux.HandleFunc("/admin/api/authors",           s.requireAuth(s.handleListAuthorsAPI))
+    mux.HandleFunc("/admin/api/pages",             s.requireAuth(s.handleListPagesAPI))
+    mux.HandleFunc("/admin/api/pages/",            s.requireAuth(s.handlePageContentAPI))
 
     mux.HandleFunc("/admin/api/subject/",           s.requireAuth(s.handleRequestSubject))
+    mux.HandleFunc("/admin/api/history/",           s.requireAuth(s.handleHistoryAPI))
+    mux.HandleFunc("/admin/api/search",             s.requireAuth(s.handleSearchAPI))
 
     mux.Handle(
     	"/assets/",
     	http.StripPrefix(
     		"/assets/",
-    		http.FileServer(http.Dir("assets")),
+    		assetsHandler(s.AssetsDir),
     	),
     )
 
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"blog/internal/model"
+)
+
+// schedulerActor is who the scheduler's revisions and audit entries are
+// by.
+const schedulerActor = "scheduler"
+
+// SchedulerInterval is how often RunScheduler looks for due articles
+// and expired trash.
+const SchedulerInterval = 30 * time.Second
+
+// publishAtLayouts are accepted for publish_at: the admin form's
+// datetime-local value, its spaced variant for stx, and RFC 3339. The
+// first two are read in the server's local time zone.
+var publishAtLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+func parsePublishAt(v string) (time.Time, error) {
+	for _, layout := range publishAtLayouts {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid publish_at, expected YYYY-MM-DDTHH:MM")
+}
+
+// scheduleFromForm reads publish_at from an article form. A missing field
+// keeps fallback and an empty one clears the schedule. A future date keeps
+// the article private until then; a past one publishes it right away.
+func scheduleFromForm(r *http.Request, fallback *time.Time, isPublic bool) (*time.Time, bool, error) {
+	publishAt := fallback
+
+	if _, ok := r.Form["publish_at"]; ok {
+		publishAt = nil
+
+		if v := strings.TrimSpace(r.FormValue("publish_at")); v != "" {
+			t, err := parsePublishAt(v)
+			if err != nil {
+				return nil, false, err
+			}
+			publishAt = &t
+		}
+	}
+
+	if publishAt == nil {
+		return nil, isPublic, nil
+	}
+
+	if !publishAt.After(time.Now()) {
+		return nil, true, nil
+	}
+
+	return publishAt, false, nil
+}
+
+// RunScheduler publishes scheduled articles once their publish_at has
+// passed, and purges articles trashed longer than TrashRetention ago,
+// until ctx is done. The schedule lives in the database, so articles
+// that fell due while the admin was down are published on the first
+// pass.
+func (s *Server) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.publishDue(ctx, time.Now()); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+		if err := s.purgeTrash(ctx, time.Now()); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) publishDue(ctx context.Context, now time.Time) error {
+	repo := s.Repos.Articles
+
+	due, err := repo.ListDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range due {
+		if err := repo.Publish(ctx, a.ID, schedulerActor); err != nil {
+			return err
+		}
+		s.auditScheduler(ctx, now, fmt.Sprintf("article %d", a.ID), "public: false → true")
+
+		// its translations gain a link to it
+		if a.TranslationGroup != 0 {
+			err = s.rebuildTranslations(ctx, "", false)
+		} else {
+			err = s.rebuildSiteLocalize(ctx, a.Title, a.Lang, a.SubjectId, 0, true, false)
+		}
+		if err != nil {
+			return err
+		}
+
+		log.Printf("scheduler: published article %d %q", a.ID, a.Title)
+	}
+
+	return nil
+}
+
+// auditScheduler records a change the scheduler made in the audit log.
+// It has no request: no auth, route or client address.
+func (s *Server) auditScheduler(ctx context.Context, now time.Time, target, summary string) {
+	if s.Repos.Audit == nil {
+		return
+	}
+
+	entry := model.AuditEntry{
+		CreatedAt: now,
+		Actor:     schedulerActor,
+		Target:    target,
+		Summary:   summary,
+	}
+	if err := s.Repos.Audit.Record(context.WithoutCancel(ctx), entry); err != nil {
+		log.Printf("audit: %s by %s: %v", target, schedulerActor, err)
+	}
+}
+
+// purgeTrash purges the articles trashed more than TrashRetention before
+// now. Trashed articles are already gone from dist/, so nothing is rebuilt.
+func (s *Server) purgeTrash(ctx context.Context, now time.Time) error {
+	if s.TrashRetention <= 0 {
+		return nil
+	}
+
+	n, err := s.Repos.Articles.PurgeTrashedBefore(ctx, now.Add(-s.TrashRetention))
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("scheduler: purged %d trashed articles", n)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/internal/model"
+)
+
+// langFromForm returns the lang of an article form, or fallback when the
+// form has none. An empty fallback means model.DefaultLang.
+func langFromForm(r *http.Request, fallback string) (string, error) {
+	lang := strings.ToLower(strings.TrimSpace(r.FormValue("lang")))
+	if lang == "" {
+		lang = fallback
+	}
+	if lang == "" {
+		return model.DefaultLang, nil
+	}
+
+	if !model.ValidLang(lang) {
+		return "", fmt.Errorf("invalid language %q", lang)
+	}
+
+	return lang, nil
+}
+
+// translationGroupFromForm resolves the translation_of field of an article
+// form into a translation group. A missing field keeps a's group, an empty
+// one takes the article out of its group. Otherwise a joins the group of
+// the article it translates, which must not already have a's language.
+func (s *Server) translationGroupFromForm(r *http.Request, a model.Article) (int64, error) {
+	if _, ok := r.Form["translation_of"]; !ok {
+		return a.TranslationGroup, nil
+	}
+
+	v := strings.TrimSpace(r.FormValue("translation_of"))
+	if v == "" || v == "0" {
+		return 0, nil
+	}
+
+	otherID, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid translation_of id")
+	}
+
+	if otherID == a.ID {
+		return a.TranslationGroup, nil
+	}
+
+	repo := s.Repos.Articles
+
+	other, err := repo.GetByID(r.Context(), otherID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errors.New("unknown translation_of id")
+		}
+		return 0, err
+	}
+
+	group := other.TranslationGroup
+	if group == 0 {
+		group = other.ID
+	}
+
+	members, err := repo.ListByTranslationGroup(r.Context(), group)
+	if err != nil {
+		return 0, err
+	}
+	if other.TranslationGroup == 0 {
+		members = append(members, other)
+	}
+
+	for _, m := range members {
+		if m.ID != a.ID && m.Lang == a.Lang {
+			return 0, fmt.Errorf("%q is already the %s translation", m.Title, a.Lang)
+		}
+	}
+
+	return group, nil
+}
+
+// rootTranslationGroup makes the first article of a group a member of it,
+// so that the group id always names one of its articles.
+func (s *Server) rootTranslationGroup(ctx context.Context, group int64) error {
+	if group == 0 {
+		return nil
+	}
+
+	repo := s.Repos.Articles
+	return repo.SetTranslationGroup(ctx, group, group)
+}
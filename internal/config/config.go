@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"blog/internal/db"
 )
@@ -12,25 +13,64 @@ type Config struct {
 	DB        db.Config
 	AdminAddr string
     AdminPass string
+
+    // StrictLinks makes a full rebuild fail when the link checker
+    // reports broken links, missing images or links to private articles.
+    StrictLinks bool
+
+    // TemplateDir holds site-specific templates overriding the embedded
+    // defaults, e.g. TemplateDir/users/index.html.
+    TemplateDir string
+
+    // Dev reloads templates from internal/templates on every render
+    // instead of using the copies embedded in the binary.
+    Dev bool
+
+    // TrashRetentionDays is how long a deleted article stays in the
+    // trash before it is purged for good. 0 keeps it until purged by hand.
+    TrashRetentionDays int
 }
 
 func Load() Config {
 	cfg := Config{
 		DB: db.Config{
+			Driver:   getEnv("BLOG_DB_DRIVER", db.MySQL),
 			User:     getEnv("BLOG_DB_USER", "blog_user"),
 			Password: getEnv("BLOG_DB_PASSWORD", "password"),
 			Host:     getEnv("BLOG_DB_HOST", "127.0.0.1"),
 			Port:     getEnvInt("BLOG_DB_PORT", 3306),
 			DBName:   getEnv("BLOG_DB_NAME", "go_blog"),
+			Path:     getEnv("BLOG_DB_PATH", "statix.db"),
+
+			MaxOpenConns:    getEnvInt("BLOG_DB_MAX_OPEN_CONNS", 0),
+			MaxIdleConns:    getEnvInt("BLOG_DB_MAX_IDLE_CONNS", 0),
+			ConnMaxLifetime: getEnvDuration("BLOG_DB_CONN_MAX_LIFETIME", 0),
+			QueryTimeout:    getEnvDuration("BLOG_DB_QUERY_TIMEOUT", 30*time.Second),
 		},
 		AdminAddr: getEnv("BLOG_ADMIN_ADDR", ":8080"),
 		AdminPass: getEnv("BLOG_ADMIN_PASSWORD", "password"),
+		StrictLinks: getEnvBool("BLOG_STRICT_LINKS", false),
+		TemplateDir: getEnv("BLOG_TEMPLATE_DIR", ""),
+		Dev:         getEnvBool("BLOG_DEV", false),
+		TrashRetentionDays: getEnvInt("BLOG_TRASH_RETENTION_DAYS", 30),
+	}
+
+	if cfg.DB.Driver != db.MySQL && cfg.DB.Driver != db.SQLite {
+		log.Fatalf("invalid BLOG_DB_DRIVER %q: want %s or %s", cfg.DB.Driver, db.MySQL, db.SQLite)
 	}
 
-	if cfg.DB.Password == "" {
+	if cfg.DB.Driver == db.MySQL && cfg.DB.Password == "" {
 		log.Fatal("BLOG_DB_PASSWORD must be set")
 	}
 
+	if cfg.DB.MaxOpenConns < 0 || cfg.DB.MaxIdleConns < 0 || cfg.DB.ConnMaxLifetime < 0 || cfg.DB.QueryTimeout < 0 {
+		log.Fatal("BLOG_DB_MAX_OPEN_CONNS, BLOG_DB_MAX_IDLE_CONNS, BLOG_DB_CONN_MAX_LIFETIME and BLOG_DB_QUERY_TIMEOUT must not be negative")
+	}
+
+	if cfg.TrashRetentionDays < 0 {
+		log.Fatal("BLOG_TRASH_RETENTION_DAYS must not be negative")
+	}
+
 	if cfg.AdminPass == "" {
 		log.Fatal("BLOG_ADMIN_PASSWORD must be set")
 	}
@@ -58,4 +98,24 @@ func getEnvInt(key string, def int) int {
 	return def
 }
 
+func getEnvBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", key, err)
+		}
+		return b
+	}
+	return def
+}
 
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", key, err)
+		}
+		return d
+	}
+	return def
+}
@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
     "errors"
+    "time"
 
 	"blog/internal/model"
 	"blog/internal/utils"
@@ -10,12 +12,22 @@ import (
 
 type ArticleRepo struct {
 	DB *sql.DB
+
+	// Driver is MySQL or SQLite, for the queries their dialects disagree
+	// on. Empty means MySQL.
+	Driver string
+
+	Timeout time.Duration // per query, see bound
 }
 
-func (r *ArticleRepo) ListAll() ([]model.Article, error) {
-	rows, err := r.DB.Query(`
-		SELECT id, title, title_url, subject_id, is_public, html, created_at
+func (r *ArticleRepo) ListAll(ctx context.Context) ([]model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, created_at, updated_at, publish_at
 		FROM articles
+		WHERE deleted_at IS NULL
 		ORDER BY id DESC
 	`)
 	if err != nil {
@@ -32,9 +44,17 @@ func (r *ArticleRepo) ListAll() ([]model.Article, error) {
 			&a.Title,
             &a.TitleURL,
 			&a.SubjectId,
+			&a.AuthorId,
+			&a.Lang,
+			&a.TranslationGroup,
             &a.IsPublic,
+			&a.PinnedHome,
+			&a.PinnedSubject,
+			&a.Featured,
 			&a.HTML,
 			&a.CreatedAt,
+			&a.UpdatedAt,
+			&a.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -48,97 +68,267 @@ func (r *ArticleRepo) ListAll() ([]model.Article, error) {
 	return articles, nil
 }
 
-func (r *ArticleRepo) GetByID(id int64) (model.Article, error) {
+func (r *ArticleRepo) GetByID(ctx context.Context, id int64) (model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var a model.Article
 
-	err := r.DB.QueryRow(`
-		SELECT id, title, title_url, subject_id, is_public, html, created_at
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, created_at, updated_at, publish_at
 		FROM articles
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`, id).Scan(
 		&a.ID,
 		&a.Title,
         &a.TitleURL,
 		&a.SubjectId,
+		&a.AuthorId,
+		&a.Lang,
+		&a.TranslationGroup,
         &a.IsPublic,
+		&a.PinnedHome,
+		&a.PinnedSubject,
+		&a.Featured,
 		&a.HTML,
 		&a.CreatedAt,
+		&a.UpdatedAt,
+		&a.PublishAt,
 	)
 
 	return a, err
 }
 
-func (r *ArticleRepo) ImportByID(id int64) (string, int64, bool, error) {
-	var title string
-    var subject_id int64
-    var is_public bool
+func (r *ArticleRepo) GetHTMLByID(ctx context.Context, id int64) (string, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
-	err := r.DB.QueryRow(`
-		SELECT title, subject_id, is_public
+	var html string
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT html
 		FROM articles
+		WHERE id = ? AND deleted_at IS NULL
+	`, id).Scan(&html)
+
+	return html, err
+}
+
+// Update saves a and records the result as a revision by actor.
+func (r *ArticleRepo) Update(ctx context.Context, a model.Article, actor string) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE articles
+		SET title = ?, title_url = ?, subject_id = ?, author_id = ?, lang = ?, translation_group = ?,
+		    html = ?, text = ?, is_public = ?, pinned_home = ?, pinned_subject = ?, featured = ?, publish_at = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, id).Scan(
-		&title,
-        &subject_id,
-		&is_public,
-	)
+	`, a.Title, utils.Slugify(a.Title), a.SubjectId, a.AuthorId, a.Lang, a.TranslationGroup,
+		a.HTML, utils.PlainText(a.HTML), a.IsPublic, a.PinnedHome, a.PinnedSubject, a.Featured, utcOrNil(a.PublishAt), a.ID); err != nil {
+		return err
+	}
+
+	if err := recordRevision(ctx, tx, a.ID, actor); err != nil {
+		return err
+	}
 
-	return title, subject_id, is_public, err
+	return tx.Commit()
 }
 
-func (r *ArticleRepo) GetHTMLByID(id int64) (string, error) {
-	var html string
+// SetTranslationGroup moves an article into a translation group without
+// touching updated_at.
+func (r *ArticleRepo) SetTranslationGroup(ctx context.Context, id, group int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
-	err := r.DB.QueryRow(`
-		SELECT html
-		FROM articles
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE articles
+		SET translation_group = ?
 		WHERE id = ?
-	`, id).Scan(&html)
+	`, group, id)
+	return err
+}
 
-	return html, err
+// SetPinnedHome pins or unpins an article on the home page without
+// touching updated_at.
+func (r *ArticleRepo) SetPinnedHome(ctx context.Context, id int64, pinned bool) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE articles
+		SET pinned_home = ?
+		WHERE id = ?
+	`, pinned, id)
+	return err
 }
 
-func (r *ArticleRepo) Update(id int64, 
-                             title string, 
-                             subjectId int64, 
-                             is_public bool,
-                             html string) error {
-	_, err := r.DB.Exec(`
+// SetPinnedSubject pins or unpins an article on the pages of its subject
+// without touching updated_at.
+func (r *ArticleRepo) SetPinnedSubject(ctx context.Context, id int64, pinned bool) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE articles
-		SET title = ?, title_url = ?, subject_id = ?, html = ?, is_public = ?
+		SET pinned_subject = ?
 		WHERE id = ?
-	`, title, utils.Slugify(title), subjectId, html, is_public, id)
+	`, pinned, id)
 	return err
 }
 
-func (r *ArticleRepo) Delete(id int64) error {
-	_, err := r.DB.Exec(
-		`DELETE FROM articles WHERE id = ?`,
-		id,
-	)
+// SetFeatured marks or unmarks an article as featured without touching
+// updated_at.
+func (r *ArticleRepo) SetFeatured(ctx context.Context, id int64, featured bool) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE articles
+		SET featured = ?
+		WHERE id = ?
+	`, featured, id)
 	return err
 }
 
-func (r *ArticleRepo) Create(title string, 
-                             subjectId int64,
-                             is_public bool,
-                             html string) (int64, error) {
-	res, err := r.DB.Exec(`
-		INSERT INTO articles (title, title_url, subject_id, html, is_public, created_at)
-		VALUES (?, ?, ?, ?, ?, NOW())
-	`, title, utils.Slugify(title), subjectId, html, is_public)
+// ListByTranslationGroup returns the id, title and language of every article
+// in a translation group.
+func (r *ArticleRepo) ListByTranslationGroup(ctx context.Context, group int64) ([]model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, lang
+		FROM articles
+		WHERE translation_group = ? AND deleted_at IS NULL
+		ORDER BY id ASC
+	`, group)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []model.Article
+
+	for rows.Next() {
+		var a model.Article
+		if err := rows.Scan(&a.ID, &a.Title, &a.Lang); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, rows.Err()
+}
+
+// ListDue returns the scheduled articles whose publish_at is at or before
+// now, oldest first.
+func (r *ArticleRepo) ListDue(ctx context.Context, now time.Time) ([]model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, subject_id, lang, translation_group
+		FROM articles
+		WHERE publish_at IS NOT NULL AND publish_at <= ? AND deleted_at IS NULL
+		ORDER BY publish_at ASC
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []model.Article
+
+	for rows.Next() {
+		var a model.Article
+		if err := rows.Scan(&a.ID, &a.Title, &a.SubjectId, &a.Lang, &a.TranslationGroup); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, rows.Err()
+}
+
+// Publish makes a scheduled article public and clears its schedule,
+// recording the published state as a revision by actor.
+func (r *ArticleRepo) Publish(ctx context.Context, id int64, actor string) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE articles
+		SET is_public = TRUE, publish_at = NULL
+		WHERE id = ?
+	`, id); err != nil {
+		return err
+	}
+
+	if err := recordRevision(ctx, tx, id, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Create inserts a with its first revision, by actor.
+func (r *ArticleRepo) Create(ctx context.Context, a model.Article, actor string) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO articles (title, title_url, subject_id, author_id, lang, translation_group,
+		                      html, text, is_public, pinned_home, pinned_subject, featured, publish_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, a.Title, utils.Slugify(a.Title), a.SubjectId, a.AuthorId, a.Lang, a.TranslationGroup,
+		a.HTML, utils.PlainText(a.HTML), a.IsPublic, a.PinnedHome, a.PinnedSubject, a.Featured, utcOrNil(a.PublishAt))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
 
-	return res.LastInsertId()
+	if err := recordRevision(ctx, tx, id, actor); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func (r *ArticleRepo) GetDefaultSubjectID() (int64, error) {
+func (r *ArticleRepo) GetDefaultSubjectID(ctx context.Context) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var id int64
-    const DefaultSubjectSlug = "default"
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT id
 		FROM subjects
 		WHERE slug = ?
@@ -151,22 +341,48 @@ func (r *ArticleRepo) GetDefaultSubjectID() (int64, error) {
 	return id, nil
 }
 
-func (r *ArticleRepo) ReslugAll() error {
-	rows, err := r.DB.Query(`
+// ReslugAll recomputes the slug of every article. The titles are read
+// before the transaction opens, so it holds the only connection a pool
+// of one has.
+func (r *ArticleRepo) ReslugAll(ctx context.Context) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
 		SELECT id, title
 		FROM articles
 	`)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	tx, err := r.DB.Begin()
+	type titled struct {
+		id    int64
+		title string
+	}
+
+	var articles []titled
+
+	for rows.Next() {
+		var a titled
+		if err := rows.Scan(&a.id, &a.title); err != nil {
+			rows.Close()
+			return err
+		}
+		articles = append(articles, a)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 		UPDATE articles
 		SET title_url = ?
 		WHERE id = ?
@@ -177,36 +393,27 @@ func (r *ArticleRepo) ReslugAll() error {
 	}
 	defer stmt.Close()
 
-	for rows.Next() {
-		var id int64
-		var title string
-
-		if err := rows.Scan(&id, &title); err != nil {
+	for _, a := range articles {
+		if _, err := stmt.ExecContext(ctx, utils.Slugify(a.title), a.id); err != nil {
 			tx.Rollback()
 			return err
 		}
-
-		slug := utils.Slugify(title)
-
-		if _, err := stmt.Exec(slug, id); err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		tx.Rollback()
-		return err
 	}
 
 	return tx.Commit()
 }
 
 
-func (r *ArticleRepo) ExistsByTitle(title string, id int64) (bool, error) {
+// ExistsByTitle reports whether another article than id has the slug
+// title would get. Trashed articles count: they keep their slug until
+// purged.
+func (r *ArticleRepo) ExistsByTitle(ctx context.Context, title string, id int64) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var exists int
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT 1
 		FROM articles
 		WHERE title_url = ? AND id != ?
@@ -223,10 +430,13 @@ func (r *ArticleRepo) ExistsByTitle(title string, id int64) (bool, error) {
 	return true, nil
 }
 
-func (r *ArticleRepo) ExistsByTitleRaw(title string) (bool, error) {
+func (r *ArticleRepo) ExistsByTitleRaw(ctx context.Context, title string) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var exists int
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT 1
 		FROM articles
 		WHERE title_url = ?
@@ -243,34 +453,51 @@ func (r *ArticleRepo) ExistsByTitleRaw(title string) (bool, error) {
 	return true, nil
 }
 
-func (r *ArticleRepo) GetByTitleURL(title_url string) (model.Article, error) {
+func (r *ArticleRepo) GetByTitleURL(ctx context.Context, title_url string) (model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var a model.Article
 
-	err := r.DB.QueryRow(`
-		SELECT id, title, title_url, subject_id, is_public, html, created_at
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, created_at, updated_at, publish_at
 		FROM articles
-		WHERE title_url = ?
+		WHERE title_url = ? AND deleted_at IS NULL
 	`, title_url).Scan(
 		&a.ID,
 		&a.Title,
         &a.TitleURL,
 		&a.SubjectId,
+		&a.AuthorId,
+		&a.Lang,
+		&a.TranslationGroup,
         &a.IsPublic,
+		&a.PinnedHome,
+		&a.PinnedSubject,
+		&a.Featured,
 		&a.HTML,
 		&a.CreatedAt,
+		&a.UpdatedAt,
+		&a.PublishAt,
 	)
 
 	return a, err
 }
 
-func (r *ArticleRepo) ListIDAndTitle() ([]struct {
+// ArticleTitle is an article as listed by ListIDAndTitle.
+type ArticleTitle struct {
 	ID    int64
 	Title string
-}, error) {
+}
 
-	rows, err := r.DB.Query(`
+func (r *ArticleRepo) ListIDAndTitle(ctx context.Context) ([]ArticleTitle, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
 		SELECT id, title
 		FROM articles
+		WHERE deleted_at IS NULL
 		ORDER BY id DESC
 	`)
 	if err != nil {
@@ -278,10 +505,7 @@ func (r *ArticleRepo) ListIDAndTitle() ([]struct {
 	}
 	defer rows.Close()
 
-	var result []struct {
-		ID    int64
-		Title string
-	}
+	var result []ArticleTitle
 
 	for rows.Next() {
 		var id int64
@@ -291,10 +515,7 @@ func (r *ArticleRepo) ListIDAndTitle() ([]struct {
 			return nil, err
 		}
 
-		result = append(result, struct {
-			ID    int64
-			Title string
-		}{
+		result = append(result, ArticleTitle{
 			ID:    id,
 			Title: title,
 		})
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"blog/internal/model"
+)
+
+// AuditFilter selects audit entries; zero fields match every entry.
+type AuditFilter struct {
+	Actor string
+	Auth  string
+
+	// Route matches as a prefix, e.g. "/admin/articles/". Target matches
+	// as a whole or by kind: "article" selects every article, "article 12"
+	// one of them.
+	Route  string
+	Target string
+
+	// Since and Until bound CreatedAt, Until excluded.
+	Since time.Time
+	Until time.Time
+
+	// Limit caps the number of entries; zero means no cap.
+	Limit int
+}
+
+// where returns the SQL condition and arguments selecting f.
+func (f AuditFilter) where() (string, []any) {
+	conds := []string{"1 = 1"}
+	var args []any
+
+	if f.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Auth != "" {
+		conds = append(conds, "auth = ?")
+		args = append(args, f.Auth)
+	}
+	if f.Route != "" {
+		conds = append(conds, "route LIKE ? ESCAPE '!'")
+		args = append(args, likeEscape(f.Route)+"%")
+	}
+	if f.Target != "" {
+		conds = append(conds, "(target = ? OR target LIKE ? ESCAPE '!')")
+		args = append(args, f.Target, likeEscape(f.Target)+" %")
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.Until.UTC())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// Match reports whether e is selected by f, ignoring Limit.
+func (f AuditFilter) Match(e model.AuditEntry) bool {
+	return (f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Auth == "" || e.Auth == f.Auth) &&
+		strings.HasPrefix(e.Route, f.Route) &&
+		(f.Target == "" || e.Target == f.Target || strings.HasPrefix(e.Target, f.Target+" ")) &&
+		(f.Since.IsZero() || !e.CreatedAt.Before(f.Since)) &&
+		(f.Until.IsZero() || e.CreatedAt.Before(f.Until))
+}
+
+// likeEscape escapes the LIKE wildcards of s, with ! as the escape.
+func likeEscape(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+type AuditRepo struct {
+	DB      *sql.DB
+	Timeout time.Duration // per query, see bound
+}
+
+func (r *AuditRepo) Record(ctx context.Context, e model.AuditEntry) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO audit_log (created_at, actor, auth, method, route, status, target, summary, ip)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.CreatedAt.UTC(), e.Actor, e.Auth, e.Method, e.Route, e.Status, e.Target, e.Summary, e.IP)
+	return err
+}
+
+// List returns the entries selected by f, latest first.
+func (r *AuditRepo) List(ctx context.Context, f AuditFilter) ([]model.AuditEntry, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	where, args := f.where()
+
+	query := `
+		SELECT id, created_at, actor, auth, method, route, status, target, summary, ip
+		FROM audit_log
+		WHERE ` + where + `
+		ORDER BY id DESC`
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.CreatedAt,
+			&e.Actor,
+			&e.Auth,
+			&e.Method,
+			&e.Route,
+			&e.Status,
+			&e.Target,
+			&e.Summary,
+			&e.IP,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
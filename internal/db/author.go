@@ -1,42 +1,187 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"blog/internal/model"
+	"blog/internal/utils"
 )
 
 type AuthorRepo struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration // per query, see bound
+}
+
+type authorScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAuthor(row authorScanner) (model.Author, error) {
+	var a model.Author
+	var links string
+
+	if err := row.Scan(&a.ID, &a.Name, &a.Slug, &a.Bio, &a.Avatar, &links); err != nil {
+		return a, err
+	}
+
+	a.Links = model.ParseAuthorLinks(links)
+
+	return a, nil
+}
+
+func (r *AuthorRepo) ListAll(ctx context.Context) ([]model.Author, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, name, slug, bio, avatar, links
+		FROM authors
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var authors []model.Author
+
+	for rows.Next() {
+		a, err := scanAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return authors, nil
+}
+
+func (r *AuthorRepo) GetByID(ctx context.Context, id int64) (model.Author, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	return scanAuthor(r.DB.QueryRowContext(ctx, `
+		SELECT id, name, slug, bio, avatar, links
+		FROM authors
+		WHERE id = ?
+	`, id))
 }
 
-func (r *AuthorRepo) Update(content string) error {
+func (r *AuthorRepo) GetBySlug(ctx context.Context, slug string) (model.Author, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	return scanAuthor(r.DB.QueryRowContext(ctx, `
+		SELECT id, name, slug, bio, avatar, links
+		FROM authors
+		WHERE slug = ?
+	`, slug))
+}
+
+// GetDefaultID returns the author given to articles created without one:
+// the first author, seeded by the initial migration. It is found by id
+// rather than slug so that it can be renamed.
+func (r *AuthorRepo) GetDefaultID(ctx context.Context) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	var id int64
 
-	_, err := r.DB.Exec(`
-		UPDATE author
-		SET html = ?
-        WHERE id = 0
-	`, content)
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id
+		FROM authors
+		ORDER BY id ASC
+		LIMIT 1
+	`).Scan(&id)
 
-    return err
+	return id, err
 }
 
-func (r *AuthorRepo) GetContent() (string, error) {
+// ExistsByName reports whether another author than id has the slug name
+// would get.
+func (r *AuthorRepo) ExistsByName(ctx context.Context, name string, id int64) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
-    var content string
+	var found int64
 
-	err := r.DB.QueryRow(`
-		SELECT html 
-        FROM author
-        WHERE id = 0
-	`).Scan(&content)
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id
+		FROM authors
+		WHERE slug = ? AND id <> ?
+		LIMIT 1
+	`, utils.Slugify(name), id).Scan(&found)
 
-    if err != nil {
-        return "", err
-    }
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
 
-    return content, nil
+	return true, nil
 }
 
+func (r *AuthorRepo) Create(ctx context.Context, a model.Author) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO authors (name, slug, bio, avatar, links)
+		VALUES (?, ?, ?, ?, ?)
+	`, a.Name, utils.Slugify(a.Name), a.Bio, a.Avatar, model.FormatAuthorLinks(a.Links))
 
+	if err != nil {
+		return 0, err
+	}
 
+	return res.LastInsertId()
+}
+
+func (r *AuthorRepo) Update(ctx context.Context, a model.Author) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE authors
+		SET name = ?, slug = ?, bio = ?, avatar = ?, links = ?
+		WHERE id = ?
+	`, a.Name, utils.Slugify(a.Name), a.Bio, a.Avatar, model.FormatAuthorLinks(a.Links), a.ID)
+
+	return err
+}
+
+func (r *AuthorRepo) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx,
+		`DELETE FROM authors WHERE id = ?`,
+		id,
+	)
+	return err
+}
+
+// CountArticles returns how many articles have the author, which then
+// cannot be deleted.
+func (r *AuthorRepo) CountArticles(ctx context.Context, id int64) (int, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	var n int
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM articles
+		WHERE author_id = ?
+	`, id).Scan(&n)
+
+	return n, err
+}
@@ -1,38 +1,117 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// Drivers accepted in Config.Driver.
+const (
+	MySQL  = "mysql"
+	SQLite = "sqlite"
 )
 
 type Config struct {
+	// Driver is MySQL or SQLite. Empty means MySQL.
+	Driver string
+
 	User     string
 	Password string
 	Host     string
 	Port     int
 	DBName   string
+
+	// Path is the SQLite database file.
+	Path string
+
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime size the connection
+	// pool. Zero keeps the database/sql default: no limit, 2 idle
+	// connections, no expiry.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// QueryTimeout bounds every repository query, on top of the caller's
+	// context. Zero leaves them to the context alone.
+	QueryTimeout time.Duration
 }
 
 func Open(cfg Config) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?parseTime=true&charset=utf8mb4",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
+	var (
+		db  *sql.DB
+		err error
 	)
 
-	db, err := sql.Open("mysql", dsn)
+	switch cfg.Driver {
+	case MySQL, "":
+		dsn := fmt.Sprintf(
+			"%s:%s@tcp(%s:%d)/%s?parseTime=true&charset=utf8mb4",
+			cfg.User,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.DBName,
+		)
+		db, err = sql.Open("mysql", dsn)
+
+	case SQLite:
+		db, err = sql.Open("sqlite", sqliteDSN(cfg.Path))
+
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// bound derives the context of one repository query from the caller's:
+// canceled with it, and after timeout if set.
+func bound(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// sqliteDSN turns on, for every connection of the pool, the foreign keys
+// the RESTRICT constraints rely on, and waits for the write lock instead
+// of failing while a build and a request write at once. Times are stored
+// in SQLite's own format rather than time.String, which carries a
+// monotonic clock reading; the repos pass them in UTC, so dates compare
+// as text.
+func sqliteDSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_time_format", "sqlite")
+
+	return "file:" + path + "?" + q.Encode()
+}
+
+// utcOrNil passes an optional time to a query in UTC, or NULL.
+func utcOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
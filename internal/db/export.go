@@ -0,0 +1,699 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"blog/internal/utils"
+)
+
+// An export is NDJSON, one {"type": ..., "data": ...} record per line.
+// The first record is the header, naming the format and its version; the
+// last is the trailer, counting the records of each type and holding the
+// SHA-256 of every byte before it. Between them come the settings, then
+// subjects, authors, pages, articles (trashed ones included) and
+// revisions, each with its id, so that references survive a restore
+// into MySQL or SQLite alike.
+//
+// Version 2 split the pinned flag of articles into pinned_home and
+// pinned_subject; version 1 exports are still read, their pinned
+// articles pinned on both.
+const (
+	ExportFormat  = "statix-export"
+	ExportVersion = 2
+)
+
+// Record types of an export.
+const (
+	typeHeader   = "header"
+	typeSetting  = "setting"
+	typeSubject  = "subject"
+	typeAuthor   = "author"
+	typePage     = "page"
+	typeArticle  = "article"
+	typeRevision = "revision"
+	typeTrailer  = "trailer"
+)
+
+type ExportHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportTrailer struct {
+	Counts map[string]int `json:"counts"`
+	SHA256 string         `json:"sha256"`
+}
+
+type ExportSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type ExportSubject struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	ParentID    int64  `json:"parent_id,omitempty"`
+	Description string `json:"description"`
+	Cover       string `json:"cover"`
+	Position    int    `json:"position"`
+}
+
+type ExportAuthor struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Slug   string `json:"slug"`
+	Bio    string `json:"bio"`
+	Avatar string `json:"avatar"`
+	Links  string `json:"links"`
+}
+
+type ExportPage struct {
+	ID        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	HTML      string    `json:"html"`
+	NavOrder  int       `json:"nav_order"`
+	IsPublic  bool      `json:"is_public"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExportArticle struct {
+	ID               int64      `json:"id"`
+	Title            string     `json:"title"`
+	TitleURL         string     `json:"title_url"`
+	SubjectID        int64      `json:"subject_id"`
+	AuthorID         int64      `json:"author_id"`
+	Lang             string     `json:"lang"`
+	TranslationGroup int64      `json:"translation_group"`
+	IsPublic         bool       `json:"is_public"`
+	PinnedHome       bool       `json:"pinned_home"`
+	PinnedSubject    bool       `json:"pinned_subject"`
+	Featured         bool       `json:"featured"`
+	HTML             string     `json:"html"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	PublishAt        *time.Time `json:"publish_at,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+// exportArticleV1 is an article of a version 1 export.
+type exportArticleV1 struct {
+	ID               int64      `json:"id"`
+	Title            string     `json:"title"`
+	TitleURL         string     `json:"title_url"`
+	SubjectID        int64      `json:"subject_id"`
+	AuthorID         int64      `json:"author_id"`
+	Lang             string     `json:"lang"`
+	TranslationGroup int64      `json:"translation_group"`
+	IsPublic         bool       `json:"is_public"`
+	Pinned           bool       `json:"pinned"`
+	Featured         bool       `json:"featured"`
+	HTML             string     `json:"html"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	PublishAt        *time.Time `json:"publish_at,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+func (a exportArticleV1) upgrade() ExportArticle {
+	return ExportArticle{
+		ID:               a.ID,
+		Title:            a.Title,
+		TitleURL:         a.TitleURL,
+		SubjectID:        a.SubjectID,
+		AuthorID:         a.AuthorID,
+		Lang:             a.Lang,
+		TranslationGroup: a.TranslationGroup,
+		IsPublic:         a.IsPublic,
+		PinnedHome:       a.Pinned,
+		PinnedSubject:    a.Pinned,
+		Featured:         a.Featured,
+		HTML:             a.HTML,
+		CreatedAt:        a.CreatedAt,
+		UpdatedAt:        a.UpdatedAt,
+		PublishAt:        a.PublishAt,
+		DeletedAt:        a.DeletedAt,
+	}
+}
+
+type ExportRevision struct {
+	ID        int64     `json:"id"`
+	ArticleID int64     `json:"article_id"`
+	Title     string    `json:"title"`
+	SubjectID int64     `json:"subject_id"`
+	IsPublic  bool      `json:"is_public"`
+	HTML      string    `json:"html"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Export is the content of an export file.
+type Export struct {
+	Header    ExportHeader
+	Settings  map[string]string
+	Subjects  []ExportSubject
+	Authors   []ExportAuthor
+	Pages     []ExportPage
+	Articles  []ExportArticle
+	Revisions []ExportRevision
+}
+
+// Counts returns how many records of each type e holds, as its trailer
+// states them.
+func (e *Export) Counts() map[string]int {
+	return map[string]int{
+		typeSetting:  len(e.Settings),
+		typeSubject:  len(e.Subjects),
+		typeAuthor:   len(e.Authors),
+		typePage:     len(e.Pages),
+		typeArticle:  len(e.Articles),
+		typeRevision: len(e.Revisions),
+	}
+}
+
+// LoadExport reads the whole database in one transaction, so the export
+// is consistent even while the admin writes.
+func LoadExport(ctx context.Context, conn *sql.DB, settings map[string]string, now time.Time) (*Export, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	e := &Export{
+		Header:   ExportHeader{Format: ExportFormat, Version: ExportVersion, CreatedAt: now.UTC()},
+		Settings: settings,
+	}
+
+	err = queryRows(ctx, tx, `
+		SELECT id, title, slug, parent_id, description, cover, position
+		FROM subjects
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var (
+			s      ExportSubject
+			parent sql.NullInt64
+		)
+		if err := rows.Scan(&s.ID, &s.Title, &s.Slug, &parent, &s.Description, &s.Cover, &s.Position); err != nil {
+			return err
+		}
+		s.ParentID = parent.Int64
+		e.Subjects = append(e.Subjects, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(ctx, tx, `
+		SELECT id, name, slug, bio, avatar, links
+		FROM authors
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var a ExportAuthor
+		if err := rows.Scan(&a.ID, &a.Name, &a.Slug, &a.Bio, &a.Avatar, &a.Links); err != nil {
+			return err
+		}
+		e.Authors = append(e.Authors, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(ctx, tx, `
+		SELECT id, slug, title, html, nav_order, is_public, updated_at
+		FROM pages
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var p ExportPage
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title, &p.HTML, &p.NavOrder, &p.IsPublic, &p.UpdatedAt); err != nil {
+			return err
+		}
+		e.Pages = append(e.Pages, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(ctx, tx, `
+		SELECT id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, created_at, updated_at, publish_at, deleted_at
+		FROM articles
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var a ExportArticle
+		if err := rows.Scan(
+			&a.ID,
+			&a.Title,
+			&a.TitleURL,
+			&a.SubjectID,
+			&a.AuthorID,
+			&a.Lang,
+			&a.TranslationGroup,
+			&a.IsPublic,
+			&a.PinnedHome,
+			&a.PinnedSubject,
+			&a.Featured,
+			&a.HTML,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+			&a.PublishAt,
+			&a.DeletedAt,
+		); err != nil {
+			return err
+		}
+		e.Articles = append(e.Articles, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryRows(ctx, tx, `
+		SELECT id, article_id, title, subject_id, is_public, html, actor, created_at
+		FROM article_revisions
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var r ExportRevision
+		if err := rows.Scan(&r.ID, &r.ArticleID, &r.Title, &r.SubjectID, &r.IsPublic, &r.HTML, &r.Actor, &r.CreatedAt); err != nil {
+			return err
+		}
+		e.Revisions = append(e.Revisions, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func queryRows(ctx context.Context, tx *sql.Tx, query string, scan func(*sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// WriteTo writes e as an export file.
+func (e *Export) WriteTo(w io.Writer) (int64, error) {
+	h := sha256.New()
+	ew := &exportWriter{w: io.MultiWriter(w, h)}
+
+	ew.record(typeHeader, e.Header)
+
+	keys := make([]string, 0, len(e.Settings))
+	for k := range e.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		ew.record(typeSetting, ExportSetting{Key: k, Value: e.Settings[k]})
+	}
+	for _, s := range e.Subjects {
+		ew.record(typeSubject, s)
+	}
+	for _, a := range e.Authors {
+		ew.record(typeAuthor, a)
+	}
+	for _, p := range e.Pages {
+		ew.record(typePage, p)
+	}
+	for _, a := range e.Articles {
+		ew.record(typeArticle, a)
+	}
+	for _, r := range e.Revisions {
+		ew.record(typeRevision, r)
+	}
+
+	// the trailer is not part of its own checksum
+	ew.w = w
+	ew.record(typeTrailer, ExportTrailer{
+		Counts: e.Counts(),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	})
+
+	return ew.n, ew.err
+}
+
+type exportWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (ew *exportWriter) record(typ string, data any) {
+	if ew.err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+
+	// HTML goes through as is, Encode ends the line
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if ew.err = enc.Encode(struct {
+		Type string `json:"type"`
+		Data any    `json:"data"`
+	}{typ, data}); ew.err != nil {
+		return
+	}
+
+	var n int
+	n, ew.err = ew.w.Write(buf.Bytes())
+	ew.n += int64(n)
+}
+
+// ReadExport reads and checks an export file: its format and version,
+// its checksum and counts, and that every id is unique and every
+// reference points at a record of the file. It loads nothing.
+func ReadExport(r io.Reader) (*Export, error) {
+	br := bufio.NewReader(r)
+	h := sha256.New()
+
+	e := &Export{Settings: make(map[string]string)}
+
+	var (
+		trailer *ExportTrailer
+		line    int
+	)
+
+	for {
+		raw, err := br.ReadBytes('\n')
+		if len(raw) == 0 && errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		line++
+
+		if trailer != nil {
+			return nil, fmt.Errorf("line %d: records after the trailer", line)
+		}
+
+		var rec struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if line == 1 && rec.Type != typeHeader {
+			return nil, errors.New("not a Statix export: no header")
+		}
+
+		if rec.Type == typeTrailer {
+			trailer = &ExportTrailer{}
+			if err := decodeRecord(rec.Data, trailer); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+
+		h.Write(raw)
+
+		if err := e.add(rec.Type, rec.Data, line); err != nil {
+			return nil, err
+		}
+	}
+
+	if line == 0 {
+		return nil, errors.New("empty export")
+	}
+
+	if trailer == nil {
+		return nil, errors.New("export is truncated: no trailer")
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != trailer.SHA256 {
+		return nil, fmt.Errorf("export checksum mismatch: got %s, trailer says %s", sum, trailer.SHA256)
+	}
+
+	for typ, n := range e.Counts() {
+		if trailer.Counts[typ] != n {
+			return nil, fmt.Errorf("export has %d %s records, trailer says %d", n, typ, trailer.Counts[typ])
+		}
+	}
+
+	if err := e.check(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func decodeRecord(data json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// add decodes the record on line of type typ into e.
+func (e *Export) add(typ string, data json.RawMessage, line int) error {
+	var err error
+
+	switch typ {
+	case typeHeader:
+		if line != 1 {
+			return fmt.Errorf("line %d: header after the first line", line)
+		}
+		if err := json.Unmarshal(data, &e.Header); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.Header.Format != ExportFormat {
+			return fmt.Errorf("not a Statix export: format %q", e.Header.Format)
+		}
+		if e.Header.Version < 1 || e.Header.Version > ExportVersion {
+			return fmt.Errorf("export version %d is not supported, want 1 to %d", e.Header.Version, ExportVersion)
+		}
+
+	case typeSetting:
+		var s ExportSetting
+		if err = decodeRecord(data, &s); err == nil {
+			if _, dup := e.Settings[s.Key]; dup {
+				return fmt.Errorf("line %d: setting %q twice", line, s.Key)
+			}
+			e.Settings[s.Key] = s.Value
+		}
+
+	case typeSubject:
+		var s ExportSubject
+		if err = decodeRecord(data, &s); err == nil {
+			e.Subjects = append(e.Subjects, s)
+		}
+
+	case typeAuthor:
+		var a ExportAuthor
+		if err = decodeRecord(data, &a); err == nil {
+			e.Authors = append(e.Authors, a)
+		}
+
+	case typePage:
+		var p ExportPage
+		if err = decodeRecord(data, &p); err == nil {
+			e.Pages = append(e.Pages, p)
+		}
+
+	case typeArticle:
+		if e.Header.Version == 1 {
+			var a exportArticleV1
+			if err = decodeRecord(data, &a); err == nil {
+				e.Articles = append(e.Articles, a.upgrade())
+			}
+			break
+		}
+
+		var a ExportArticle
+		if err = decodeRecord(data, &a); err == nil {
+			e.Articles = append(e.Articles, a)
+		}
+
+	case typeRevision:
+		var r ExportRevision
+		if err = decodeRecord(data, &r); err == nil {
+			e.Revisions = append(e.Revisions, r)
+		}
+
+	default:
+		return fmt.Errorf("line %d: unknown record type %q", line, typ)
+	}
+
+	if err != nil {
+		return fmt.Errorf("line %d: %s: %w", line, typ, err)
+	}
+	return nil
+}
+
+// check verifies ids and references, which the database would otherwise
+// reject halfway through a restore.
+func (e *Export) check() error {
+	subjects := make(map[int64]bool)
+	for _, s := range e.Subjects {
+		if s.ID <= 0 || subjects[s.ID] {
+			return fmt.Errorf("subject %q: invalid or repeated id %d", s.Slug, s.ID)
+		}
+		subjects[s.ID] = true
+	}
+	for _, s := range e.Subjects {
+		if s.ParentID != 0 && !subjects[s.ParentID] {
+			return fmt.Errorf("subject %q: unknown parent %d", s.Slug, s.ParentID)
+		}
+	}
+
+	authors := make(map[int64]bool)
+	for _, a := range e.Authors {
+		if a.ID <= 0 || authors[a.ID] {
+			return fmt.Errorf("author %q: invalid or repeated id %d", a.Slug, a.ID)
+		}
+		authors[a.ID] = true
+	}
+
+	pages := make(map[int64]bool)
+	for _, p := range e.Pages {
+		if p.ID <= 0 || pages[p.ID] {
+			return fmt.Errorf("page %q: invalid or repeated id %d", p.Slug, p.ID)
+		}
+		pages[p.ID] = true
+	}
+
+	articles := make(map[int64]bool)
+	for _, a := range e.Articles {
+		if a.ID <= 0 || articles[a.ID] {
+			return fmt.Errorf("article %q: invalid or repeated id %d", a.Title, a.ID)
+		}
+		if !subjects[a.SubjectID] {
+			return fmt.Errorf("article %q: unknown subject %d", a.Title, a.SubjectID)
+		}
+		if !authors[a.AuthorID] {
+			return fmt.Errorf("article %q: unknown author %d", a.Title, a.AuthorID)
+		}
+		articles[a.ID] = true
+	}
+
+	revisions := make(map[int64]bool)
+	for _, r := range e.Revisions {
+		if r.ID <= 0 || revisions[r.ID] {
+			return fmt.Errorf("revision %d: invalid or repeated id", r.ID)
+		}
+		if !articles[r.ArticleID] {
+			return fmt.Errorf("revision %d: unknown article %d", r.ID, r.ArticleID)
+		}
+		revisions[r.ID] = true
+	}
+
+	return nil
+}
+
+// RestoreExport replaces every subject, author, page, article and
+// revision with those of e, in one transaction: on error the database is
+// left as it was. Settings are the caller's to apply.
+func RestoreExport(ctx context.Context, conn *sql.DB, e *Export) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`DELETE FROM article_revisions`,
+		`DELETE FROM articles`,
+		`DELETE FROM pages`,
+		`UPDATE subjects SET parent_id = NULL`,
+		`DELETE FROM subjects`,
+		`DELETE FROM authors`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	// parents are set once every subject exists, whatever the order
+	for _, s := range e.Subjects {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO subjects (id, title, slug, parent_id, description, cover, position)
+			VALUES (?, ?, ?, NULL, ?, ?, ?)
+		`, s.ID, s.Title, s.Slug, s.Description, s.Cover, s.Position); err != nil {
+			return fmt.Errorf("subject %q: %w", s.Slug, err)
+		}
+	}
+	for _, s := range e.Subjects {
+		if s.ParentID == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE subjects SET parent_id = ? WHERE id = ?`,
+			s.ParentID, s.ID,
+		); err != nil {
+			return fmt.Errorf("subject %q: %w", s.Slug, err)
+		}
+	}
+
+	for _, a := range e.Authors {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO authors (id, name, slug, bio, avatar, links)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, a.ID, a.Name, a.Slug, a.Bio, a.Avatar, a.Links); err != nil {
+			return fmt.Errorf("author %q: %w", a.Slug, err)
+		}
+	}
+
+	for _, p := range e.Pages {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pages (id, slug, title, html, nav_order, is_public, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, p.ID, p.Slug, p.Title, p.HTML, p.NavOrder, p.IsPublic, p.UpdatedAt.UTC()); err != nil {
+			return fmt.Errorf("page %q: %w", p.Slug, err)
+		}
+	}
+
+	for _, a := range e.Articles {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO articles (id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, text, created_at, updated_at, publish_at, deleted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			a.ID, a.Title, a.TitleURL, a.SubjectID, a.AuthorID, a.Lang, a.TranslationGroup,
+			a.IsPublic, a.PinnedHome, a.PinnedSubject, a.Featured, a.HTML, utils.PlainText(a.HTML),
+			a.CreatedAt.UTC(), a.UpdatedAt.UTC(), utcOrNil(a.PublishAt), utcOrNil(a.DeletedAt),
+		); err != nil {
+			return fmt.Errorf("article %q: %w", a.Title, err)
+		}
+	}
+
+	for _, r := range e.Revisions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO article_revisions (id, article_id, title, subject_id, is_public, html, actor, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.ID, r.ArticleID, r.Title, r.SubjectID, r.IsPublic, r.HTML, r.Actor, r.CreatedAt.UTC()); err != nil {
+			return fmt.Errorf("revision %d: %w", r.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	src := openSQLite(t)
+	repos := NewRepos(src.DB, Config{Driver: SQLite})
+
+	parentID, err := repos.Subjects.Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childID, err := repos.Subjects.Create(ctx, "Generics", parentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorID, err := repos.Authors.GetDefaultID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := repos.Articles.Create(ctx, model.Article{
+		Title:     "Type Sets",
+		SubjectId: childID,
+		AuthorId:  authorID,
+		Lang:      model.DefaultLang,
+		HTML:      "<p>a & b</p>\n<p>line two</p>",
+	}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Articles.Create(ctx, model.Article{Title: "Old", SubjectId: parentID, AuthorId: authorID, Lang: model.DefaultLang}, "stx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Articles.Trash(ctx, id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := LoadExport(ctx, src.DB, map[string]string{"theme": "dark"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := exported.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadExport(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("reading the export back: %v\n%s", err, buf.String())
+	}
+
+	if len(read.Articles) != 2 || read.Articles[0].DeletedAt == nil || len(read.Revisions) != 2 || read.Settings["theme"] != "dark" {
+		t.Errorf("read back: %+v", read)
+	}
+
+	// the target has its own seeded rows, which the restore replaces
+	dst := openSQLite(t)
+	if _, err := NewRepos(dst.DB, Config{Driver: SQLite}).Subjects.Create(ctx, "Elsewhere", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RestoreExport(ctx, dst.DB, read); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadExport(ctx, dst.DB, read.Settings, exported.Header.CreatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []*Export{exported, restored} {
+		for i := range e.Pages {
+			e.Pages[i].UpdatedAt = e.Pages[i].UpdatedAt.UTC()
+		}
+		for i := range e.Articles {
+			a := &e.Articles[i]
+			a.CreatedAt, a.UpdatedAt, a.DeletedAt = a.CreatedAt.UTC(), a.UpdatedAt.UTC(), utcPtr(a.DeletedAt)
+		}
+		for i := range e.Revisions {
+			e.Revisions[i].CreatedAt = e.Revisions[i].CreatedAt.UTC()
+		}
+	}
+	if !reflect.DeepEqual(restored.Subjects, exported.Subjects) || !reflect.DeepEqual(restored.Authors, exported.Authors) ||
+		!reflect.DeepEqual(restored.Pages, exported.Pages) || !reflect.DeepEqual(restored.Articles, exported.Articles) ||
+		!reflect.DeepEqual(restored.Revisions, exported.Revisions) {
+		t.Errorf("restored:\n%+v\nexported:\n%+v", restored, exported)
+	}
+
+	// the full-text index follows the restored articles
+	if found, err := NewRepos(dst.DB, Config{Driver: SQLite}).Articles.Search(ctx, "old", 10); err != nil || len(found) != 1 {
+		t.Errorf("search after restore: %+v, %v", found, err)
+	}
+}
+
+// TestExportV1 restores a version 1 export, whose articles have a single
+// pinned flag, and exports it again as the current version.
+func TestExportV1(t *testing.T) {
+	ctx := context.Background()
+
+	records := []string{
+		`{"type":"header","data":{"format":"statix-export","version":1,"created_at":"2026-01-02T03:04:05Z"}}`,
+		`{"type":"subject","data":{"id":1,"title":"Default","slug":"default","description":"","cover":"","position":0}}`,
+		`{"type":"author","data":{"id":1,"name":"Default","slug":"default","bio":"","avatar":"","links":""}}`,
+		`{"type":"article","data":{"id":1,"title":"Pinned","title_url":"pinned","subject_id":1,"author_id":1,"lang":"en","translation_group":0,"is_public":true,"pinned":true,"featured":false,"html":"<p>pinned</p>","created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z"}}`,
+		`{"type":"article","data":{"id":2,"title":"Plain","title_url":"plain","subject_id":1,"author_id":1,"lang":"en","translation_group":0,"is_public":true,"pinned":false,"featured":true,"html":"<p>plain</p>","created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z"}}`,
+	}
+
+	var body strings.Builder
+	for _, r := range records {
+		body.WriteString(r + "\n")
+	}
+	sum := sha256.Sum256([]byte(body.String()))
+	fmt.Fprintf(&body, `{"type":"trailer","data":{"counts":{"article":2,"author":1,"subject":1},"sha256":%q}}`+"\n", hex.EncodeToString(sum[:]))
+
+	read, err := ReadExport(strings.NewReader(body.String()))
+	if err != nil {
+		t.Fatalf("version 1 export rejected: %v", err)
+	}
+
+	dst := openSQLite(t)
+	if err := RestoreExport(ctx, dst.DB, read); err != nil {
+		t.Fatal(err)
+	}
+
+	articles := NewRepos(dst.DB, Config{Driver: SQLite}).Articles
+	for id, want := range map[int64]bool{1: true, 2: false} {
+		a, err := articles.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.PinnedHome != want || a.PinnedSubject != want || a.Featured == want {
+			t.Errorf("article %d restored as %+v", id, a)
+		}
+	}
+
+	exported, err := LoadExport(ctx, dst.DB, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := exported.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadExport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Header.Version != ExportVersion || !again.Articles[0].PinnedHome || !again.Articles[0].PinnedSubject {
+		t.Errorf("exported again as %+v", again)
+	}
+}
+
+func TestReadExportRejects(t *testing.T) {
+	e := &Export{
+		Header:   ExportHeader{Format: ExportFormat, Version: ExportVersion, CreatedAt: time.Now()},
+		Subjects: []ExportSubject{{ID: 1, Title: "Default", Slug: "default"}},
+		Authors:  []ExportAuthor{{ID: 1, Name: "Default", Slug: "default"}},
+		Articles: []ExportArticle{{ID: 1, Title: "A", TitleURL: "a", SubjectID: 1, AuthorID: 1}},
+	}
+
+	var buf bytes.Buffer
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	good := buf.String()
+
+	if _, err := ReadExport(strings.NewReader(good)); err != nil {
+		t.Fatalf("valid export rejected: %v", err)
+	}
+
+	lines := strings.SplitAfter(good, "\n")
+
+	e.Articles[0].SubjectID = 2
+	buf.Reset()
+	if _, err := e.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, export := range map[string]string{
+		"empty":            "",
+		"truncated":        strings.Join(lines[:len(lines)-2], ""),
+		"tampered":         strings.Replace(good, `"title":"A"`, `"title":"B"`, 1),
+		"no header":        strings.Join(lines[1:], ""),
+		"dangling subject": buf.String(),
+		"future version":   strings.Replace(good, `"version":2`, `"version":3`, 1),
+	} {
+		if _, err := ReadExport(strings.NewReader(export)); err == nil {
+			t.Errorf("%s export accepted", name)
+		}
+	}
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,965 @@
+// Package memdb keeps the db stores in memory, so the admin and the build
+// pipeline can run without a database, e.g. in httptest.
+//
+// It enforces what the SQL schema enforces: unique titles and slugs,
+// compared case-insensitively, and foreign keys that refuse to delete a
+// subject or an author still in use. Contexts are accepted for the
+// interfaces and otherwise ignored: nothing here waits.
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blog/internal/db"
+	"blog/internal/model"
+	"blog/internal/utils"
+)
+
+// ErrConstraint is returned where the SQL schema would reject a write.
+var ErrConstraint = errors.New("memdb: constraint violation")
+
+// store holds every table behind one lock, so that foreign keys can be
+// checked across them.
+type store struct {
+	mu sync.Mutex
+
+	articles map[int64]model.Article
+	subjects map[int64]model.Subject
+	authors  map[int64]model.Author
+	pages    map[int64]model.Page
+
+	revisions map[int64]model.Revision
+	audit     []model.AuditEntry
+
+	// lastID holds the last id handed out per table, like AUTOINCREMENT.
+	lastID map[string]int64
+
+	// now is the clock used for created_at and updated_at.
+	now func() time.Time
+}
+
+// New returns empty stores seeded like the initial migration: the default
+// subject, the default author and the author page.
+func New() db.Repos {
+	s := &store{
+		articles: make(map[int64]model.Article),
+		subjects: make(map[int64]model.Subject),
+		authors:  make(map[int64]model.Author),
+		pages:    make(map[int64]model.Page),
+
+		revisions: make(map[int64]model.Revision),
+
+		lastID: make(map[string]int64),
+		now: func() time.Time {
+			return time.Now().UTC().Truncate(time.Second)
+		},
+	}
+
+	subjectID := s.id("subjects")
+	s.subjects[subjectID] = model.Subject{Id: subjectID, Title: "Default", Slug: "default"}
+
+	authorID := s.id("authors")
+	s.authors[authorID] = model.Author{ID: authorID, Name: "Default", Slug: "default"}
+
+	pageID := s.id("pages")
+	s.pages[pageID] = model.Page{
+		ID:        pageID,
+		Slug:      "author",
+		Title:     "Author",
+		HTML:      `<h2 id="author_talk">About me</h2><p>default author description</p><h2 id="contact">Contact</h2><p>contacts</p>`,
+		IsPublic:  true,
+		UpdatedAt: s.now(),
+	}
+
+	return db.Repos{
+		Articles: &articleStore{s},
+		Subjects: &subjectStore{s},
+		Authors:  &authorStore{s},
+		Pages:    &pageStore{s},
+		Audit:    &auditStore{s},
+	}
+}
+
+func (s *store) id(table string) int64 {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+func constraint(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrConstraint, fmt.Sprintf(format, args...))
+}
+
+// sortedIDs returns the keys of m, lowest first.
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// lessFold orders like the case-insensitive SQL collations.
+func lessFold(a, b string) bool {
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+/* articles */
+
+// copyTime keeps the caller's pointer out of the store.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+type articleStore struct{ *store }
+
+func (s *articleStore) ListAll(ctx context.Context) ([]model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := sortedIDs(s.articles)
+
+	var articles []model.Article
+	for i := len(ids) - 1; i >= 0; i-- {
+		if a := s.articles[ids[i]]; a.DeletedAt == nil {
+			articles = append(articles, a)
+		}
+	}
+	return articles, nil
+}
+
+func (s *articleStore) ListIDAndTitle(ctx context.Context) ([]db.ArticleTitle, error) {
+	articles, _ := s.ListAll(ctx)
+
+	var result []db.ArticleTitle
+	for _, a := range articles {
+		result = append(result, db.ArticleTitle{ID: a.ID, Title: a.Title})
+	}
+	return result, nil
+}
+
+func (s *articleStore) ListByTranslationGroup(ctx context.Context, group int64) ([]model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var articles []model.Article
+	for _, id := range sortedIDs(s.articles) {
+		if a := s.articles[id]; a.TranslationGroup == group && a.DeletedAt == nil {
+			articles = append(articles, a)
+		}
+	}
+	return articles, nil
+}
+
+func (s *articleStore) ListDue(ctx context.Context, now time.Time) ([]model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var articles []model.Article
+	for _, id := range sortedIDs(s.articles) {
+		if a := s.articles[id]; a.PublishAt != nil && !a.PublishAt.After(now) && a.DeletedAt == nil {
+			articles = append(articles, a)
+		}
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].PublishAt.Before(*articles[j].PublishAt)
+	})
+	return articles, nil
+}
+
+func (s *articleStore) GetByID(ctx context.Context, id int64) (model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.articles[id]
+	if !ok || a.DeletedAt != nil {
+		return model.Article{}, sql.ErrNoRows
+	}
+	return a, nil
+}
+
+func (s *articleStore) GetByTitleURL(ctx context.Context, titleURL string) (model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.articles {
+		if strings.EqualFold(a.TitleURL, titleURL) && a.DeletedAt == nil {
+			return a, nil
+		}
+	}
+	return model.Article{}, sql.ErrNoRows
+}
+
+func (s *articleStore) GetHTMLByID(ctx context.Context, id int64) (string, error) {
+	a, err := s.GetByID(ctx, id)
+	return a.HTML, err
+}
+
+func (s *articleStore) GetDefaultSubjectID(ctx context.Context) (int64, error) {
+	return (&subjectStore{s.store}).GetIDBySlug(ctx, "default")
+}
+
+func (s *articleStore) ExistsByTitle(ctx context.Context, title string, id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.titleTaken(utils.Slugify(title), id), nil
+}
+
+func (s *articleStore) ExistsByTitleRaw(ctx context.Context, title string) (bool, error) {
+	return s.ExistsByTitle(ctx, title, 0)
+}
+
+// titleTaken reports whether an article other than id has the URL slug.
+func (s *articleStore) titleTaken(titleURL string, id int64) bool {
+	for _, a := range s.articles {
+		if a.ID != id && strings.EqualFold(a.TitleURL, titleURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// check enforces the unique keys and the foreign keys of a.
+func (s *articleStore) check(a model.Article) error {
+	for _, other := range s.articles {
+		if other.ID != a.ID && strings.EqualFold(other.Title, a.Title) {
+			return constraint("duplicate article title %q", a.Title)
+		}
+	}
+	if s.titleTaken(a.TitleURL, a.ID) {
+		return constraint("duplicate article title_url %q", a.TitleURL)
+	}
+	if _, ok := s.subjects[a.SubjectId]; !ok {
+		return constraint("fk_articles_subject: no subject %d", a.SubjectId)
+	}
+	if _, ok := s.authors[a.AuthorId]; !ok {
+		return constraint("fk_articles_author: no author %d", a.AuthorId)
+	}
+	return nil
+}
+
+func (s *articleStore) Create(ctx context.Context, a model.Article, actor string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.ID = 0
+	a.TitleURL = utils.Slugify(a.Title)
+	if err := s.check(a); err != nil {
+		return 0, err
+	}
+
+	a.ID = s.id("articles")
+	a.PublishAt = copyTime(a.PublishAt)
+	a.DeletedAt = nil
+	a.CreatedAt = s.now()
+	a.UpdatedAt = a.CreatedAt
+	s.articles[a.ID] = a
+	s.record(a, actor)
+
+	return a.ID, nil
+}
+
+func (s *articleStore) Update(ctx context.Context, a model.Article, actor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.articles[a.ID]
+	if !ok {
+		return nil
+	}
+
+	a.TitleURL = utils.Slugify(a.Title)
+	if err := s.check(a); err != nil {
+		return err
+	}
+
+	a.PublishAt = copyTime(a.PublishAt)
+	a.DeletedAt = old.DeletedAt
+	a.CreatedAt = old.CreatedAt
+	a.UpdatedAt = s.now()
+	s.articles[a.ID] = a
+	s.record(a, actor)
+
+	return nil
+}
+
+// set applies change to article id, if it exists.
+func (s *articleStore) set(id int64, change func(a *model.Article)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.articles[id]; ok {
+		change(&a)
+		s.articles[id] = a
+	}
+	return nil
+}
+
+func (s *articleStore) SetTranslationGroup(ctx context.Context, id, group int64) error {
+	return s.set(id, func(a *model.Article) { a.TranslationGroup = group })
+}
+
+func (s *articleStore) SetPinnedHome(ctx context.Context, id int64, pinned bool) error {
+	return s.set(id, func(a *model.Article) { a.PinnedHome = pinned })
+}
+
+func (s *articleStore) SetPinnedSubject(ctx context.Context, id int64, pinned bool) error {
+	return s.set(id, func(a *model.Article) { a.PinnedSubject = pinned })
+}
+
+func (s *articleStore) SetFeatured(ctx context.Context, id int64, featured bool) error {
+	return s.set(id, func(a *model.Article) { a.Featured = featured })
+}
+
+func (s *articleStore) Publish(ctx context.Context, id int64, actor string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.articles[id]; ok {
+		a.IsPublic = true
+		a.PublishAt = nil
+		s.articles[id] = a
+		s.record(a, actor)
+	}
+	return nil
+}
+
+func (s *articleStore) ReslugAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, a := range s.articles {
+		a.TitleURL = utils.Slugify(a.Title)
+		s.articles[id] = a
+	}
+	return nil
+}
+
+func (s *articleStore) Trash(ctx context.Context, id int64, now time.Time) error {
+	return s.set(id, func(a *model.Article) {
+		if a.DeletedAt == nil {
+			t := now.UTC()
+			a.DeletedAt = &t
+		}
+	})
+}
+
+func (s *articleStore) Untrash(ctx context.Context, id int64) error {
+	return s.set(id, func(a *model.Article) { a.DeletedAt = nil })
+}
+
+func (s *articleStore) ListTrash(ctx context.Context) ([]model.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := sortedIDs(s.articles)
+
+	var articles []model.Article
+	for i := len(ids) - 1; i >= 0; i-- {
+		if a := s.articles[ids[i]]; a.DeletedAt != nil {
+			articles = append(articles, a)
+		}
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].DeletedAt.After(*articles[j].DeletedAt)
+	})
+	return articles, nil
+}
+
+func (s *articleStore) Purge(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.articles[id]; ok && a.DeletedAt != nil {
+		s.delete(id)
+	}
+	return nil
+}
+
+func (s *articleStore) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, a := range s.articles {
+		if a.DeletedAt != nil && a.DeletedAt.Before(cutoff) {
+			s.delete(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// delete removes article id and, as fk_article_revisions_article
+// cascades, its revisions.
+func (s *articleStore) delete(id int64) {
+	delete(s.articles, id)
+
+	for revID, rev := range s.revisions {
+		if rev.ArticleID == id {
+			delete(s.revisions, revID)
+		}
+	}
+}
+
+// record stores a as a new revision by actor.
+func (s *articleStore) record(a model.Article, actor string) {
+	id := s.id("article_revisions")
+	s.revisions[id] = model.Revision{
+		ID:        id,
+		ArticleID: a.ID,
+		Title:     a.Title,
+		SubjectId: a.SubjectId,
+		IsPublic:  a.IsPublic,
+		HTML:      a.HTML,
+		CreatedAt: a.UpdatedAt,
+		Actor:     actor,
+	}
+}
+
+// Search tokenizes the raw title and HTML like the SQL full-text
+// indexes, and ranks articles with more terms in their title first.
+func (s *articleStore) Search(ctx context.Context, query string, limit int) ([]model.Article, error) {
+	terms := db.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := sortedIDs(s.articles)
+
+	var (
+		articles []model.Article
+		score    = make(map[int64]int)
+	)
+
+	for i := len(ids) - 1; i >= 0; i-- {
+		a := s.articles[ids[i]]
+		if a.DeletedAt != nil {
+			continue
+		}
+
+		title := db.SearchTerms(a.Title)
+		words := append(db.SearchTerms(utils.PlainText(a.HTML)), title...)
+
+		if !hasPrefixes(words, terms) {
+			continue
+		}
+
+		for _, t := range terms {
+			if hasPrefixes(title, []string{t}) {
+				score[a.ID]++
+			}
+		}
+		articles = append(articles, a)
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return score[articles[i].ID] > score[articles[j].ID]
+	})
+
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, nil
+}
+
+// hasPrefixes reports whether every term starts one of words.
+func hasPrefixes(words, terms []string) bool {
+	for _, t := range terms {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *articleStore) ListRevisions(ctx context.Context, articleID int64) ([]model.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := sortedIDs(s.revisions)
+
+	var revisions []model.Revision
+	for i := len(ids) - 1; i >= 0; i-- {
+		if rev := s.revisions[ids[i]]; rev.ArticleID == articleID {
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+func (s *articleStore) GetRevision(ctx context.Context, id int64) (model.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rev, ok := s.revisions[id]
+	if !ok {
+		return model.Revision{}, sql.ErrNoRows
+	}
+	return rev, nil
+}
+
+/* subjects */
+
+type subjectStore struct{ *store }
+
+func (s *subjectStore) ListAll(ctx context.Context) ([]model.Subject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subjects := make([]model.Subject, 0, len(s.subjects))
+	for _, id := range sortedIDs(s.subjects) {
+		subjects = append(subjects, s.subjects[id])
+	}
+
+	sort.SliceStable(subjects, func(i, j int) bool {
+		if subjects[i].Position != subjects[j].Position {
+			return subjects[i].Position < subjects[j].Position
+		}
+		return lessFold(subjects[i].Title, subjects[j].Title)
+	})
+	return subjects, nil
+}
+
+func (s *subjectStore) ListIDAndTitle(ctx context.Context) ([]db.SubjectTitle, error) {
+	subjects, _ := s.ListAll(ctx)
+
+	var result []db.SubjectTitle
+	for _, sub := range subjects {
+		result = append(result, db.SubjectTitle{ID: sub.Id, Title: sub.Title, ParentID: sub.ParentId})
+	}
+	return result, nil
+}
+
+func (s *subjectStore) GetByID(ctx context.Context, id int64) (model.Subject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subjects[id]
+	if !ok {
+		return model.Subject{}, sql.ErrNoRows
+	}
+	return sub, nil
+}
+
+func (s *subjectStore) GetSlugByID(ctx context.Context, id int64) (string, error) {
+	sub, err := s.GetByID(ctx, id)
+	return sub.Slug, err
+}
+
+func (s *subjectStore) GetIDBySlug(ctx context.Context, slug string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subjects {
+		if strings.EqualFold(sub.Slug, slug) {
+			return sub.Id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (s *subjectStore) ExistsByName(ctx context.Context, name string, id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.slugTaken(utils.Slugify(name), id), nil
+}
+
+func (s *subjectStore) ExistsByNameRaw(ctx context.Context, name string) (bool, error) {
+	return s.ExistsByName(ctx, name, 0)
+}
+
+func (s *subjectStore) slugTaken(slug string, id int64) bool {
+	for _, sub := range s.subjects {
+		if sub.Id != id && strings.EqualFold(sub.Slug, slug) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subjectStore) checkParent(parentID int64) error {
+	if _, ok := s.subjects[parentID]; parentID != 0 && !ok {
+		return constraint("fk_subjects_parent: no subject %d", parentID)
+	}
+	return nil
+}
+
+func (s *subjectStore) Create(ctx context.Context, title string, parentID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slug := utils.Slugify(title)
+	if s.slugTaken(slug, 0) {
+		return 0, constraint("duplicate subject slug %q", slug)
+	}
+	if err := s.checkParent(parentID); err != nil {
+		return 0, err
+	}
+
+	id := s.id("subjects")
+	s.subjects[id] = model.Subject{Id: id, Title: title, Slug: slug, ParentId: parentID}
+
+	return id, nil
+}
+
+func (s *subjectStore) Update(ctx context.Context, sub model.Subject) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.subjects[sub.Id]
+	if !ok {
+		return nil
+	}
+
+	slug := utils.Slugify(sub.Title)
+	if s.slugTaken(slug, sub.Id) {
+		return constraint("duplicate subject slug %q", slug)
+	}
+
+	old.Title = sub.Title
+	old.Slug = slug
+	old.Description = sub.Description
+	old.Cover = sub.Cover
+	old.Position = sub.Position
+	s.subjects[sub.Id] = old
+
+	return nil
+}
+
+func (s *subjectStore) SetParent(ctx context.Context, id, parentID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkParent(parentID); err != nil {
+		return err
+	}
+
+	if sub, ok := s.subjects[id]; ok {
+		sub.ParentId = parentID
+		s.subjects[id] = sub
+	}
+	return nil
+}
+
+// Delete moves the children up to the subject's parent, like
+// db.SubjectRepo.Delete, and refuses while articles use the subject.
+func (s *subjectStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subjects[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	var defaultID int64
+	for subID, other := range s.subjects {
+		if other.Slug == db.DefaultSubjectSlug {
+			defaultID = subID
+		}
+	}
+
+	for _, a := range s.articles {
+		if a.SubjectId == id && a.DeletedAt == nil {
+			return constraint("fk_articles_subject: subject %d has articles", id)
+		}
+	}
+
+	// only live articles keep the subject
+	for articleID, a := range s.articles {
+		if a.SubjectId == id {
+			a.SubjectId = defaultID
+			s.articles[articleID] = a
+		}
+	}
+
+	for childID, child := range s.subjects {
+		if child.ParentId == id {
+			child.ParentId = sub.ParentId
+			s.subjects[childID] = child
+		}
+	}
+
+	delete(s.subjects, id)
+	return nil
+}
+
+/* authors */
+
+type authorStore struct{ *store }
+
+func (s *authorStore) ListAll(ctx context.Context) ([]model.Author, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authors := make([]model.Author, 0, len(s.authors))
+	for _, id := range sortedIDs(s.authors) {
+		authors = append(authors, s.authors[id])
+	}
+
+	sort.SliceStable(authors, func(i, j int) bool {
+		return lessFold(authors[i].Name, authors[j].Name)
+	})
+	return authors, nil
+}
+
+func (s *authorStore) GetByID(ctx context.Context, id int64) (model.Author, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.authors[id]
+	if !ok {
+		return model.Author{}, sql.ErrNoRows
+	}
+	return a, nil
+}
+
+func (s *authorStore) GetBySlug(ctx context.Context, slug string) (model.Author, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.authors {
+		if strings.EqualFold(a.Slug, slug) {
+			return a, nil
+		}
+	}
+	return model.Author{}, sql.ErrNoRows
+}
+
+func (s *authorStore) GetDefaultID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := sortedIDs(s.authors)
+	if len(ids) == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return ids[0], nil
+}
+
+func (s *authorStore) ExistsByName(ctx context.Context, name string, id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.slugTaken(utils.Slugify(name), id), nil
+}
+
+func (s *authorStore) slugTaken(slug string, id int64) bool {
+	for _, a := range s.authors {
+		if a.ID != id && strings.EqualFold(a.Slug, slug) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *authorStore) CountArticles(ctx context.Context, id int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, a := range s.articles {
+		if a.AuthorId == id {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// storedAuthor returns a as the SQL store reads it back: slugged, with its
+// links through the column format.
+func storedAuthor(a model.Author) model.Author {
+	a.Slug = utils.Slugify(a.Name)
+	a.Links = model.ParseAuthorLinks(model.FormatAuthorLinks(a.Links))
+	return a
+}
+
+func (s *authorStore) Create(ctx context.Context, a model.Author) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a = storedAuthor(a)
+	if s.slugTaken(a.Slug, 0) {
+		return 0, constraint("duplicate author slug %q", a.Slug)
+	}
+
+	a.ID = s.id("authors")
+	s.authors[a.ID] = a
+
+	return a.ID, nil
+}
+
+func (s *authorStore) Update(ctx context.Context, a model.Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.authors[a.ID]; !ok {
+		return nil
+	}
+
+	a = storedAuthor(a)
+	if s.slugTaken(a.Slug, a.ID) {
+		return constraint("duplicate author slug %q", a.Slug)
+	}
+
+	s.authors[a.ID] = a
+	return nil
+}
+
+func (s *authorStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.articles {
+		if a.AuthorId == id {
+			return constraint("fk_articles_author: author %d has articles", id)
+		}
+	}
+
+	delete(s.authors, id)
+	return nil
+}
+
+/* pages */
+
+type pageStore struct{ *store }
+
+func (s *pageStore) ListAll(ctx context.Context) ([]model.Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pages := make([]model.Page, 0, len(s.pages))
+	for _, id := range sortedIDs(s.pages) {
+		pages = append(pages, s.pages[id])
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].NavOrder != pages[j].NavOrder {
+			return pages[i].NavOrder < pages[j].NavOrder
+		}
+		return lessFold(pages[i].Title, pages[j].Title)
+	})
+	return pages, nil
+}
+
+func (s *pageStore) GetBySlug(ctx context.Context, slug string) (model.Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.pages {
+		if strings.EqualFold(p.Slug, slug) {
+			return p, nil
+		}
+	}
+	return model.Page{}, sql.ErrNoRows
+}
+
+func (s *pageStore) ExistsBySlug(ctx context.Context, slug string, id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.slugTaken(slug, id), nil
+}
+
+func (s *pageStore) slugTaken(slug string, id int64) bool {
+	for _, p := range s.pages {
+		if p.ID != id && strings.EqualFold(p.Slug, slug) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *pageStore) Create(ctx context.Context, p model.Page) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.slugTaken(p.Slug, 0) {
+		return 0, constraint("duplicate page slug %q", p.Slug)
+	}
+
+	p.ID = s.id("pages")
+	p.UpdatedAt = s.now()
+	s.pages[p.ID] = p
+
+	return p.ID, nil
+}
+
+func (s *pageStore) Update(ctx context.Context, p model.Page) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pages[p.ID]; !ok {
+		return nil
+	}
+	if s.slugTaken(p.Slug, p.ID) {
+		return constraint("duplicate page slug %q", p.Slug)
+	}
+
+	p.UpdatedAt = s.now()
+	s.pages[p.ID] = p
+	return nil
+}
+
+func (s *pageStore) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pages, id)
+	return nil
+}
+
+type auditStore struct{ *store }
+
+func (s *auditStore) Record(ctx context.Context, e model.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.id("audit_log")
+	e.CreatedAt = e.CreatedAt.UTC()
+	s.audit = append(s.audit, e)
+	return nil
+}
+
+func (s *auditStore) List(ctx context.Context, f db.AuditFilter) ([]model.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []model.AuditEntry
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(entries) == f.Limit {
+			break
+		}
+		if f.Match(s.audit[i]) {
+			entries = append(entries, s.audit[i])
+		}
+	}
+
+	return entries, nil
+}
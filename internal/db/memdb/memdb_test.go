@@ -0,0 +1,216 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"blog/internal/db"
+	"blog/internal/model"
+)
+
+// TestStores runs the same scenario over memdb and SQLite, so the two
+// cannot drift apart.
+func TestStores(t *testing.T) {
+	t.Run("memdb", func(t *testing.T) { testStores(t, New()) })
+
+	t.Run("sqlite", func(t *testing.T) {
+		conn, err := db.Open(db.Config{Driver: db.SQLite, Path: filepath.Join(t.TempDir(), "statix.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if _, err := (&db.Migrator{DB: conn, Driver: db.SQLite}).Up(0, false); err != nil {
+			t.Fatal(err)
+		}
+
+		testStores(t, db.NewRepos(conn, db.Config{Driver: db.SQLite}))
+	})
+}
+
+func testStores(t *testing.T, repos db.Repos) {
+	ctx := context.Background()
+
+	defaultSubject, err := repos.Articles.GetDefaultSubjectID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorID, err := repos.Authors.GetDefaultID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repos.Pages.GetBySlug(ctx, "author"); err != nil {
+		t.Errorf("author page not seeded: %v", err)
+	}
+
+	parentID, err := repos.Subjects.Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childID, err := repos.Subjects.Create(ctx, "Generics", parentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repos.Subjects.Create(ctx, "GO", 0); err == nil {
+		t.Error("created a subject with a taken slug")
+	}
+
+	if id, err := repos.Subjects.GetIDBySlug(ctx, "GENERICS"); err != nil || id != childID {
+		t.Errorf("slug lookup is not case-insensitive: %d, %v", id, err)
+	}
+
+	publishAt := time.Now().Add(-time.Minute)
+	id, err := repos.Articles.Create(ctx, model.Article{
+		Title:     "Hello World",
+		SubjectId: parentID,
+		AuthorId:  authorID,
+		Lang:      model.DefaultLang,
+		HTML:      "<p>hi</p>",
+		PublishAt: &publishAt,
+	}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repos.Articles.Create(ctx, model.Article{Title: "Orphan", SubjectId: 999, AuthorId: authorID}, "admin"); err == nil {
+		t.Error("created an article in a missing subject")
+	}
+
+	a, err := repos.Articles.GetByTitleURL(ctx, "hello-world")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != id || a.CreatedAt.IsZero() || a.PublishAt == nil {
+		t.Errorf("article read back as %+v", a)
+	}
+
+	if exists, err := repos.Articles.ExistsByTitleRaw(ctx, "HELLO WORLD"); err != nil || !exists {
+		t.Errorf("title lookup is not case-insensitive: %v, %v", exists, err)
+	}
+
+	due, err := repos.Articles.ListDue(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != id {
+		t.Errorf("due articles: %+v", due)
+	}
+
+	if err := repos.Articles.Publish(ctx, id, "scheduler"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := repos.Articles.GetByID(ctx, id); !a.IsPublic || a.PublishAt != nil {
+		t.Errorf("published article: %+v", a)
+	}
+
+	if err := repos.Subjects.Delete(ctx, parentID); err == nil {
+		t.Error("deleted a subject that still has articles")
+	}
+	if err := repos.Authors.Delete(ctx, authorID); err == nil {
+		t.Error("deleted an author that still has articles")
+	}
+
+	a.SubjectId = defaultSubject
+	a.HTML = "<p>hi again</p>"
+	if err := repos.Articles.Update(ctx, a, "stx"); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := repos.Articles.ListRevisions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 || revisions[0].Actor != "stx" || revisions[0].HTML != a.HTML ||
+		revisions[1].Actor != "scheduler" || !revisions[1].IsPublic ||
+		revisions[2].Actor != "admin" || revisions[2].SubjectId != parentID {
+		t.Errorf("revisions: %+v", revisions)
+	}
+	if rev, err := repos.Articles.GetRevision(ctx, revisions[2].ID); err != nil || rev.HTML != "<p>hi</p>" {
+		t.Errorf("first revision: %+v, %v", rev, err)
+	}
+
+	for query, want := range map[string]int{
+		"again HELL": 1, // prefixes, in title and body, any case
+		"world hi":   1,
+		"again nope": 0,
+		"!?":         0,
+	} {
+		found, err := repos.Articles.Search(ctx, query, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		if len(found) != want || (want > 0 && found[0].ID != id) {
+			t.Errorf("search %q: %+v, want %d hits", query, found, want)
+		}
+	}
+
+	// the children of a deleted subject move up to its parent
+	if err := repos.Subjects.Delete(ctx, parentID); err != nil {
+		t.Fatal(err)
+	}
+	if child, err := repos.Subjects.GetByID(ctx, childID); err != nil || child.ParentId != 0 {
+		t.Errorf("child after deleting its parent: %+v, %v", child, err)
+	}
+
+	if _, err := repos.Subjects.GetByID(ctx, parentID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted subject lookup: %v, want sql.ErrNoRows", err)
+	}
+
+	if err := repos.Articles.Trash(ctx, id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Articles.GetByID(ctx, id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("trashed article lookup: %v, want sql.ErrNoRows", err)
+	}
+	if all, _ := repos.Articles.ListAll(ctx); len(all) != 0 {
+		t.Errorf("trashed article listed: %+v", all)
+	}
+	if found, _ := repos.Articles.Search(ctx, "hello", 10); len(found) != 0 {
+		t.Errorf("trashed article found: %+v", found)
+	}
+	if exists, _ := repos.Articles.ExistsByTitleRaw(ctx, "Hello World"); !exists {
+		t.Error("a trashed article gave up its title")
+	}
+
+	trash, err := repos.Articles.ListTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 1 || trash[0].ID != id || trash[0].DeletedAt == nil {
+		t.Errorf("trash: %+v", trash)
+	}
+
+	// purging leaves live articles alone
+	if err := repos.Articles.Untrash(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Articles.Purge(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Articles.GetByID(ctx, id); err != nil {
+		t.Errorf("restored article lookup: %v", err)
+	}
+
+	if err := repos.Articles.Trash(ctx, id, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repos.Articles.PurgeTrashedBefore(ctx, time.Now().Add(-72*time.Hour)); err != nil || n != 0 {
+		t.Errorf("purged %d articles trashed before the cutoff, %v", n, err)
+	}
+	if n, err := repos.Articles.PurgeTrashedBefore(ctx, time.Now().Add(-24*time.Hour)); err != nil || n != 1 {
+		t.Errorf("purged %d articles trashed before the cutoff, %v, want 1", n, err)
+	}
+	if trash, _ := repos.Articles.ListTrash(ctx); len(trash) != 0 {
+		t.Errorf("trash after purging: %+v", trash)
+	}
+	if _, err := repos.Articles.GetRevision(ctx, revisions[0].ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("revision of a deleted article: %v, want sql.ErrNoRows", err)
+	}
+}
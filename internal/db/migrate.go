@@ -0,0 +1,430 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"blog/internal/utils"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer
+// binary: running against it could corrupt data this one does not know.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// Migration is one step of the schema history, read from the embedded
+// migrations/<driver>/NNNN_name.up.sql and its NNNN_name.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the embedded migrations of driver in version order.
+// Versions must start at 1 and have no gaps, and every migration needs
+// both directions.
+func Migrations(driver string) ([]Migration, error) {
+	if driver == "" {
+		driver = MySQL
+	}
+	return parseMigrations(migrationFiles, path.Join("migrations", driver))
+}
+
+func parseMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, e := range entries {
+		name := e.Name()
+
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || !strings.HasSuffix(name, ".sql") || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: want NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+
+		num, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: bad version %q", name, num)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if m.Name != label {
+			return nil, fmt.Errorf("migration %d: names %q and %q differ", version, m.Name, label)
+		}
+
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d: up and down are both required", m.Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Statements splits a migration script on the semicolons ending a line,
+// dropping the -- comment lines. The driver runs one statement per Exec.
+// A trigger body, from a line ending in BEGIN to a line END;, stays in
+// one statement.
+func Statements(script string) []string {
+	var (
+		stmts []string
+		cur   strings.Builder
+		body  bool
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if (trimmed == "" && cur.Len() == 0) || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		cur.WriteString(line)
+		cur.WriteString("\n")
+
+		upper := strings.ToUpper(trimmed)
+		if strings.HasSuffix(upper, "BEGIN") {
+			body = true
+		}
+		if body && upper != "END;" {
+			continue
+		}
+		body = false
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(cur.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+
+	return stmts
+}
+
+// fills finish the migrations, by name, whose data SQL cannot compute.
+// A fill runs in the transaction of its migration, after the script.
+var fills = map[string]func(tx *sql.Tx) error{
+	"article_text": fillArticleText,
+}
+
+// fillArticleText extracts the text of every article for the search
+// index, as ArticleRepo does on each save.
+func fillArticleText(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, html FROM articles`)
+	if err != nil {
+		return err
+	}
+
+	texts := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id   int64
+			html string
+		)
+		if err := rows.Scan(&id, &html); err != nil {
+			rows.Close()
+			return err
+		}
+		texts[id] = utils.PlainText(html)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, text := range texts {
+		if _, err := tx.Exec(`UPDATE articles SET text = ? WHERE id = ?`, text, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Migrator applies the embedded migrations, recording each applied
+// version in schema_migrations. Each migration runs in a transaction, but
+// MySQL commits DDL on its own, so there a migration failing halfway is
+// not rolled back: fix the database by hand and run it again.
+type Migrator struct {
+	DB     *sql.DB
+	Driver string
+
+	// Migrations overrides the embedded migrations, for tests.
+	Migrations []Migration
+}
+
+func (m *Migrator) migrations() ([]Migration, error) {
+	if m.Migrations != nil {
+		return m.Migrations, nil
+	}
+	return Migrations(m.Driver)
+}
+
+// ensure creates schema_migrations. A database holding the tables of the
+// released database.sql but no schema_migrations is recorded at version
+// 1, the migration that holds that schema.
+func (m *Migrator) ensure() error {
+	if _, err := m.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return err
+	}
+
+	var recorded int
+	if err := m.DB.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		return err
+	}
+	if recorded > 0 {
+		return nil
+	}
+
+	// database.sql only ever existed for MySQL
+	if m.Driver == SQLite {
+		return nil
+	}
+
+	if legacy, err := m.hasTable("articles"); err != nil || !legacy {
+		return err
+	}
+
+	migrations, err := m.migrations()
+	if err != nil || len(migrations) == 0 {
+		return err
+	}
+
+	_, err = m.DB.Exec(
+		`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`,
+		migrations[0].Version, migrations[0].Name,
+	)
+	return err
+}
+
+// hasTable reports whether the MySQL table exists.
+func (m *Migrator) hasTable(table string) (bool, error) {
+	var n int
+	if err := m.DB.QueryRow(`
+		SELECT COUNT(*)
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?
+	`, table).Scan(&n); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// Version returns the latest applied version, 0 on an empty database.
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensure(); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := m.DB.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, err
+	}
+
+	return int(version.Int64), nil
+}
+
+// Status returns the applied version and the migrations still pending.
+// It fails with ErrSchemaTooNew when the database is ahead of the binary.
+func (m *Migrator) Status() (int, []Migration, error) {
+	migrations, err := m.migrations()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if version > len(migrations) {
+		return version, nil, fmt.Errorf("%w: version %d, this binary knows up to %d",
+			ErrSchemaTooNew, version, len(migrations))
+	}
+
+	return version, migrations[version:], nil
+}
+
+// Up applies the pending migrations up to target, or all of them when
+// target is 0, and returns them. With dryRun nothing is executed.
+func (m *Migrator) Up(target int, dryRun bool) ([]Migration, error) {
+	version, pending, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	if target != 0 {
+		if target < version || target > version+len(pending) {
+			return nil, fmt.Errorf("cannot migrate up from version %d to %d", version, target)
+		}
+		pending = pending[:target-version]
+	}
+
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, mig := range pending {
+		err := m.exec(mig.Up, fills[mig.Name],
+			`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`,
+			mig.Version, mig.Name,
+		)
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Down reverts the last steps applied migrations, latest first, and
+// returns them. With dryRun nothing is executed.
+func (m *Migrator) Down(steps int, dryRun bool) ([]Migration, error) {
+	migrations, err := m.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	version, _, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	if steps < 0 || steps > version {
+		return nil, fmt.Errorf("cannot revert %d migrations from version %d", steps, version)
+	}
+
+	var reverted []Migration
+	for v := version; v > version-steps; v-- {
+		reverted = append(reverted, migrations[v-1])
+	}
+
+	if dryRun {
+		return reverted, nil
+	}
+
+	for i, mig := range reverted {
+		err := m.exec(mig.Down, nil,
+			`DELETE FROM schema_migrations WHERE version = ?`,
+			mig.Version,
+		)
+		if err != nil {
+			return reverted[:i], fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	return reverted, nil
+}
+
+// exec runs script and fill, if any, then the bookkeeping statement
+// record with args.
+// SQLite rebuilds tables to change them, which foreign keys would refuse
+// midway, so there they are off during the migration and checked before
+// it commits.
+func (m *Migrator) exec(script string, fill func(*sql.Tx) error, record string, args ...any) error {
+	ctx := context.Background()
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.Driver == SQLite {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range Statements(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	if fill != nil {
+		if err := fill(tx); err != nil {
+			return err
+		}
+	}
+
+	if m.Driver == SQLite {
+		var (
+			table, parent string
+			rowid, fkid   sql.NullInt64
+		)
+		err := tx.QueryRow(`PRAGMA foreign_key_check`).Scan(&table, &rowid, &parent, &fkid)
+		if err == nil {
+			return fmt.Errorf("rows of %s break a foreign key", table)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
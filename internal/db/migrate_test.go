@@ -0,0 +1,82 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	mysql, err := Migrations(MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite, err := Migrations(SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mysql) == 0 || mysql[0].Name != "initial" {
+		t.Fatalf("first migration is not the initial schema: %+v", mysql)
+	}
+
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("%d MySQL migrations, %d SQLite ones", len(mysql), len(sqlite))
+	}
+
+	for i, m := range mysql {
+		if sqlite[i].Name != m.Name {
+			t.Errorf("migration %d is %q for MySQL, %q for SQLite", m.Version, m.Name, sqlite[i].Name)
+		}
+
+		for _, script := range []string{m.Up, m.Down, sqlite[i].Up, sqlite[i].Down} {
+			for _, stmt := range Statements(script) {
+				if !strings.HasSuffix(stmt, ";") {
+					t.Errorf("migration %d: unterminated statement %q", m.Version, stmt)
+				}
+			}
+		}
+	}
+}
+
+func TestParseMigrationsRejectsGaps(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"m/0001_a.down.sql": {Data: []byte("SELECT 1;")},
+		"m/0003_c.up.sql":   {Data: []byte("SELECT 1;")},
+		"m/0003_c.down.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	if _, err := parseMigrations(fsys, "m"); err == nil {
+		t.Fatal("missing migration 2 accepted")
+	}
+}
+
+func TestStatements(t *testing.T) {
+	got := Statements(`-- comment
+CREATE TABLE t (
+    a INT
+);
+
+INSERT INTO t VALUES (1);
+
+CREATE TRIGGER t_ai AFTER INSERT ON t BEGIN
+    INSERT INTO u VALUES (new.a);
+END;
+`)
+
+	want := []string{
+		"CREATE TABLE t (\n    a INT\n);",
+		"INSERT INTO t VALUES (1);",
+		"CREATE TRIGGER t_ai AFTER INSERT ON t BEGIN\n    INSERT INTO u VALUES (new.a);\nEND;",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("statement %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
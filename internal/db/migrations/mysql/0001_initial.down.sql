@@ -0,0 +1,3 @@
+DROP TABLE author;
+DROP TABLE articles;
+DROP TABLE subjects;
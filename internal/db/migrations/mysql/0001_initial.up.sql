@@ -1,6 +1,7 @@
---CREATE DATABASE IF NOT EXISTS go_blog;
-
-DROP TABLE IF EXISTS articles;
+-- The schema of the original database.sql. Installs created from it are
+-- recorded at this version, or at the last of the following ones they
+-- already have, without running it. Keep sqlite/ in step: every version
+-- exists for both databases.
 
 CREATE TABLE subjects (
     id INT AUTO_INCREMENT PRIMARY KEY,
@@ -35,7 +36,3 @@ CREATE TABLE author (
 
 INSERT INTO author (id, html)
 VALUES (0, '<h2 id="author_talk">About me</h2><p>default author description</p><h2 id="contact">Contact</h2><p>contacts</p>');
-
-
-
-
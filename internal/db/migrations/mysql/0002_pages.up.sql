@@ -0,0 +1,16 @@
+-- Standalone pages replace the single author table: its text becomes the
+-- author page.
+
+CREATE TABLE pages (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    title VARCHAR(255) NOT NULL,
+    html MEDIUMTEXT NOT NULL,
+    nav_order INT NOT NULL DEFAULT 0,
+    is_public BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO pages (slug, title, html)
+SELECT 'author', 'Author', COALESCE(html, '') FROM author WHERE id = 0;
+
+DROP TABLE author;
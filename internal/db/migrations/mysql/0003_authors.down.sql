@@ -0,0 +1,6 @@
+ALTER TABLE articles
+    DROP FOREIGN KEY fk_articles_author,
+    DROP INDEX idx_articles_author,
+    DROP COLUMN author_id;
+
+DROP TABLE authors;
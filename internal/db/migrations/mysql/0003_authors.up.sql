@@ -0,0 +1,24 @@
+-- Articles get an author. Existing ones go to the default author, the
+-- first row of the new table.
+
+CREATE TABLE authors (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    bio MEDIUMTEXT NOT NULL,
+    avatar VARCHAR(255) NOT NULL DEFAULT '',
+    links TEXT NOT NULL
+);
+
+INSERT INTO authors (name, slug, bio, links)
+VALUES ('Default', 'default', '', '');
+
+ALTER TABLE articles
+    ADD author_id INT NOT NULL DEFAULT 1 AFTER subject_id,
+    ADD INDEX idx_articles_author (author_id),
+    ADD CONSTRAINT fk_articles_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT;
+
+ALTER TABLE articles ALTER author_id DROP DEFAULT;
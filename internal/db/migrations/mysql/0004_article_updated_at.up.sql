@@ -0,0 +1,7 @@
+-- Existing articles were last modified when they were created, as far as
+-- anyone knows.
+
+ALTER TABLE articles
+    ADD updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP AFTER created_at;
+
+UPDATE articles SET updated_at = created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE articles
+    DROP INDEX idx_articles_publish_at,
+    DROP COLUMN publish_at;
@@ -0,0 +1,6 @@
+-- publish_at holds the time a scheduled article goes public, NULL when
+-- none is set.
+
+ALTER TABLE articles
+    ADD publish_at DATETIME NULL AFTER updated_at,
+    ADD INDEX idx_articles_publish_at (publish_at);
@@ -0,0 +1,4 @@
+ALTER TABLE articles
+    DROP INDEX idx_articles_translation_group,
+    DROP COLUMN translation_group,
+    DROP COLUMN lang;
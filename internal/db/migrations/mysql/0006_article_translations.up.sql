@@ -0,0 +1,7 @@
+-- Translations of an article share its translation_group; 0 means the
+-- article has none.
+
+ALTER TABLE articles
+    ADD lang VARCHAR(8) NOT NULL DEFAULT 'en' AFTER author_id,
+    ADD translation_group INT NOT NULL DEFAULT 0 AFTER lang,
+    ADD INDEX idx_articles_translation_group (translation_group);
@@ -0,0 +1,3 @@
+ALTER TABLE subjects DROP FOREIGN KEY fk_subjects_parent;
+
+ALTER TABLE subjects DROP COLUMN parent_id;
@@ -0,0 +1,8 @@
+-- Subjects nest: parent_id is NULL for a top level subject.
+
+ALTER TABLE subjects
+    ADD parent_id INT NULL,
+    ADD CONSTRAINT fk_subjects_parent
+        FOREIGN KEY (parent_id)
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT;
@@ -0,0 +1,4 @@
+ALTER TABLE subjects
+    DROP COLUMN position,
+    DROP COLUMN cover,
+    DROP COLUMN description;
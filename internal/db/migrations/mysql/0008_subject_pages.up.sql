@@ -0,0 +1,6 @@
+-- Subject pages show a description and a cover, in position order.
+
+ALTER TABLE subjects
+    ADD description MEDIUMTEXT NOT NULL,
+    ADD cover VARCHAR(255) NOT NULL DEFAULT '',
+    ADD position INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE articles
+    DROP COLUMN featured,
+    DROP COLUMN pinned;
@@ -0,0 +1,6 @@
+-- Pinned articles lead their listings, featured ones show on the home
+-- page.
+
+ALTER TABLE articles
+    ADD pinned BOOLEAN NOT NULL DEFAULT FALSE AFTER is_public,
+    ADD featured BOOLEAN NOT NULL DEFAULT FALSE AFTER pinned;
@@ -0,0 +1,26 @@
+-- Every save of an article records a revision, so edits can be diffed and
+-- restored. subject_id has no foreign key: a revision outlives its
+-- subject, and restoring it checks the subject still exists.
+
+CREATE TABLE article_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    article_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    subject_id INT NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html MEDIUMTEXT NOT NULL,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_article_revisions_article (article_id),
+
+    CONSTRAINT fk_article_revisions_article
+        FOREIGN KEY (article_id)
+        REFERENCES articles(id)
+        ON DELETE CASCADE
+);
+
+-- existing articles start their history at their current state
+INSERT INTO article_revisions (article_id, title, subject_id, is_public, html, actor, created_at)
+SELECT id, title, subject_id, is_public, html, 'migration', updated_at
+FROM articles;
@@ -0,0 +1,5 @@
+-- Trashed articles come back as live ones.
+
+ALTER TABLE articles
+    DROP INDEX idx_articles_deleted_at,
+    DROP COLUMN deleted_at;
@@ -0,0 +1,6 @@
+-- Deleting an article moves it to the trash: deleted_at is set and every
+-- read but the trash view skips it, until it is restored or purged.
+
+ALTER TABLE articles
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD INDEX idx_articles_deleted_at (deleted_at);
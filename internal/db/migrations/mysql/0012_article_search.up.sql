@@ -0,0 +1,5 @@
+-- Full-text index for the admin search over article titles and bodies.
+-- Queries run in boolean mode with a prefix wildcard on every term.
+
+ALTER TABLE articles
+    ADD FULLTEXT INDEX ft_articles_title_html (title, html);
@@ -0,0 +1,3 @@
+-- The audit log is lost.
+
+DROP TABLE audit_log;
@@ -0,0 +1,18 @@
+-- Every authenticated request that changes or exports something is
+-- recorded, whether it came from the admin session or the publish token.
+
+CREATE TABLE audit_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(100) NOT NULL,
+    auth VARCHAR(20) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    status INT NOT NULL,
+    target VARCHAR(255) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+
+    INDEX idx_audit_log_created_at (created_at),
+    INDEX idx_audit_log_target (target)
+);
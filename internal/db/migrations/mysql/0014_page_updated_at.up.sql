@@ -0,0 +1,5 @@
+-- The sitemap gives a page's lastmod. Existing pages count as modified
+-- now, when the migration runs.
+
+ALTER TABLE pages
+    ADD updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,5 @@
+ALTER TABLE articles
+    DROP INDEX ft_articles_title_text,
+    ADD FULLTEXT INDEX ft_articles_title_html (title, html);
+
+ALTER TABLE articles DROP COLUMN text;
@@ -0,0 +1,10 @@
+-- The search index covers the plain text of articles instead of their
+-- HTML, so tag and attribute names no longer match. text is filled from
+-- html when an article is saved, and for existing ones by the migrator.
+
+ALTER TABLE articles
+    ADD text MEDIUMTEXT NOT NULL AFTER html;
+
+ALTER TABLE articles
+    DROP INDEX ft_articles_title_html,
+    ADD FULLTEXT INDEX ft_articles_title_text (title, text);
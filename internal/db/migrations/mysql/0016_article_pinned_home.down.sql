@@ -0,0 +1,3 @@
+UPDATE articles SET pinned = pinned OR pinned_home;
+
+ALTER TABLE articles DROP COLUMN pinned_home;
@@ -0,0 +1,7 @@
+-- pinned_home leads the home page with an article, apart from the pages
+-- of its subject. Articles pinned so far keep their place on both.
+
+ALTER TABLE articles
+    ADD pinned_home BOOLEAN NOT NULL DEFAULT FALSE AFTER pinned;
+
+UPDATE articles SET pinned_home = pinned;
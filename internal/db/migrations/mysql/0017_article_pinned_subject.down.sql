@@ -0,0 +1,6 @@
+ALTER TABLE articles
+    ADD pinned BOOLEAN NOT NULL DEFAULT FALSE AFTER is_public;
+
+UPDATE articles SET pinned = pinned_subject;
+
+ALTER TABLE articles DROP COLUMN pinned_subject;
@@ -0,0 +1,9 @@
+-- pinned_subject leads the pages of an article's subject and its parents
+-- with it, and takes over what is left of pinned.
+
+ALTER TABLE articles
+    ADD pinned_subject BOOLEAN NOT NULL DEFAULT FALSE AFTER pinned_home;
+
+UPDATE articles SET pinned_subject = pinned;
+
+ALTER TABLE articles DROP COLUMN pinned;
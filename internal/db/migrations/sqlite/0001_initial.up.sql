@@ -0,0 +1,38 @@
+-- The SQLite twin of mysql/0001_initial.up.sql. Text columns that MySQL
+-- compares case-insensitively are declared COLLATE NOCASE, so uniqueness
+-- and ordering match. Where SQLite cannot alter a column in place, the
+-- following migrations rebuild the table.
+
+CREATE TABLE subjects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE,
+    slug TEXT NOT NULL COLLATE NOCASE UNIQUE
+);
+
+CREATE TABLE articles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title_url TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    subject_id INTEGER NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_articles_subject
+        FOREIGN KEY (subject_id)
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_articles_subject ON articles (subject_id);
+
+INSERT INTO subjects (title, slug)
+VALUES ('Default', 'default');
+
+CREATE TABLE author (
+    id INTEGER,
+    html TEXT
+);
+
+INSERT INTO author (id, html)
+VALUES (0, '<h2 id="author_talk">About me</h2><p>default author description</p><h2 id="contact">Contact</h2><p>contacts</p>');
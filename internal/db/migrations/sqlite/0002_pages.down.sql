@@ -0,0 +1,12 @@
+-- Only the author page survives, as the author table. The other pages are
+-- lost.
+
+CREATE TABLE author (
+    id INTEGER,
+    html TEXT
+);
+
+INSERT INTO author (id, html)
+SELECT 0, html FROM pages WHERE slug = 'author';
+
+DROP TABLE pages;
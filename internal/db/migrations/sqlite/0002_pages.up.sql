@@ -0,0 +1,15 @@
+-- The SQLite twin of mysql/0002_pages.up.sql.
+
+CREATE TABLE pages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title TEXT NOT NULL COLLATE NOCASE,
+    html TEXT NOT NULL,
+    nav_order INTEGER NOT NULL DEFAULT 0,
+    is_public BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO pages (slug, title, html)
+SELECT 'author', 'Author', COALESCE(html, '') FROM author WHERE id = 0;
+
+DROP TABLE author;
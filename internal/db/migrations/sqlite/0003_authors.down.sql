@@ -0,0 +1,25 @@
+CREATE TABLE articles_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title_url TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    subject_id INTEGER NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_articles_subject
+        FOREIGN KEY (subject_id)
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT
+);
+
+INSERT INTO articles_old (id, title, title_url, subject_id, is_public, html, created_at)
+SELECT id, title, title_url, subject_id, is_public, html, created_at FROM articles;
+
+DROP TABLE articles;
+
+ALTER TABLE articles_old RENAME TO articles;
+
+CREATE INDEX idx_articles_subject ON articles (subject_id);
+
+DROP TABLE authors;
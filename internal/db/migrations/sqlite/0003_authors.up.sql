@@ -0,0 +1,45 @@
+-- The SQLite twin of mysql/0003_authors.up.sql. SQLite cannot add a
+-- NOT NULL foreign key column, so articles is rebuilt.
+
+CREATE TABLE authors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL COLLATE NOCASE,
+    slug TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    bio TEXT NOT NULL,
+    avatar TEXT NOT NULL DEFAULT '',
+    links TEXT NOT NULL
+);
+
+INSERT INTO authors (name, slug, bio, links)
+VALUES ('Default', 'default', '', '');
+
+CREATE TABLE articles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title_url TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    subject_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_articles_subject
+        FOREIGN KEY (subject_id)
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT,
+
+    CONSTRAINT fk_articles_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT
+);
+
+INSERT INTO articles_new (id, title, title_url, subject_id, author_id, is_public, html, created_at)
+SELECT id, title, title_url, subject_id, 1, is_public, html, created_at FROM articles;
+
+DROP TABLE articles;
+
+ALTER TABLE articles_new RENAME TO articles;
+
+CREATE INDEX idx_articles_subject ON articles (subject_id);
+CREATE INDEX idx_articles_author ON articles (author_id);
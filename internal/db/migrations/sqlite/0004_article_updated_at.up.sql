@@ -0,0 +1,34 @@
+-- The SQLite twin of mysql/0004_article_updated_at.up.sql. SQLite cannot
+-- add a column defaulting to CURRENT_TIMESTAMP, so articles is rebuilt.
+
+CREATE TABLE articles_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title_url TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    subject_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_articles_subject
+        FOREIGN KEY (subject_id)
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT,
+
+    CONSTRAINT fk_articles_author
+        FOREIGN KEY (author_id)
+        REFERENCES authors(id)
+        ON DELETE RESTRICT
+);
+
+INSERT INTO articles_new (id, title, title_url, subject_id, author_id, is_public, html, created_at, updated_at)
+SELECT id, title, title_url, subject_id, author_id, is_public, html, created_at, created_at FROM articles;
+
+DROP TABLE articles;
+
+ALTER TABLE articles_new RENAME TO articles;
+
+CREATE INDEX idx_articles_subject ON articles (subject_id);
+CREATE INDEX idx_articles_author ON articles (author_id);
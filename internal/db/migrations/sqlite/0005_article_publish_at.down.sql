@@ -0,0 +1,3 @@
+DROP INDEX idx_articles_publish_at;
+
+ALTER TABLE articles DROP COLUMN publish_at;
@@ -0,0 +1,5 @@
+-- The SQLite twin of mysql/0005_article_publish_at.up.sql.
+
+ALTER TABLE articles ADD COLUMN publish_at DATETIME NULL;
+
+CREATE INDEX idx_articles_publish_at ON articles (publish_at);
@@ -0,0 +1,4 @@
+DROP INDEX idx_articles_translation_group;
+
+ALTER TABLE articles DROP COLUMN translation_group;
+ALTER TABLE articles DROP COLUMN lang;
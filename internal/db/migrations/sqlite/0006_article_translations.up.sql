@@ -0,0 +1,6 @@
+-- The SQLite twin of mysql/0006_article_translations.up.sql.
+
+ALTER TABLE articles ADD COLUMN lang TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE articles ADD COLUMN translation_group INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_articles_translation_group ON articles (translation_group);
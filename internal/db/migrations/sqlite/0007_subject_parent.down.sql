@@ -0,0 +1,14 @@
+-- SQLite cannot drop a foreign key column, so subjects is rebuilt.
+
+CREATE TABLE subjects_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL COLLATE NOCASE,
+    slug TEXT NOT NULL COLLATE NOCASE UNIQUE
+);
+
+INSERT INTO subjects_old (id, title, slug)
+SELECT id, title, slug FROM subjects;
+
+DROP TABLE subjects;
+
+ALTER TABLE subjects_old RENAME TO subjects;
@@ -0,0 +1,6 @@
+-- The SQLite twin of mysql/0007_subject_parent.up.sql.
+
+ALTER TABLE subjects ADD COLUMN parent_id INTEGER NULL
+    CONSTRAINT fk_subjects_parent
+        REFERENCES subjects(id)
+        ON DELETE RESTRICT;
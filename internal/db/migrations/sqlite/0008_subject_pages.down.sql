@@ -0,0 +1,3 @@
+ALTER TABLE subjects DROP COLUMN position;
+ALTER TABLE subjects DROP COLUMN cover;
+ALTER TABLE subjects DROP COLUMN description;
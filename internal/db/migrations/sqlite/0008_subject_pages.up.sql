@@ -0,0 +1,6 @@
+-- The SQLite twin of mysql/0008_subject_pages.up.sql. SQLite needs a
+-- default to add a NOT NULL column; MySQL fills in '' by itself.
+
+ALTER TABLE subjects ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE subjects ADD COLUMN cover TEXT NOT NULL DEFAULT '';
+ALTER TABLE subjects ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
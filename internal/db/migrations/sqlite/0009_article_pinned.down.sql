@@ -0,0 +1,2 @@
+ALTER TABLE articles DROP COLUMN featured;
+ALTER TABLE articles DROP COLUMN pinned;
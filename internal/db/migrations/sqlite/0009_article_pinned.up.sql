@@ -0,0 +1,4 @@
+-- The SQLite twin of mysql/0009_article_pinned.up.sql.
+
+ALTER TABLE articles ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE articles ADD COLUMN featured BOOLEAN NOT NULL DEFAULT FALSE;
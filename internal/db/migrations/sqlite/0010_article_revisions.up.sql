@@ -0,0 +1,24 @@
+-- The SQLite twin of mysql/0010_article_revisions.up.sql.
+
+CREATE TABLE article_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    subject_id INTEGER NOT NULL,
+    is_public BOOLEAN NOT NULL,
+    html TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_article_revisions_article
+        FOREIGN KEY (article_id)
+        REFERENCES articles(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_article_revisions_article ON article_revisions (article_id);
+
+-- existing articles start their history at their current state
+INSERT INTO article_revisions (article_id, title, subject_id, is_public, html, actor, created_at)
+SELECT id, title, subject_id, is_public, html, 'migration', updated_at
+FROM articles;
@@ -0,0 +1,5 @@
+-- Trashed articles come back as live ones.
+
+DROP INDEX idx_articles_deleted_at;
+
+ALTER TABLE articles DROP COLUMN deleted_at;
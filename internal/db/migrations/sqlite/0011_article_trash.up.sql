@@ -0,0 +1,5 @@
+-- The SQLite twin of mysql/0011_article_trash.up.sql.
+
+ALTER TABLE articles ADD COLUMN deleted_at DATETIME NULL;
+
+CREATE INDEX idx_articles_deleted_at ON articles (deleted_at);
@@ -0,0 +1,19 @@
+-- The SQLite twin of mysql/0014_page_updated_at.up.sql. SQLite cannot
+-- add a column defaulting to CURRENT_TIMESTAMP, so pages is rebuilt.
+
+CREATE TABLE pages_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL COLLATE NOCASE UNIQUE,
+    title TEXT NOT NULL COLLATE NOCASE,
+    html TEXT NOT NULL,
+    nav_order INTEGER NOT NULL DEFAULT 0,
+    is_public BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO pages_new (id, slug, title, html, nav_order, is_public)
+SELECT id, slug, title, html, nav_order, is_public FROM pages;
+
+DROP TABLE pages;
+
+ALTER TABLE pages_new RENAME TO pages;
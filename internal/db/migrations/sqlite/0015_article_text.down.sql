@@ -0,0 +1,28 @@
+DROP TRIGGER articles_fts_update;
+DROP TRIGGER articles_fts_delete;
+DROP TRIGGER articles_fts_insert;
+DROP TABLE articles_fts;
+
+ALTER TABLE articles DROP COLUMN text;
+
+CREATE VIRTUAL TABLE articles_fts USING fts5(
+    title,
+    html,
+    content = 'articles',
+    content_rowid = 'id'
+);
+
+CREATE TRIGGER articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, html) VALUES (new.id, new.title, new.html);
+END;
+
+CREATE TRIGGER articles_fts_delete AFTER DELETE ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, html) VALUES ('delete', old.id, old.title, old.html);
+END;
+
+CREATE TRIGGER articles_fts_update AFTER UPDATE OF title, html ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, html) VALUES ('delete', old.id, old.title, old.html);
+    INSERT INTO articles_fts (rowid, title, html) VALUES (new.id, new.title, new.html);
+END;
+
+INSERT INTO articles_fts (articles_fts) VALUES ('rebuild');
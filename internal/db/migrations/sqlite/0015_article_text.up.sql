@@ -0,0 +1,31 @@
+-- The SQLite twin of mysql/0015_article_text.up.sql: articles_fts is
+-- recreated over title and text.
+
+DROP TRIGGER articles_fts_update;
+DROP TRIGGER articles_fts_delete;
+DROP TRIGGER articles_fts_insert;
+DROP TABLE articles_fts;
+
+ALTER TABLE articles ADD COLUMN text TEXT NOT NULL DEFAULT '';
+
+CREATE VIRTUAL TABLE articles_fts USING fts5(
+    title,
+    text,
+    content = 'articles',
+    content_rowid = 'id'
+);
+
+CREATE TRIGGER articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, text) VALUES (new.id, new.title, new.text);
+END;
+
+CREATE TRIGGER articles_fts_delete AFTER DELETE ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, text) VALUES ('delete', old.id, old.title, old.text);
+END;
+
+CREATE TRIGGER articles_fts_update AFTER UPDATE OF title, text ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, text) VALUES ('delete', old.id, old.title, old.text);
+    INSERT INTO articles_fts (rowid, title, text) VALUES (new.id, new.title, new.text);
+END;
+
+INSERT INTO articles_fts (articles_fts) VALUES ('rebuild');
@@ -0,0 +1,5 @@
+-- The SQLite twin of mysql/0016_article_pinned_home.up.sql.
+
+ALTER TABLE articles ADD COLUMN pinned_home BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE articles SET pinned_home = pinned;
@@ -0,0 +1,5 @@
+ALTER TABLE articles ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE articles SET pinned = pinned_subject;
+
+ALTER TABLE articles DROP COLUMN pinned_subject;
@@ -0,0 +1,7 @@
+-- The SQLite twin of mysql/0017_article_pinned_subject.up.sql.
+
+ALTER TABLE articles ADD COLUMN pinned_subject BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE articles SET pinned_subject = pinned;
+
+ALTER TABLE articles DROP COLUMN pinned;
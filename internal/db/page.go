@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"blog/internal/model"
+)
+
+type PageRepo struct {
+	DB      *sql.DB
+	Timeout time.Duration // per query, see bound
+}
+
+func (r *PageRepo) ListAll(ctx context.Context) ([]model.Page, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, slug, title, html, nav_order, is_public, updated_at
+		FROM pages
+		ORDER BY nav_order ASC, title ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pages []model.Page
+
+	for rows.Next() {
+		var p model.Page
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title, &p.HTML, &p.NavOrder, &p.IsPublic, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+func (r *PageRepo) GetBySlug(ctx context.Context, slug string) (model.Page, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	var p model.Page
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, slug, title, html, nav_order, is_public, updated_at
+		FROM pages
+		WHERE slug = ?
+	`, slug).Scan(&p.ID, &p.Slug, &p.Title, &p.HTML, &p.NavOrder, &p.IsPublic, &p.UpdatedAt)
+
+	return p, err
+}
+
+// ExistsBySlug reports whether another page than id uses slug.
+func (r *PageRepo) ExistsBySlug(ctx context.Context, slug string, id int64) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	var found int64
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id
+		FROM pages
+		WHERE slug = ? AND id <> ?
+		LIMIT 1
+	`, slug, id).Scan(&found)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PageRepo) Create(ctx context.Context, p model.Page) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO pages (slug, title, html, nav_order, is_public)
+		VALUES (?, ?, ?, ?, ?)
+	`, p.Slug, p.Title, p.HTML, p.NavOrder, p.IsPublic)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+func (r *PageRepo) Update(ctx context.Context, p model.Page) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE pages
+		SET slug = ?, title = ?, html = ?, nav_order = ?, is_public = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, p.Slug, p.Title, p.HTML, p.NavOrder, p.IsPublic, p.ID)
+
+	return err
+}
+
+func (r *PageRepo) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx,
+		`DELETE FROM pages WHERE id = ?`,
+		id,
+	)
+	return err
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"blog/internal/model"
+)
+
+// The stores are what the admin and the generator need from storage.
+// The *Repo types implement them over SQL; memdb implements them in
+// memory. Lookups of a missing row fail with sql.ErrNoRows, and updates
+// of a missing row do nothing, whatever the implementation. Article
+// lookups skip trashed articles: only ListTrash and the title uniqueness
+// see them. Every method takes the context of the request or build it
+// serves, so a canceled one stops its queries.
+
+type ArticleStore interface {
+	ListAll(ctx context.Context) ([]model.Article, error)
+	ListIDAndTitle(ctx context.Context) ([]ArticleTitle, error)
+	ListByTranslationGroup(ctx context.Context, group int64) ([]model.Article, error)
+	ListDue(ctx context.Context, now time.Time) ([]model.Article, error)
+
+	GetByID(ctx context.Context, id int64) (model.Article, error)
+	GetByTitleURL(ctx context.Context, titleURL string) (model.Article, error)
+	GetHTMLByID(ctx context.Context, id int64) (string, error)
+	GetDefaultSubjectID(ctx context.Context) (int64, error)
+
+	ExistsByTitle(ctx context.Context, title string, id int64) (bool, error)
+	ExistsByTitleRaw(ctx context.Context, title string) (bool, error)
+
+	// Create and Update record the saved article as a revision by actor.
+	Create(ctx context.Context, a model.Article, actor string) (int64, error)
+	Update(ctx context.Context, a model.Article, actor string) error
+	SetTranslationGroup(ctx context.Context, id, group int64) error
+	SetPinnedHome(ctx context.Context, id int64, pinned bool) error
+	SetPinnedSubject(ctx context.Context, id int64, pinned bool) error
+	SetFeatured(ctx context.Context, id int64, featured bool) error
+	Publish(ctx context.Context, id int64, actor string) error
+	ReslugAll(ctx context.Context) error
+
+	// Trash hides an article from every read but ListTrash until Untrash
+	// brings it back or a purge deletes it with its revisions. Purges
+	// leave live articles alone.
+	Trash(ctx context.Context, id int64, now time.Time) error
+	Untrash(ctx context.Context, id int64) error
+	ListTrash(ctx context.Context) ([]model.Article, error)
+	Purge(ctx context.Context, id int64) error
+	PurgeTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// Search returns up to limit live articles matching every word of
+	// query, in title or body, as a word or the start of one, best
+	// matches first.
+	Search(ctx context.Context, query string, limit int) ([]model.Article, error)
+
+	// ListRevisions returns the revisions of an article, latest first.
+	ListRevisions(ctx context.Context, articleID int64) ([]model.Revision, error)
+	GetRevision(ctx context.Context, id int64) (model.Revision, error)
+}
+
+type SubjectStore interface {
+	ListAll(ctx context.Context) ([]model.Subject, error)
+	ListIDAndTitle(ctx context.Context) ([]SubjectTitle, error)
+
+	GetByID(ctx context.Context, id int64) (model.Subject, error)
+	GetSlugByID(ctx context.Context, id int64) (string, error)
+	GetIDBySlug(ctx context.Context, slug string) (int64, error)
+
+	ExistsByName(ctx context.Context, name string, id int64) (bool, error)
+	ExistsByNameRaw(ctx context.Context, name string) (bool, error)
+
+	Create(ctx context.Context, title string, parentID int64) (int64, error)
+	Update(ctx context.Context, s model.Subject) error
+	SetParent(ctx context.Context, id, parentID int64) error
+
+	// Delete fails while live articles use the subject. Trashed ones
+	// move to the default subject, where a restore finds them.
+	Delete(ctx context.Context, id int64) error
+}
+
+type AuthorStore interface {
+	ListAll(ctx context.Context) ([]model.Author, error)
+
+	GetByID(ctx context.Context, id int64) (model.Author, error)
+	GetBySlug(ctx context.Context, slug string) (model.Author, error)
+	GetDefaultID(ctx context.Context) (int64, error)
+
+	ExistsByName(ctx context.Context, name string, id int64) (bool, error)
+	CountArticles(ctx context.Context, id int64) (int, error)
+
+	Create(ctx context.Context, a model.Author) (int64, error)
+	Update(ctx context.Context, a model.Author) error
+
+	// Delete fails while articles use the author.
+	Delete(ctx context.Context, id int64) error
+}
+
+type PageStore interface {
+	ListAll(ctx context.Context) ([]model.Page, error)
+	GetBySlug(ctx context.Context, slug string) (model.Page, error)
+	ExistsBySlug(ctx context.Context, slug string, id int64) (bool, error)
+
+	Create(ctx context.Context, p model.Page) (int64, error)
+	Update(ctx context.Context, p model.Page) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// AuditStore keeps the audit log, which only grows: nothing edits or
+// deletes an entry.
+type AuditStore interface {
+	Record(ctx context.Context, e model.AuditEntry) error
+
+	// List returns the entries selected by f, latest first.
+	List(ctx context.Context, f AuditFilter) ([]model.AuditEntry, error)
+}
+
+// Repos bundles one store of each kind.
+type Repos struct {
+	Articles ArticleStore
+	Subjects SubjectStore
+	Authors  AuthorStore
+	Pages    PageStore
+	Audit    AuditStore
+}
+
+// NewRepos returns the SQL stores over conn, a database opened with cfg,
+// whose QueryTimeout bounds each of their queries.
+func NewRepos(conn *sql.DB, cfg Config) Repos {
+	return Repos{
+		Articles: &ArticleRepo{DB: conn, Driver: cfg.Driver, Timeout: cfg.QueryTimeout},
+		Subjects: &SubjectRepo{DB: conn, Timeout: cfg.QueryTimeout},
+		Authors:  &AuthorRepo{DB: conn, Timeout: cfg.QueryTimeout},
+		Pages:    &PageRepo{DB: conn, Timeout: cfg.QueryTimeout},
+		Audit:    &AuditRepo{DB: conn, Timeout: cfg.QueryTimeout},
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"blog/internal/model"
+)
+
+// recordRevision copies the stored state of article id into
+// article_revisions, so the revision matches what was written.
+func recordRevision(ctx context.Context, tx *sql.Tx, id int64, actor string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO article_revisions (article_id, title, subject_id, is_public, html, actor, created_at)
+		SELECT id, title, subject_id, is_public, html, ?, updated_at
+		FROM articles
+		WHERE id = ?
+	`, actor, id)
+	return err
+}
+
+// ListRevisions returns the revisions of an article, latest first.
+func (r *ArticleRepo) ListRevisions(ctx context.Context, articleID int64) ([]model.Revision, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, article_id, title, subject_id, is_public, html, actor, created_at
+		FROM article_revisions
+		WHERE article_id = ?
+		ORDER BY id DESC
+	`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []model.Revision
+
+	for rows.Next() {
+		var rev model.Revision
+		if err := rows.Scan(
+			&rev.ID,
+			&rev.ArticleID,
+			&rev.Title,
+			&rev.SubjectId,
+			&rev.IsPublic,
+			&rev.HTML,
+			&rev.Actor,
+			&rev.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *ArticleRepo) GetRevision(ctx context.Context, id int64) (model.Revision, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	var rev model.Revision
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, article_id, title, subject_id, is_public, html, actor, created_at
+		FROM article_revisions
+		WHERE id = ?
+	`, id).Scan(
+		&rev.ID,
+		&rev.ArticleID,
+		&rev.Title,
+		&rev.SubjectId,
+		&rev.IsPublic,
+		&rev.HTML,
+		&rev.Actor,
+		&rev.CreatedAt,
+	)
+
+	return rev, err
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blog/internal/model"
+)
+
+// minTokenSize is MySQL's default innodb_ft_min_token_size: shorter words
+// are left out of its FULLTEXT indexes.
+const minTokenSize = 3
+
+// SearchTerms splits a search query into its lowercased words, dropping
+// punctuation and repeats, so no term can carry full-text operators.
+func SearchTerms(query string) []string {
+	var terms []string
+
+	seen := make(map[string]bool)
+
+	for _, f := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+
+	return terms
+}
+
+// Search matches every term as a prefix of a word in the title or the plain
+// text of the body, against the FULLTEXT index of migration 0015 on MySQL
+// and its FTS5 table on SQLite. MySQL does not index words shorter than
+// innodb_ft_min_token_size (3 by default), so shorter terms fall back to
+// LIKE there.
+func (r *ArticleRepo) Search(ctx context.Context, query string, limit int) ([]model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var (
+		stmt string
+		args []any
+	)
+
+	switch r.Driver {
+	case SQLite:
+		for i, t := range terms {
+			terms[i] = `"` + t + `"*`
+		}
+		args = []any{strings.Join(terms, " "), limit}
+
+		// a title hit weighs as much as ten in the body
+		stmt = `
+			SELECT a.id, a.title, a.title_url, a.subject_id, a.author_id, a.lang, a.translation_group, a.is_public, a.pinned_home, a.pinned_subject, a.featured, a.html, a.created_at, a.updated_at, a.publish_at
+			FROM articles_fts
+			JOIN articles a ON a.id = articles_fts.rowid
+			WHERE articles_fts MATCH ? AND a.deleted_at IS NULL
+			ORDER BY bm25(articles_fts, 10.0, 1.0), a.id DESC
+			LIMIT ?
+		`
+	default:
+		var (
+			long  []string
+			where []string
+		)
+
+		for _, t := range terms {
+			if utf8.RuneCountInString(t) < minTokenSize {
+				where = append(where, "CONCAT(' ', a.title, ' ', a.text) LIKE ?")
+				args = append(args, "% "+t+"%")
+			} else {
+				long = append(long, "+"+t+"*")
+			}
+		}
+
+		order := "a.id DESC"
+
+		if len(long) > 0 {
+			match := strings.Join(long, " ")
+			where = append(where, "MATCH (a.title, a.text) AGAINST (? IN BOOLEAN MODE)")
+			args = append(args, match, match)
+			order = "MATCH (a.title, a.text) AGAINST (? IN BOOLEAN MODE) DESC, " + order
+		}
+		args = append(args, limit)
+
+		stmt = `
+			SELECT a.id, a.title, a.title_url, a.subject_id, a.author_id, a.lang, a.translation_group, a.is_public, a.pinned_home, a.pinned_subject, a.featured, a.html, a.created_at, a.updated_at, a.publish_at
+			FROM articles a
+			WHERE ` + strings.Join(where, " AND ") + ` AND a.deleted_at IS NULL
+			ORDER BY ` + order + `
+			LIMIT ?
+		`
+	}
+
+	rows, err := r.DB.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []model.Article
+
+	for rows.Next() {
+		var a model.Article
+		if err := rows.Scan(
+			&a.ID,
+			&a.Title,
+			&a.TitleURL,
+			&a.SubjectId,
+			&a.AuthorId,
+			&a.Lang,
+			&a.TranslationGroup,
+			&a.IsPublic,
+			&a.PinnedHome,
+			&a.PinnedSubject,
+			&a.Featured,
+			&a.HTML,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+			&a.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, rows.Err()
+}
@@ -0,0 +1,558 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func openSQLite(t *testing.T) *Migrator {
+	t.Helper()
+
+	conn, err := Open(Config{Driver: SQLite, Path: filepath.Join(t.TempDir(), "statix.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	m := &Migrator{DB: conn, Driver: SQLite}
+	if _, err := m.Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestSQLiteRepos(t *testing.T) {
+	ctx := context.Background()
+
+	m := openSQLite(t)
+
+	subjects := SubjectRepo{DB: m.DB}
+	articles := ArticleRepo{DB: m.DB}
+	authors := AuthorRepo{DB: m.DB}
+
+	subjectID, err := subjects.Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorID, err := authors.GetDefaultID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().Add(-time.Minute)
+	id, err := articles.Create(ctx, model.Article{
+		Title:      "Hello",
+		SubjectId:  subjectID,
+		AuthorId:   authorID,
+		Lang:       model.DefaultLang,
+		HTML:       "<p>hi</p>",
+		PinnedHome: true,
+		PublishAt:  &publishAt,
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := articles.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.TitleURL != "hello" || !a.PinnedHome || a.PinnedSubject || a.IsPublic || a.CreatedAt.IsZero() || a.PublishAt == nil {
+		t.Errorf("article read back as %+v", a)
+	}
+
+	due, err := articles.ListDue(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != id {
+		t.Errorf("due articles: %+v", due)
+	}
+
+	// handleDeleteSubject relies on fk_articles_subject refusing this
+	if err := subjects.Delete(ctx, subjectID); err == nil {
+		t.Fatal("deleted a subject that still has articles")
+	}
+
+	if exists, err := articles.ExistsByTitleRaw(ctx, "HELLO"); err != nil || !exists {
+		t.Errorf("title lookup is not case-insensitive: %v, %v", exists, err)
+	}
+
+	if err := articles.Trash(ctx, id, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := articles.Purge(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := subjects.Delete(ctx, subjectID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSQLiteArticleListAll reads an article with every column set back
+// through ListAll, which scans its own column list.
+func TestSQLiteArticleListAll(t *testing.T) {
+	ctx := context.Background()
+
+	m := openSQLite(t)
+
+	articles := ArticleRepo{DB: m.DB}
+
+	subjectID, err := (&SubjectRepo{DB: m.DB}).Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorID, err := (&AuthorRepo{DB: m.DB}).GetDefaultID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	id, err := articles.Create(ctx, model.Article{
+		Title:         "Round Trip",
+		SubjectId:     subjectID,
+		AuthorId:      authorID,
+		Lang:          "fr",
+		IsPublic:      true,
+		PinnedHome:    true,
+		PinnedSubject: true,
+		Featured:      true,
+		HTML:          "<p>body</p>",
+		PublishAt:     &publishAt,
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := articles.SetTranslationGroup(ctx, id, id); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := articles.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := articles.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("ListAll returned %d articles, want 1", len(list))
+	}
+
+	got := list[0]
+	if got.ID != id || got.Title != "Round Trip" || got.TitleURL != "round-trip" ||
+		got.SubjectId != subjectID || got.AuthorId != authorID || got.Lang != "fr" ||
+		got.TranslationGroup != id || !got.IsPublic || !got.PinnedHome || !got.PinnedSubject || !got.Featured ||
+		got.HTML != "<p>body</p>" {
+		t.Errorf("ListAll read back %+v", got)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || got.UpdatedAt.IsZero() {
+		t.Errorf("ListAll dates: created %v, updated %v, want %v, %v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+	if got.PublishAt == nil || !got.PublishAt.Equal(publishAt) {
+		t.Errorf("ListAll publish_at = %v, want %v", got.PublishAt, publishAt)
+	}
+}
+
+// TestSQLiteListDueOffsets schedules articles in other time zones than
+// the clock the scheduler reads: due is decided on the instant, not on
+// the wall clock stored with it.
+func TestSQLiteListDueOffsets(t *testing.T) {
+	ctx := context.Background()
+
+	m := openSQLite(t)
+
+	articles := ArticleRepo{DB: m.DB}
+
+	subjectID, err := (&SubjectRepo{DB: m.DB}).Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	east := time.FixedZone("east", 5*3600)
+	west := time.FixedZone("west", -8*3600)
+	now := time.Now()
+
+	past := now.Add(-time.Hour).In(east)
+	future := now.Add(time.Hour).In(west)
+
+	var dueID int64
+	for i, publishAt := range []*time.Time{&past, &future} {
+		id, err := articles.Create(ctx, model.Article{
+			Title:     []string{"Past", "Future"}[i],
+			SubjectId: subjectID,
+			AuthorId:  1,
+			Lang:      model.DefaultLang,
+			PublishAt: publishAt,
+		}, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			dueID = id
+		}
+	}
+
+	for _, clock := range []time.Time{now.In(east), now.In(west), now.UTC()} {
+		due, err := articles.ListDue(ctx, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 1 || due[0].ID != dueID {
+			t.Errorf("ListDue(%v) = %+v, want only article %d", clock, due, dueID)
+		}
+	}
+
+	got, err := articles.GetByID(ctx, dueID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PublishAt == nil || !got.PublishAt.Equal(past) {
+		t.Errorf("publish_at read back as %v, want %v", got.PublishAt, past)
+	}
+}
+
+// TestSQLiteReslugAllOneConn reslugs through a pool of one connection,
+// which the transaction must not wait for.
+func TestSQLiteReslugAllOneConn(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := Open(Config{Driver: SQLite, Path: filepath.Join(t.TempDir(), "statix.db"), MaxOpenConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := (&Migrator{DB: conn, Driver: SQLite}).Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	articles := ArticleRepo{DB: conn, Timeout: 5 * time.Second}
+
+	id, err := articles.Create(ctx, model.Article{Title: "Hello World", SubjectId: 1, AuthorId: 1, Lang: model.DefaultLang}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`UPDATE articles SET title_url = 'stale' WHERE id = ?`, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := articles.ReslugAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := articles.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.TitleURL != "hello-world" {
+		t.Errorf("slug after ReslugAll = %q", a.TitleURL)
+	}
+}
+
+func TestSQLiteContext(t *testing.T) {
+	conn, err := Open(Config{
+		Driver:       SQLite,
+		Path:         filepath.Join(t.TempDir(), "statix.db"),
+		MaxOpenConns: 3,
+		QueryTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if n := conn.Stats().MaxOpenConnections; n != 3 {
+		t.Errorf("pool allows %d connections, want 3", n)
+	}
+
+	if _, err := (&Migrator{DB: conn, Driver: SQLite}).Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := NewRepos(conn, Config{Driver: SQLite, QueryTimeout: time.Minute})
+
+	if _, err := repos.Articles.ListAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := repos.Articles.ListAll(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListAll with a canceled context = %v, want context.Canceled", err)
+	}
+	if _, err := repos.Subjects.Create(ctx, "Go", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create with a canceled context = %v, want context.Canceled", err)
+	}
+
+	bounded, cancel := bound(context.Background(), time.Minute)
+	defer cancel()
+	if _, ok := bounded.Deadline(); !ok {
+		t.Error("QueryTimeout sets no deadline")
+	}
+
+	unbounded, cancel := bound(context.Background(), 0)
+	defer cancel()
+	if _, ok := unbounded.Deadline(); ok {
+		t.Error("zero QueryTimeout sets a deadline")
+	}
+}
+
+func TestSQLiteMigrateDown(t *testing.T) {
+	m := openSQLite(t)
+
+	migrations, err := Migrations(SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Down(len(migrations), false); err != nil {
+		t.Fatal(err)
+	}
+
+	version, pending, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 || len(pending) != len(migrations) {
+		t.Errorf("after reverting everything: version %d, %d pending", version, len(pending))
+	}
+
+	if _, err := m.DB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future')`, len(migrations)+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Status(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("newer schema accepted: %v", err)
+	}
+}
+
+// TestSQLiteMigrateLegacy upgrades a database holding the schema and rows
+// of the original database.sql.
+func TestSQLiteMigrateLegacy(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := Open(Config{Driver: SQLite, Path: filepath.Join(t.TempDir(), "statix.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m := &Migrator{DB: conn, Driver: SQLite}
+	if _, err := m.Up(1, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range []string{
+		`UPDATE author SET html = '<p>about me</p>' WHERE id = 0`,
+		`INSERT INTO articles (title, title_url, subject_id, is_public, html, created_at)
+		VALUES ('Old', 'old', 1, TRUE, '<p>old</p>', '2020-01-02 03:04:05')`,
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := (&PageRepo{DB: conn}).GetBySlug(ctx, "author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.HTML != "<p>about me</p>" {
+		t.Errorf("author page holds %q", page.HTML)
+	}
+
+	articles, err := (&ArticleRepo{DB: conn}).ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("%d articles after migrating, want 1", len(articles))
+	}
+	a := articles[0]
+	if a.Title != "Old" || a.AuthorId != 1 || a.Lang != model.DefaultLang || !a.UpdatedAt.Equal(a.CreatedAt) {
+		t.Errorf("article migrated to %+v", a)
+	}
+
+	if found, err := (&ArticleRepo{DB: conn, Driver: SQLite}).Search(ctx, "old", 10); err != nil || len(found) != 1 {
+		t.Errorf("search after migrating: %+v, %v", found, err)
+	}
+
+	if _, err := (&SubjectRepo{DB: conn}).Create(ctx, "Go", 1); err != nil {
+		t.Errorf("nesting a subject after migrating: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE articles SET author_id = 99`); err == nil {
+		t.Error("foreign keys are off after migrating")
+	}
+}
+
+func TestSQLiteSearch(t *testing.T) {
+	ctx := context.Background()
+
+	m := openSQLite(t)
+
+	subjects := SubjectRepo{DB: m.DB}
+	articles := ArticleRepo{DB: m.DB, Driver: SQLite}
+
+	subjectID, err := subjects.Create(ctx, "Go", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := articles.Create(ctx, model.Article{
+		Title:     "Hello",
+		SubjectId: subjectID,
+		AuthorId:  1,
+		Lang:      model.DefaultLang,
+		HTML:      `<p class="lead">Go is fun</p>`,
+	}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for q, want := range map[string]int{"go fun": 1, "class": 0, "lead": 0} {
+		found, err := articles.Search(ctx, q, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != want {
+			t.Errorf("Search(%q) found %d articles, want %d", q, len(found), want)
+		}
+	}
+
+	if err := articles.Update(ctx, model.Article{
+		ID:        id,
+		Title:     "Hello",
+		SubjectId: subjectID,
+		AuthorId:  1,
+		Lang:      model.DefaultLang,
+		HTML:      "<p>Rust is fun</p>",
+	}, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, _ := articles.Search(ctx, "go", 10); len(found) != 0 {
+		t.Errorf("search still matches the old text: %+v", found)
+	}
+	if found, _ := articles.Search(ctx, "rust", 10); len(found) != 1 {
+		t.Errorf("search misses the new text: %+v", found)
+	}
+}
+
+// TestSQLiteMigratePinned splits the pinned flag of an article into
+// pinned_home and pinned_subject, and merges them back down.
+func TestSQLiteMigratePinned(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := Open(Config{Driver: SQLite, Path: filepath.Join(t.TempDir(), "statix.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	m := &Migrator{DB: conn, Driver: SQLite}
+	if _, err := m.Up(15, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Exec(`
+		INSERT INTO articles (title, title_url, subject_id, author_id, is_public, pinned, html, created_at)
+		VALUES ('Pinned', 'pinned', 1, 1, TRUE, TRUE, '<p>pinned</p>', CURRENT_TIMESTAMP)
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(0, false); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := (&ArticleRepo{DB: conn}).GetByTitleURL(ctx, "pinned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.PinnedHome || !a.PinnedSubject {
+		t.Errorf("pinned article migrated to %+v", a)
+	}
+
+	if err := (&ArticleRepo{DB: conn}).SetPinnedSubject(ctx, a.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Down(2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var pinned bool
+	if err := conn.QueryRow(`SELECT pinned FROM articles WHERE id = ?`, a.ID).Scan(&pinned); err != nil {
+		t.Fatal(err)
+	}
+	if !pinned {
+		t.Error("pinned_home was lost reverting the split")
+	}
+}
+
+func TestSQLiteAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	audit := AuditRepo{DB: openSQLite(t).DB}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []model.AuditEntry{
+		{CreatedAt: start, Actor: "admin", Auth: "session", Method: "POST", Route: "/admin/articles/1", Status: 303, Target: "article 1"},
+		{CreatedAt: start.Add(time.Hour), Actor: "stx", Auth: "token", Method: "POST", Route: "/admin/articles/12", Status: 200, Target: "article 12", Summary: "title: A → B"},
+		{CreatedAt: start.Add(2 * time.Hour), Actor: "stx", Auth: "token", Method: "POST", Route: "/admin/dry_run", Status: 200},
+	}
+	for _, e := range entries {
+		if err := audit.Record(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		filter AuditFilter
+		want   []string
+	}{
+		{AuditFilter{}, []string{"/admin/dry_run", "/admin/articles/12", "/admin/articles/1"}},
+		{AuditFilter{Limit: 1}, []string{"/admin/dry_run"}},
+		{AuditFilter{Actor: "stx"}, []string{"/admin/dry_run", "/admin/articles/12"}},
+		{AuditFilter{Target: "article"}, []string{"/admin/articles/12", "/admin/articles/1"}},
+		{AuditFilter{Target: "article 1"}, []string{"/admin/articles/1"}},
+		// _ is no wildcard
+		{AuditFilter{Route: "/admin/dry_"}, []string{"/admin/dry_run"}},
+		{AuditFilter{Route: "/admin/dry%"}, nil},
+		{AuditFilter{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)}, []string{"/admin/articles/12"}},
+	} {
+		got, err := audit.List(ctx, tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var routes []string
+		for _, e := range got {
+			routes = append(routes, e.Route)
+			if !tc.filter.Match(e) {
+				t.Errorf("%+v: Match disagrees on %+v", tc.filter, e)
+			}
+		}
+		if strings.Join(routes, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("%+v: got %v, want %v", tc.filter, routes, tc.want)
+		}
+	}
+
+	got, err := audit.List(ctx, AuditFilter{Target: "article 12"})
+	if err != nil || len(got) != 1 || got[0].Summary != "title: A → B" || !got[0].CreatedAt.Equal(entries[1].CreatedAt) {
+		t.Errorf("entry read back: %+v, %v", got, err)
+	}
+}
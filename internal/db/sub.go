@@ -1,33 +1,87 @@
 package db
 
 import (
+	"context"
 	"database/sql"
     "errors"
+	"time"
 
 	"blog/internal/model"
     "blog/internal/utils"
 )
 
+// DefaultSubjectSlug is the slug of the subject that cannot be deleted,
+// where articles go when no other subject holds them.
+const DefaultSubjectSlug = "default"
+
 type SubjectRepo struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration // per query, see bound
 }
 
 
-func (r *SubjectRepo) Delete(id int64) error {
-	_, err := r.DB.Exec(
+// Delete removes a subject and moves its children up to its own parent,
+// and its trashed articles to the default subject. It fails, leaving
+// everything in place, while live articles still use it.
+func (r *SubjectRepo) Delete(ctx context.Context, id int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parent sql.NullInt64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT parent_id FROM subjects WHERE id = ?`,
+		id,
+	).Scan(&parent); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE subjects SET parent_id = ? WHERE parent_id = ?`,
+		parent, id,
+	); err != nil {
+		return err
+	}
+
+	// only live articles keep the subject
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE articles
+		SET subject_id = (SELECT id FROM subjects WHERE slug = ?)
+		WHERE subject_id = ? AND deleted_at IS NOT NULL
+	`, DefaultSubjectSlug, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
 		`DELETE FROM subjects WHERE id = ?`,
 		id,
-	)
-	return err
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// nullParent stores the top level (0) as NULL.
+func nullParent(parentID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: parentID, Valid: parentID != 0}
 }
 
-func (r *SubjectRepo) Create(title string) (int64, error) {
+func (r *SubjectRepo) Create(ctx context.Context, title string, parentID int64) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	slug := utils.Slugify(title)
 
-	res, err := r.DB.Exec(`
-		INSERT INTO subjects (title, slug)
-		VALUES (?, ?)
-	`, title, slug)
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO subjects (title, slug, parent_id, description)
+		VALUES (?, ?, ?, '')
+	`, title, slug, nullParent(parentID))
 
 	if err != nil {
 		return 0, err
@@ -36,11 +90,14 @@ func (r *SubjectRepo) Create(title string) (int64, error) {
 	return res.LastInsertId()
 }
 
-func (r *SubjectRepo) ListAll() ([]model.Subject, error) {
-	rows, err := r.DB.Query(`
-		SELECT id, title, slug
+func (r *SubjectRepo) ListAll(ctx context.Context) ([]model.Subject, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, slug, parent_id, description, cover, position
 		FROM subjects
-		ORDER BY title ASC
+		ORDER BY position ASC, title ASC
 	`)
 	if err != nil {
 		return nil, err
@@ -51,9 +108,12 @@ func (r *SubjectRepo) ListAll() ([]model.Subject, error) {
 
 	for rows.Next() {
 		var s model.Subject
-		if err := rows.Scan(&s.Id, &s.Title, &s.Slug); err != nil {
+		var parent sql.NullInt64
+		if err := rows.Scan(&s.Id, &s.Title, &s.Slug, &parent,
+			&s.Description, &s.Cover, &s.Position); err != nil {
 			return nil, err
 		}
+		s.ParentId = parent.Int64
 		subjects = append(subjects, s)
 	}
 
@@ -64,36 +124,62 @@ func (r *SubjectRepo) ListAll() ([]model.Subject, error) {
 	return subjects, nil
 }
 
-func (r *SubjectRepo) Update(id int64, title string) error {
-	_, err := r.DB.Exec(`
+func (r *SubjectRepo) Update(ctx context.Context, s model.Subject) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE subjects
+		SET title = ?, slug = ?, description = ?, cover = ?, position = ?
+		WHERE id = ?
+	`, s.Title, utils.Slugify(s.Title), s.Description, s.Cover, s.Position, s.Id)
+
+	return err
+}
+
+// SetParent moves a subject under parentID, or to the top level when
+// parentID is 0.
+func (r *SubjectRepo) SetParent(ctx context.Context, id, parentID int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE subjects
-		SET title = ?, slug = ?
+		SET parent_id = ?
 		WHERE id = ?
-	`, title, utils.Slugify(title), id)
+	`, nullParent(parentID), id)
 
 	return err
 }
 
-func (r *SubjectRepo) GetByID(id int64) (model.Subject, error) {
+func (r *SubjectRepo) GetByID(ctx context.Context, id int64) (model.Subject, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var s model.Subject
+	var parent sql.NullInt64
 
-	err := r.DB.QueryRow(`
-		SELECT id, title, slug
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, title, slug, parent_id, description, cover, position
 		FROM subjects
 		WHERE id = ?
-	`, id).Scan(&s.Id, &s.Title, &s.Slug)
+	`, id).Scan(&s.Id, &s.Title, &s.Slug, &parent, &s.Description, &s.Cover, &s.Position)
 
 	if err != nil {
 		return model.Subject{}, err
 	}
 
+	s.ParentId = parent.Int64
 	return s, nil
 }
 
-func (r *SubjectRepo) ExistsByName(name string, id int64) (bool, error) {
+func (r *SubjectRepo) ExistsByName(ctx context.Context, name string, id int64) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var exists int
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT 1
 		FROM subjects
 		WHERE slug = ? AND id != ?
@@ -110,10 +196,13 @@ func (r *SubjectRepo) ExistsByName(name string, id int64) (bool, error) {
 	return true, nil
 }
 
-func (r *SubjectRepo) ExistsByNameRaw(name string) (bool, error) {
+func (r *SubjectRepo) ExistsByNameRaw(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var exists int
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT 1
 		FROM subjects
 		WHERE slug = ?
@@ -130,10 +219,13 @@ func (r *SubjectRepo) ExistsByNameRaw(name string) (bool, error) {
 	return true, nil
 }
 
-func (r *SubjectRepo) GetSlugByID(id int64) (string, error) {
+func (r *SubjectRepo) GetSlugByID(ctx context.Context, id int64) (string, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var slug_val string
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT slug
 		FROM subjects
 		WHERE id = ?
@@ -146,40 +238,42 @@ func (r *SubjectRepo) GetSlugByID(id int64) (string, error) {
 	return slug_val, nil
 }
 
-func (r *SubjectRepo) ListIDAndTitle() ([]struct {
-	ID    int64
-	Title string
-}, error) {
+// SubjectTitle is a subject as listed by ListIDAndTitle.
+type SubjectTitle struct {
+	ID       int64
+	Title    string
+	ParentID int64
+}
+
+func (r *SubjectRepo) ListIDAndTitle(ctx context.Context) ([]SubjectTitle, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
 
-	rows, err := r.DB.Query(`
-		SELECT id, title
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, parent_id
 		FROM subjects
-		ORDER BY title ASC
+		ORDER BY position ASC, title ASC
 	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var result []struct {
-		ID    int64
-		Title string
-	}
+	var result []SubjectTitle
 
 	for rows.Next() {
 		var id int64
 		var title string
+		var parent sql.NullInt64
 
-		if err := rows.Scan(&id, &title); err != nil {
+		if err := rows.Scan(&id, &title, &parent); err != nil {
 			return nil, err
 		}
 
-		result = append(result, struct {
-			ID    int64
-			Title string
-		}{
-			ID:    id,
-			Title: title,
+		result = append(result, SubjectTitle{
+			ID:       id,
+			Title:    title,
+			ParentID: parent.Int64,
 		})
 	}
 
@@ -190,10 +284,13 @@ func (r *SubjectRepo) ListIDAndTitle() ([]struct {
 	return result, nil
 }
 
-func (r *SubjectRepo) GetIDBySlug(slug string) (int64, error) {
+func (r *SubjectRepo) GetIDBySlug(ctx context.Context, slug string) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
 	var id int64
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT id
 		FROM subjects
 		WHERE slug = ?
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"blog/internal/model"
+)
+
+// Trash moves an article to the trash at now. Trashed articles are
+// hidden from every read but ListTrash, so the site is built without
+// them, and keep their revisions until purged.
+func (r *ArticleRepo) Trash(ctx context.Context, id int64, now time.Time) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE articles
+		SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, now.UTC(), id)
+	return err
+}
+
+// Untrash puts a trashed article back.
+func (r *ArticleRepo) Untrash(ctx context.Context, id int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE articles
+		SET deleted_at = NULL
+		WHERE id = ?
+	`, id)
+	return err
+}
+
+// ListTrash returns the trashed articles, last trashed first.
+func (r *ArticleRepo) ListTrash(ctx context.Context) ([]model.Article, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, title, title_url, subject_id, author_id, lang, translation_group, is_public, pinned_home, pinned_subject, featured, html, created_at, updated_at, publish_at, deleted_at
+		FROM articles
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []model.Article
+
+	for rows.Next() {
+		var a model.Article
+		if err := rows.Scan(
+			&a.ID,
+			&a.Title,
+			&a.TitleURL,
+			&a.SubjectId,
+			&a.AuthorId,
+			&a.Lang,
+			&a.TranslationGroup,
+			&a.IsPublic,
+			&a.PinnedHome,
+			&a.PinnedSubject,
+			&a.Featured,
+			&a.HTML,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+			&a.PublishAt,
+			&a.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, rows.Err()
+}
+
+// Purge deletes a trashed article for good, with its revisions. Live
+// articles are left alone.
+func (r *ArticleRepo) Purge(ctx context.Context, id int64) error {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM articles
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`, id)
+	return err
+}
+
+// PurgeTrashedBefore purges the articles trashed before cutoff and
+// returns how many there were.
+func (r *ArticleRepo) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, cancel := bound(ctx, r.Timeout)
+	defer cancel()
+
+	res, err := r.DB.ExecContext(ctx, `
+		DELETE FROM articles
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"blog/internal/model"
+)
+
+type AuthorView struct {
+	Author   model.Author
+	Bio      template.HTML
+	Articles []model.ArticleView
+}
+
+// authorPages lists each author's articles at /authors/<slug>.html, with
+// a matching RSS feed at /authors/<slug>.xml.
+func (g *Generator) authorPages() ([]Page, error) {
+	if len(g.Authors) == 0 {
+		return nil, nil
+	}
+
+	tmpl, err := g.template("author")
+	if err != nil {
+		return nil, err
+	}
+
+	views := g.BuildArticleViews()
+
+	pages := make([]Page, 0, 2*len(g.Authors))
+
+	for _, author := range g.Authors {
+		view := AuthorView{
+			Author: author,
+			Bio:    template.HTML(author.Bio),
+		}
+
+		var articles []model.Article
+		for i, a := range g.Articles {
+			if a.AuthorId == author.ID {
+				articles = append(articles, a)
+				view.Articles = append(view.Articles, views[i])
+			}
+		}
+
+		feed, err := rssFeed(
+			fmt.Sprintf("Articles by %s", author.Name),
+			fmt.Sprintf("%s/authors/%s.html", siteURL, author.Slug),
+			fmt.Sprintf("Article publication notifications for %s.", author.Name),
+			articles,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		pages = append(pages,
+			Page{
+				Path:     "authors/" + author.Slug + ".html",
+				Template: tmpl,
+				Name:     "base",
+				Data:     view,
+			},
+			Page{
+				Path: "authors/" + author.Slug + ".xml",
+				Body: feed,
+			},
+		)
+	}
+
+	return pages, nil
+}
+
+// BuildAuthors renders every author's listing page and feed.
+func (g *Generator) BuildAuthors(ctx context.Context) error {
+	return g.BuildPages(ctx, PageProducerFunc((*Generator).authorPages))
+}
+
+// RemoveAuthor deletes the listing page and feed of a deleted or renamed
+// author.
+func (g *Generator) RemoveAuthor(slug string) error {
+	for _, ext := range []string{".html", ".xml"} {
+		err := os.Remove(filepath.Join(g.OutDir, "authors", slug+ext))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestSitemapAuthors(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "dist")
+
+	day := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Old", TitleURL: "old", SubjectId: 1, AuthorId: 1, IsPublic: true, CreatedAt: day.AddDate(0, -1, 0), UpdatedAt: day},
+			{ID: 2, Title: "Newer", TitleURL: "newer", SubjectId: 1, AuthorId: 1, IsPublic: true, CreatedAt: day.AddDate(0, 0, -1)},
+			{ID: 3, Title: "Draft", TitleURL: "draft", SubjectId: 1, AuthorId: 1, CreatedAt: day.AddDate(0, 1, 0)},
+			{ID: 4, Title: "Other Draft", TitleURL: "other-draft", SubjectId: 1, AuthorId: 2, CreatedAt: day},
+		},
+		Authors: []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}, {ID: 2, Name: "Bob", Slug: "bob"}},
+		OutDir:  out,
+	}
+
+	if err := g.BuildSitemap(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sitemap := string(b)
+
+	if !strings.Contains(sitemap, "/authors/ada.html</loc>\n    <lastmod>2025-03-04</lastmod>") {
+		t.Errorf("author not dated by their latest public article:\n%s", sitemap)
+	}
+	if strings.Contains(sitemap, "/authors/bob.html") {
+		t.Errorf("author without public articles listed:\n%s", sitemap)
+	}
+}
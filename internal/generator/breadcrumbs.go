@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"encoding/json"
+	"html/template"
+
+	"blog/internal/model"
+)
+
+type breadcrumbItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item"`
+}
+
+type breadcrumbList struct {
+	Context string           `json:"@context"`
+	Type    string           `json:"@type"`
+	Items   []breadcrumbItem `json:"itemListElement"`
+}
+
+// breadcrumbLD returns the schema.org BreadcrumbList of an article page:
+// the home page, its subject trail, then the article itself.
+func breadcrumbLD(v model.ArticleView) (template.JS, error) {
+	list := breadcrumbList{
+		Context: "https://schema.org",
+		Type:    "BreadcrumbList",
+	}
+
+	add := func(name, path string) {
+		list.Items = append(list.Items, breadcrumbItem{
+			Type:     "ListItem",
+			Position: len(list.Items) + 1,
+			Name:     name,
+			Item:     siteURL + path,
+		})
+	}
+
+	add("Home", "/")
+	for _, s := range v.Breadcrumbs {
+		add(s.Title, "/sub/"+s.Slug+".html")
+	}
+	add(v.Title, v.Path())
+
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	return template.JS(b), nil
+}
@@ -0,0 +1,311 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffOp struct {
+	kind byte // ' ' equal, '-' only in a, '+' only in b
+	line string
+}
+
+// maxEditDistance caps the edit distance diffLines searches for. The
+// trace it keeps grows with the square of the distance: about 8 MB at
+// the cap.
+const maxEditDistance = 1000
+
+// diffLines computes a shortest edit script between a and b with Myers'
+// algorithm. Only the explored window of each round is kept, so memory
+// grows with the square of the edit distance, not the file size. Past
+// maxEditDistance it gives up and reports false, with a script that
+// removes all of a and adds all of b.
+func diffLines(a, b []string) ([]diffOp, bool) {
+	n, m := len(a), len(b)
+	max := min(n+m, maxEditDistance)
+
+	v := make([]int, 2*max+3)
+	off := max + 1
+
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[off-d-1:off+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[off+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace), true
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops, false
+}
+
+func backtrack(a, b []string, trace [][]int) []diffOp {
+	x, y := len(a), len(b)
+
+	var ops []diffOp
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// UnifiedDiff renders the difference between two texts in unified format
+// with context lines around each change. It returns "" for equal texts,
+// and a one line note for texts too far apart to diff.
+func UnifiedDiff(aName, bName, a, b string, context int) string {
+	ops, ok := diffLines(splitLines(a), splitLines(b))
+	if !ok {
+		return fmt.Sprintf("Files %s and %s differ\n", aName, bName)
+	}
+
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	// line numbers (1-based) in a and b at the start of ops[i]
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	aLine[0], bLine[0] = 1, 1
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op.kind != '+' {
+			aLine[i+1]++
+		}
+		if op.kind != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	for c := 0; c < len(changes); {
+		start := max(changes[c]-context, 0)
+		end := changes[c] + 1
+
+		// merge following changes whose context overlaps
+		for c < len(changes) && changes[c]-context <= end+context {
+			end = changes[c] + 1
+			c++
+		}
+		end = min(end+context, len(ops))
+
+		aCount := aLine[end] - aLine[start]
+		bCount := bLine[end] - bLine[start]
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aCount),
+			hunkRange(bLine[start], bCount),
+		)
+
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return out.String()
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// DiffRow is one row of a side-by-side diff. Line numbers are 1-based, 0
+// on the side a row does not have.
+type DiffRow struct {
+	// Kind is "equal", "removed", "added", "changed" (a removed line
+	// facing the added line that replaced it) or "skip", which stands
+	// for unchanged lines left out.
+	Kind string
+
+	LeftLine  int
+	Left      string
+	RightLine int
+	Right     string
+}
+
+// SideBySide lines up two texts for display in two columns. Unchanged
+// runs keep context lines around each change; a negative context keeps
+// them all. It returns nil for equal texts. Texts too far apart to diff
+// show as every line of a replaced by every line of b.
+func SideBySide(a, b string, context int) []DiffRow {
+	ops, _ := diffLines(splitLines(a), splitLines(b))
+
+	var rows []DiffRow
+	changed := false
+	aLine, bLine := 1, 1
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			rows = append(rows, DiffRow{
+				Kind:     "equal",
+				LeftLine: aLine, Left: trimEOL(ops[i].line),
+				RightLine: bLine, Right: trimEOL(ops[i].line),
+			})
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		changed = true
+
+		// pair a run of removals with the additions that follow it
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind == '-'; i++ {
+			removed = append(removed, ops[i].line)
+		}
+		for ; i < len(ops) && ops[i].kind == '+'; i++ {
+			added = append(added, ops[i].line)
+		}
+
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			row := DiffRow{Kind: "changed"}
+			if j < len(removed) {
+				row.LeftLine, row.Left = aLine, trimEOL(removed[j])
+				aLine++
+			} else {
+				row.Kind = "added"
+			}
+			if j < len(added) {
+				row.RightLine, row.Right = bLine, trimEOL(added[j])
+				bLine++
+			} else {
+				row.Kind = "removed"
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	if context < 0 {
+		return rows
+	}
+
+	return collapseEqual(rows, context)
+}
+
+// collapseEqual replaces the equal rows further than context from any
+// change with one skip row per run.
+func collapseEqual(rows []DiffRow, context int) []DiffRow {
+	keep := make([]bool, len(rows))
+	for i, row := range rows {
+		if row.Kind == "equal" {
+			continue
+		}
+		for j := max(i-context, 0); j <= min(i+context, len(rows)-1); j++ {
+			keep[j] = true
+		}
+	}
+
+	var out []DiffRow
+	for i, row := range rows {
+		if keep[i] {
+			out = append(out, row)
+		} else if len(out) == 0 || out[len(out)-1].Kind != "skip" {
+			out = append(out, DiffRow{Kind: "skip"})
+		}
+	}
+	return out
+}
+
+func trimEOL(line string) string {
+	return strings.TrimSuffix(line, "\n")
+}
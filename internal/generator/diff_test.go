@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\ntwo\nthree\nfour\nFIVE\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	want := `--- a
++++ b
+@@ -2,9 +2,10 @@
+ two
+ three
+ four
+-five
++FIVE
+ six
+ seven
+ eight
+ nine
+ ten
++eleven
+`
+
+	if got := UnifiedDiff("a", "b", a, b, 3); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := UnifiedDiff("a", "b", a, a, 3); got != "" {
+		t.Errorf("equal texts: got %q", got)
+	}
+
+	want = "--- /dev/null\n+++ b\n@@ -0,0 +1 @@\n+x\n"
+	if got := UnifiedDiff("/dev/null", "b", "", "x\n", 3); got != want {
+		t.Errorf("added file: got %q, want %q", got, want)
+	}
+}
+
+func TestDiffPastMaxEditDistance(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < maxEditDistance; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+
+	if got, want := UnifiedDiff("a", "b", a.String(), b.String(), 3), "Files a and b differ\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	rows := SideBySide(a.String(), b.String(), 3)
+	if len(rows) != maxEditDistance || rows[0] != (DiffRow{Kind: "changed", LeftLine: 1, Left: "a0", RightLine: 1, Right: "b0"}) {
+		t.Errorf("side by side: %d rows, first %+v", len(rows), rows[0])
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+	b := "one\ntwo\nthree\nFOUR\n4b\nfive\nsix\n"
+
+	want := []DiffRow{
+		{Kind: "skip"},
+		{Kind: "equal", LeftLine: 3, Left: "three", RightLine: 3, Right: "three"},
+		{Kind: "changed", LeftLine: 4, Left: "four", RightLine: 4, Right: "FOUR"},
+		{Kind: "added", RightLine: 5, Right: "4b"},
+		{Kind: "equal", LeftLine: 5, Left: "five", RightLine: 6, Right: "five"},
+		{Kind: "equal", LeftLine: 6, Left: "six", RightLine: 7, Right: "six"},
+		{Kind: "removed", LeftLine: 7, Left: "seven"},
+	}
+
+	got := SideBySide(a, b, 1)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%+v\nwant:\n%+v", got, want)
+	}
+
+	if got := SideBySide(a, b, -1); len(got) != 8 || got[0].Kind != "equal" {
+		t.Errorf("full context: %+v", got)
+	}
+
+	if got := SideBySide(a, a, 3); got != nil {
+		t.Errorf("equal texts: %+v", got)
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "live")
+	dry := filepath.Join(root, "dry")
+
+	writeTestFile(t, filepath.Join(live, "index.html"), "same\n")
+	writeTestFile(t, filepath.Join(dry, "index.html"), "same\n")
+	writeTestFile(t, filepath.Join(live, "articles", "old.html"), "old\n")
+	writeTestFile(t, filepath.Join(live, "rss.xml"), "a\n")
+	writeTestFile(t, filepath.Join(dry, "rss.xml"), "b\n")
+	writeTestFile(t, filepath.Join(dry, "articles", "new.html"), "new\n")
+
+	diff, err := DiffTrees(live, dry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FileChange{
+		{Path: "articles/new.html", Kind: FileAdded},
+		{Path: "articles/old.html", Kind: FileRemoved},
+		{Path: "rss.xml", Kind: FileModified},
+	}
+
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(diff.Changes), len(want), diff.Changes)
+	}
+	for i, c := range diff.Changes {
+		if c.Path != want[i].Path || c.Kind != want[i].Kind {
+			t.Errorf("change %d: got %s %s, want %s %s", i, c.Kind, c.Path, want[i].Kind, want[i].Path)
+		}
+		if c.Diff == "" {
+			t.Errorf("change %d: empty diff", i)
+		}
+	}
+
+	if diff.Unchanged != 1 || diff.Files != 3 {
+		t.Errorf("got %d unchanged of %d files, want 1 of 3", diff.Unchanged, diff.Files)
+	}
+
+	if _, err := DiffTrees(filepath.Join(root, "missing"), dry); err != nil {
+		t.Errorf("missing live dir: %v", err)
+	}
+}
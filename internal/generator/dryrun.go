@@ -0,0 +1,285 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BuildAll runs every step of a full site build: the pages, the built-in
+// and registered producers, the error pages, then the post-build hooks.
+// The site is built into a staging directory next to OutDir, checked
+// when StrictLinks is set, and only then swapped in: a build that fails,
+// is canceled or has broken links leaves the live site as it was.
+func (g *Generator) BuildAll(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(g.OutDir), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(g.OutDir), "."+filepath.Base(g.OutDir)+".build-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	staged, err := g.staged(filepath.Join(tmp, "dist"))
+	if err != nil {
+		return err
+	}
+
+	if err := staged.buildAll(ctx); err != nil {
+		return err
+	}
+
+	if g.StrictLinks {
+		if _, err := staged.VerifyLinks(); err != nil {
+			return err
+		}
+	}
+
+	if err := swapDir(staged.OutDir, g.OutDir, filepath.Join(tmp, "old")); err != nil {
+		return err
+	}
+
+	return g.runHooks()
+}
+
+// buildAll writes a full build into OutDir, without the hooks.
+func (g *Generator) buildAll(ctx context.Context) error {
+	if err := g.Build(ctx); err != nil {
+		return err
+	}
+
+	producers := append(builtinProducers[:len(builtinProducers):len(builtinProducers)], g.Plugins.Producers...)
+	if err := g.BuildPages(ctx, producers...); err != nil {
+		return err
+	}
+
+	return g.BuildErrorPages(ctx)
+}
+
+// staged returns a copy of g building into dir, seeded with the gone
+// markers of OutDir: they are state, not output, and a build into OutDir
+// would find them.
+func (g *Generator) staged(dir string) (*Generator, error) {
+	s := *g
+	s.OutDir = dir
+	s.Articles = append(g.Articles[:0:0], g.Articles...)
+
+	gone, err := g.gonePaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range gone {
+		if err := s.writeGoneMarker(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// swapDir replaces dir with staged, moving the previous dir to old. dir
+// is missing for a moment between the two renames, never half written.
+func swapDir(staged, dir, old string) error {
+	if err := os.Rename(dir, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Rename(staged, dir); err != nil {
+		os.Rename(old, dir)
+		return err
+	}
+
+	return nil
+}
+
+type FileChangeKind string
+
+const (
+	FileAdded    FileChangeKind = "added"
+	FileRemoved  FileChangeKind = "removed"
+	FileModified FileChangeKind = "modified"
+)
+
+type FileChange struct {
+	Path string // relative to OutDir, slash separated
+	Kind FileChangeKind
+	Diff string // unified diff, live file first
+}
+
+type BuildDiff struct {
+	Files     int // files produced by the dry run
+	Unchanged int
+	Changes   []FileChange
+}
+
+func (d *BuildDiff) Count(kind FileChangeKind) int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *BuildDiff) WriteText(w io.Writer) error {
+	for _, c := range d.Changes {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", c.Kind, c.Path); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range d.Changes {
+		if _, err := io.WriteString(w, c.Diff); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w,
+		"%d files: %d added, %d removed, %d modified, %d unchanged\n",
+		d.Files,
+		d.Count(FileAdded), d.Count(FileRemoved), d.Count(FileModified),
+		d.Unchanged,
+	)
+	return err
+}
+
+const diffContext = 3
+
+// DryRun performs a full build into a temporary directory and compares
+// the result with OutDir, which is left untouched. The nginx snippet is
+// not written and the post-build hooks do not run.
+func (g *Generator) DryRun(ctx context.Context) (*BuildDiff, error) {
+	tmp, err := os.MkdirTemp("", "statix-dry-run-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	dry, err := g.staged(filepath.Join(tmp, "dist"))
+	if err != nil {
+		return nil, err
+	}
+	dry.NginxConf = ""
+
+	if err := dry.buildAll(ctx); err != nil {
+		return nil, err
+	}
+
+	return DiffTrees(g.OutDir, dry.OutDir)
+}
+
+// DiffTrees compares every regular file under oldDir and newDir. A
+// missing oldDir counts as empty.
+func DiffTrees(oldDir, newDir string) (*BuildDiff, error) {
+	oldFiles, err := listFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+
+	newFiles, err := listFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &BuildDiff{Files: len(newFiles)}
+
+	for rel := range oldFiles {
+		if !newFiles[rel] {
+			a, err := os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(rel)))
+			if err != nil {
+				return nil, err
+			}
+
+			diff.Changes = append(diff.Changes, FileChange{
+				Path: rel,
+				Kind: FileRemoved,
+				Diff: fileDiff("a/"+rel, "/dev/null", a, nil),
+			})
+		}
+	}
+
+	for rel := range newFiles {
+		b, err := os.ReadFile(filepath.Join(newDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+
+		if !oldFiles[rel] {
+			diff.Changes = append(diff.Changes, FileChange{
+				Path: rel,
+				Kind: FileAdded,
+				Diff: fileDiff("/dev/null", "b/"+rel, nil, b),
+			})
+			continue
+		}
+
+		a, err := os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(a, b) {
+			diff.Unchanged++
+			continue
+		}
+
+		diff.Changes = append(diff.Changes, FileChange{
+			Path: rel,
+			Kind: FileModified,
+			Diff: fileDiff("a/"+rel, "b/"+rel, a, b),
+		})
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Path < diff.Changes[j].Path
+	})
+
+	return diff, nil
+}
+
+// fileDiff is UnifiedDiff for text files. Binary files, like the EPUBs,
+// only get a one line note.
+func fileDiff(aName, bName string, a, b []byte) string {
+	if bytes.IndexByte(a, 0) >= 0 || bytes.IndexByte(b, 0) >= 0 {
+		return fmt.Sprintf("Binary files %s and %s differ\n", aName, bName)
+	}
+	return UnifiedDiff(aName, bName, string(a), string(b), diffContext)
+}
+
+func listFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
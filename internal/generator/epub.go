@@ -0,0 +1,613 @@
+package generator
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"blog/internal/model"
+)
+
+// An EPUB bundles the public articles of a subject's subtree, oldest
+// first, into sub/<slug>.epub. Its bytes only depend on the articles, the
+// images and the theme, so an unchanged book is not rewritten as modified.
+
+const epubMimetype = "application/epub+zip"
+
+// epubImageTypes are the EPUB core media types for images; common_files
+// images of any other type are left out of the book.
+var epubImageTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// epubDropped are elements that make no sense, or are not allowed, in a
+// reading system.
+var epubDropped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Video:    true,
+	atom.Audio:    true,
+}
+
+var xmlNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.:-]*$`)
+
+// epubCSS is the code styling of the site. E-readers do not resolve CSS
+// variables, so var(--name) is replaced with the current theme's value.
+const epubCSS = `body { line-height: 1.6; }
+img { max-width: 100%; }
+nav ol { padding-left: 1.2em; }
+
+pre {
+  margin: 1.2em 0;
+  padding: 0.8em 1em;
+  background: var(--code-bg);
+  color: var(--code-text);
+  border: 1px solid var(--code-border);
+  border-radius: 6px;
+  font-family: "JetBrains Mono", "Fira Code", "Source Code Pro", monospace;
+  font-size: 0.85em;
+  line-height: 1.5;
+  white-space: pre-wrap;
+  word-wrap: break-word;
+}
+
+code {
+  font-family: "JetBrains Mono", "Fira Code", "Source Code Pro", monospace;
+  font-size: 0.9em;
+  padding: 0.1em 0.3em;
+  background: var(--code-bg);
+  color: var(--code-text);
+}
+
+pre code { padding: 0; font-size: 1em; background: none; }
+
+table { border-collapse: collapse; }
+th, td { border: 1px solid var(--border-soft); padding: 0.3em 0.6em; }
+
+.token.comment     { color: var(--syntax-comment); }
+.token.keyword     { color: var(--syntax-keyword); font-weight: 600; }
+.token.string      { color: var(--syntax-string); }
+.token.number      { color: var(--syntax-number); }
+.token.function    { color: var(--syntax-function); }
+.token.class-name,
+.token.type        { color: var(--syntax-type); }
+.token.operator    { color: var(--syntax-operator); }
+`
+
+var (
+	cssCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssVarDeclRe = regexp.MustCompile(`--([A-Za-z0-9-]+)\s*:\s*([^;]+);`)
+	cssVarUseRe  = regexp.MustCompile(`var\(--([A-Za-z0-9-]+)\)`)
+)
+
+// epubStylesheet resolves epubCSS against the variables of the first
+// :root block of the current theme. Unknown variables fall back to
+// inherit, which keeps the book readable without a theme.
+func (g *Generator) epubStylesheet() (string, error) {
+	b, err := os.ReadFile(filepath.Join(g.assetsDir(), "css", "theme.css"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	theme := cssCommentRe.ReplaceAllString(string(b), "")
+	if i := strings.Index(theme, ":root"); i >= 0 {
+		theme = theme[i:]
+		if j := strings.Index(theme, "}"); j >= 0 {
+			theme = theme[:j]
+		}
+	} else {
+		theme = ""
+	}
+
+	vars := make(map[string]string)
+	for _, m := range cssVarDeclRe.FindAllStringSubmatch(theme, -1) {
+		vars[m[1]] = strings.TrimSpace(m[2])
+	}
+
+	resolve := func(s string) string {
+		return cssVarUseRe.ReplaceAllStringFunc(s, func(use string) string {
+			if v, ok := vars[cssVarUseRe.FindStringSubmatch(use)[1]]; ok {
+				return v
+			}
+			return "inherit"
+		})
+	}
+
+	// theme variables may refer to each other
+	for name, v := range vars {
+		vars[name] = resolve(v)
+	}
+
+	return resolve(epubCSS), nil
+}
+
+type epubChapter struct {
+	file  string
+	title string
+	lang  string
+	body  string
+}
+
+type epubImage struct {
+	id    string
+	href  string // relative to OEBPS
+	media string
+	data  []byte
+	cover bool
+}
+
+// epubBook collects what goes into one EPUB while its chapters are
+// converted.
+type epubBook struct {
+	g        *Generator
+	chapters map[string]string // article path -> chapter file
+	images   map[string]*epubImage
+	order    []*epubImage
+}
+
+// epubJob returns the job writing the EPUB of subject, or false when its
+// subtree has no public article.
+func (g *Generator) epubJob(subject model.Subject, views []model.ArticleView, css string) (renderJob, bool, error) {
+	var public []model.ArticleView
+	for _, v := range views {
+		if v.IsPublic {
+			public = append(public, v)
+		}
+	}
+	if len(public) == 0 {
+		return renderJob{}, false, nil
+	}
+
+	sort.SliceStable(public, func(i, j int) bool {
+		return public[i].CreatedAt.Before(public[j].CreatedAt)
+	})
+
+	book := &epubBook{
+		g:        g,
+		chapters: make(map[string]string, len(public)),
+		images:   make(map[string]*epubImage),
+	}
+
+	for i, v := range public {
+		book.chapters[v.Path()] = fmt.Sprintf("chapter-%03d.xhtml", i+1)
+	}
+
+	if subject.Cover != "" {
+		if img, err := book.image(subject.Cover); err != nil {
+			return renderJob{}, false, err
+		} else if img != nil {
+			img.cover = true
+		}
+	}
+
+	var modified time.Time
+	chapters := make([]epubChapter, 0, len(public))
+
+	for _, v := range public {
+		body, err := book.xhtml(string(v.HTML))
+		if err != nil {
+			return renderJob{}, false, fmt.Errorf("%s: %w", v.Path(), err)
+		}
+
+		chapters = append(chapters, epubChapter{
+			file:  book.chapters[v.Path()],
+			title: v.Title,
+			lang:  v.Lang,
+			body:  body,
+		})
+
+		if v.CreatedAt.After(modified) {
+			modified = v.CreatedAt
+		}
+		if v.UpdatedAt.After(modified) {
+			modified = v.UpdatedAt
+		}
+	}
+
+	b, err := book.write(subject, chapters, css, modified.UTC().Truncate(time.Second))
+	if err != nil {
+		return renderJob{}, false, err
+	}
+
+	return renderJob{
+		filename: filepath.Join(g.OutDir, "sub", subject.Slug+".epub"),
+		body:     b,
+	}, true, nil
+}
+
+// image embeds a common_files image once and returns it, or nil when the
+// file is missing or of a type readers do not support.
+func (b *epubBook) image(name string) (*epubImage, error) {
+	name = path.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") || strings.HasPrefix(name, "/") {
+		return nil, nil
+	}
+
+	if img, ok := b.images[name]; ok {
+		return img, nil
+	}
+
+	media, ok := epubImageTypes[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(b.g.assetsDir(), "common_files", filepath.FromSlash(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img := &epubImage{
+		id:    fmt.Sprintf("img-%d", len(b.order)+1),
+		href:  "images/" + name,
+		media: media,
+		data:  data,
+	}
+	b.images[name] = img
+	b.order = append(b.order, img)
+
+	return img, nil
+}
+
+// xhtml turns an article body into XHTML a reading system accepts: active
+// content is dropped, common_files images are embedded, links to other
+// chapters stay inside the book and the other site links become absolute.
+func (b *epubBook) xhtml(body string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+
+	nodes, err := html.ParseFragment(strings.NewReader(body), context)
+	if err != nil {
+		return "", err
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+
+	if err := b.clean(root); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for n := root.FirstChild; n != nil; n = n.NextSibling {
+		if err := html.Render(&buf, n); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func (b *epubBook) clean(n *html.Node) error {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch {
+		case c.Type == html.CommentNode,
+			c.Type == html.ElementNode && epubDropped[c.DataAtom]:
+			n.RemoveChild(c)
+
+		case c.Type == html.ElementNode && c.DataAtom == atom.Img:
+			keep, err := b.cleanImage(c)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				// an image that cannot be embedded leaves its alt text
+				if alt := attr(c, "alt"); alt != "" {
+					n.InsertBefore(&html.Node{Type: html.TextNode, Data: alt}, c)
+				}
+				n.RemoveChild(c)
+			}
+
+		case c.Type == html.ElementNode:
+			cleanAttrs(c)
+			if c.DataAtom == atom.A {
+				b.cleanLink(c)
+			}
+			if err := b.clean(c); err != nil {
+				return err
+			}
+		}
+
+		c = next
+	}
+
+	return nil
+}
+
+func (b *epubBook) cleanImage(n *html.Node) (bool, error) {
+	name, ok := strings.CutPrefix(attr(n, "src"), model.CommonFilesURL)
+	if !ok {
+		return false, nil
+	}
+
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		return false, nil
+	}
+
+	img, err := b.image(name)
+	if err != nil || img == nil {
+		return false, err
+	}
+
+	cleanAttrs(n)
+	setAttr(n, "src", img.href)
+	if attr(n, "alt") == "" {
+		setAttr(n, "alt", "")
+	}
+
+	return true, nil
+}
+
+func (b *epubBook) cleanLink(n *html.Node) {
+	href := attr(n, "href")
+	if !strings.HasPrefix(href, "/") || strings.HasPrefix(href, "//") {
+		return
+	}
+
+	p, fragment, _ := strings.Cut(href, "#")
+	if file, ok := b.chapters[p]; ok {
+		if fragment != "" {
+			file += "#" + fragment
+		}
+		setAttr(n, "href", file)
+		return
+	}
+
+	setAttr(n, "href", siteURL+href)
+}
+
+// cleanAttrs drops event handlers and the attribute names XML rejects.
+func cleanAttrs(n *html.Node) {
+	kept := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Namespace != "" || strings.HasPrefix(strings.ToLower(a.Key), "on") || !xmlNameRe.MatchString(a.Key) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	n.Attr = kept
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+type epubFile struct {
+	name string
+	body []byte
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// write zips the book. The mimetype entry comes first and is stored
+// uncompressed, as the OCF container format requires.
+func (b *epubBook) write(subject model.Subject, chapters []epubChapter, css string, modified time.Time) ([]byte, error) {
+	lang := chapters[0].lang
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", b.opf(subject, chapters, lang, modified)},
+		{"OEBPS/nav.xhtml", epubNav(subject, chapters, lang)},
+		{"OEBPS/style.css", []byte(css)},
+	}
+
+	for _, c := range chapters {
+		files = append(files, epubFile{"OEBPS/" + c.file, epubChapterXHTML(c)})
+	}
+
+	for _, img := range b.order {
+		files = append(files, epubFile{"OEBPS/" + img.href, img.data})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// readers sniff the mimetype at byte 38, so its local header must
+	// carry no extra field and no data descriptor: CreateHeader adds both
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(epubMimetype)),
+		CompressedSize64:   uint64(len(epubMimetype)),
+		UncompressedSize64: uint64(len(epubMimetype)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte(epubMimetype)); err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *epubBook) opf(subject model.Subject, chapters []epubChapter, lang string, modified time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:language>%s</dc:language>
+    <dc:publisher>%s</dc:publisher>
+    <meta property="dcterms:modified">%s</meta>
+`,
+		xmlEscape(lang),
+		xmlEscape(siteURL+"/sub/"+subject.Slug+".epub"),
+		xmlEscape(subject.Title),
+		xmlEscape(lang),
+		xmlEscape(siteURL),
+		modified.Format("2006-01-02T15:04:05Z"),
+	)
+
+	for _, img := range b.order {
+		if img.cover {
+			fmt.Fprintf(&buf, "    <meta name=\"cover\" content=\"%s\"/>\n", img.id)
+		}
+	}
+
+	buf.WriteString(`  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+`)
+
+	for i, c := range chapters {
+		fmt.Fprintf(&buf, "    <item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, c.file)
+	}
+
+	for _, img := range b.order {
+		properties := ""
+		if img.cover {
+			properties = ` properties="cover-image"`
+		}
+		fmt.Fprintf(&buf, "    <item id=\"%s\" href=\"%s\" media-type=\"%s\"%s/>\n",
+			img.id, xmlEscape(img.href), img.media, properties)
+	}
+
+	buf.WriteString(`  </manifest>
+  <spine>
+    <itemref idref="nav"/>
+`)
+
+	for i := range chapters {
+		fmt.Fprintf(&buf, "    <itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+
+	buf.WriteString(`  </spine>
+</package>
+`)
+
+	return buf.Bytes()
+}
+
+func epubNav(subject model.Subject, chapters []epubChapter, lang string) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%[1]s" xml:lang="%[1]s">
+<head>
+  <meta charset="UTF-8"/>
+  <title>%[2]s</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>%[2]s</h1>
+    <ol>
+`, xmlEscape(lang), xmlEscape(subject.Title))
+
+	for _, c := range chapters {
+		fmt.Fprintf(&buf, "      <li><a href=\"%s\">%s</a></li>\n", c.file, xmlEscape(c.title))
+	}
+
+	buf.WriteString(`    </ol>
+  </nav>
+</body>
+</html>
+`)
+
+	return buf.Bytes()
+}
+
+func epubChapterXHTML(c epubChapter) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%[1]s" xml:lang="%[1]s">
+<head>
+  <meta charset="UTF-8"/>
+  <title>%[2]s</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+  <section epub:type="chapter">
+    <h1>%[2]s</h1>
+%[3]s
+  </section>
+</body>
+</html>
+`, xmlEscape(c.lang), xmlEscape(c.title), c.body))
+}
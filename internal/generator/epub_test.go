@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+// TestEPUBMimetype checks the container signature of the EPUB OCF spec:
+// a stored mimetype entry first, its content at byte 38.
+func TestEPUBMimetype(t *testing.T) {
+	b := &epubBook{g: &Generator{}}
+
+	book, err := b.write(
+		model.Subject{Id: 1, Title: "Go", Slug: "go"},
+		[]epubChapter{{file: "chapter-001.xhtml", title: "Hello", lang: "en", body: "<p>hi</p>"}},
+		"",
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book) < 58 {
+		t.Fatalf("EPUB is only %d bytes", len(book))
+	}
+
+	header := book[:30]
+	if sig := binary.LittleEndian.Uint32(header[0:]); sig != 0x04034b50 {
+		t.Fatalf("no local file header at byte 0: %#x", sig)
+	}
+	if flags := binary.LittleEndian.Uint16(header[6:]); flags&0x8 != 0 {
+		t.Error("mimetype has a data descriptor")
+	}
+	if method := binary.LittleEndian.Uint16(header[8:]); method != 0 {
+		t.Errorf("mimetype compressed with method %d", method)
+	}
+	if n := binary.LittleEndian.Uint16(header[26:]); n != 8 {
+		t.Errorf("file name is %d bytes, want 8", n)
+	}
+	if n := binary.LittleEndian.Uint16(header[28:]); n != 0 {
+		t.Errorf("mimetype has a %d byte extra field", n)
+	}
+
+	if got := book[30:58]; !bytes.Equal(got, []byte("mimetypeapplication/epub+zip")) {
+		t.Errorf("bytes 30-58 are %q", got)
+	}
+}
+
+func TestBuildAllEPUB(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "dist")
+	assets := filepath.Join(dir, "assets")
+
+	if err := os.MkdirAll(filepath.Join(assets, "common_files"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(assets, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assets, "common_files", "gopher.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assets, "css", "theme.css"), []byte(":root {\n  --code-bg: #123456;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Second", TitleURL: "second", SubjectId: 1, AuthorId: 1, IsPublic: true, HTML: `<p>see <a href="/articles/first.html">first</a><br></p><script>x()</script>`, CreatedAt: day.AddDate(0, 0, 1)},
+			{ID: 2, Title: "First", TitleURL: "first", SubjectId: 1, AuthorId: 1, IsPublic: true, HTML: `<p><img src="/assets/common_files/gopher.png" alt="gopher"></p>`, CreatedAt: day},
+			{ID: 3, Title: "Draft", TitleURL: "draft", SubjectId: 1, AuthorId: 1, HTML: "<p>draft</p>", CreatedAt: day},
+		},
+		Subjects:  []model.Subject{{Id: 1, Title: "Go", Slug: "go"}, {Id: 2, Title: "Empty", Slug: "empty"}},
+		Authors:   []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}},
+		OutDir:    out,
+		AssetsDir: assets,
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(out, "sub", "go.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `href="/sub/go.epub"`) {
+		t.Error("subject page does not link its EPUB")
+	}
+	if _, err := os.Stat(filepath.Join(out, "sub", "empty.epub")); !os.IsNotExist(err) {
+		t.Error("EPUB written for a subject without public articles")
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(out, "sub", "go.epub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("first entry is %s (method %d), want stored mimetype", first.Name, first.Method)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	if strings.Index(nav, "First") > strings.Index(nav, "Second") || strings.Contains(nav, "Draft") {
+		t.Errorf("nav does not list the public articles oldest first:\n%s", nav)
+	}
+
+	for name, want := range map[string]string{
+		"OEBPS/chapter-001.xhtml": `src="images/gopher.png"`,
+		"OEBPS/chapter-002.xhtml": `href="chapter-001.xhtml"`,
+		"OEBPS/style.css":         "#123456",
+		"OEBPS/content.opf":       `href="images/gopher.png" media-type="image/png"`,
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s does not contain %s:\n%s", name, want, files[name])
+		}
+	}
+
+	if chapter := files["OEBPS/chapter-002.xhtml"]; strings.Contains(chapter, "<script") || !strings.Contains(chapter, "<br/>") {
+		t.Errorf("chapter is not clean XHTML:\n%s", chapter)
+	}
+
+	if files["OEBPS/images/gopher.png"] != "png" {
+		t.Error("image not embedded")
+	}
+}
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"blog/internal/model"
+)
+
+// goneDir holds one empty marker per deleted article, mirroring its old
+// URL (.gone/articles/<slug>.html, .gone/<lang>/articles/<slug>.html).
+// nginx answers 410 when a marker exists and 404 otherwise, so deletions
+// need no nginx reload.
+const goneDir = ".gone"
+
+const recentOnErrorPage = 10
+
+type ErrorPageView struct {
+	Status     int
+	Title      string
+	Message    string
+	Recent     []model.ArticleView
+	SearchSite string
+}
+
+const nginxErrorPages = `# Generated by Statix, include it inside the server block.
+error_page 404 /404.html;
+error_page 410 /410.html;
+
+location = /404.html { internal; }
+location = /410.html { internal; }
+location ^~ /` + goneDir + `/ { internal; }
+
+location /articles/ {
+    try_files $uri @statix_gone;
+}
+
+location ~ ^/[a-z]{2,3}(-[a-z0-9]+)?/articles/ {
+    try_files $uri @statix_gone;
+}
+
+location @statix_gone {
+    if (-f $document_root/` + goneDir + `$uri) {
+        return 410;
+    }
+    return 404;
+}
+`
+
+// BuildErrorPages renders 404.html and 410.html and, when NginxConf is
+// set, the nginx snippet serving them.
+func (g *Generator) BuildErrorPages(ctx context.Context) error {
+	tmpl, err := g.template("error")
+	if err != nil {
+		return err
+	}
+
+	var recent []model.ArticleView
+	for _, v := range g.BuildArticleViews() {
+		if !v.IsPublic {
+			continue
+		}
+		recent = append(recent, v)
+		if len(recent) == recentOnErrorPage {
+			break
+		}
+	}
+
+	site := ""
+	if u, err := url.Parse(siteURL); err == nil {
+		site = u.Host
+	}
+
+	pages := []ErrorPageView{
+		{
+			Status:  404,
+			Title:   "Page not found",
+			Message: "This page does not exist, or it moved.",
+		},
+		{
+			Status:  410,
+			Title:   "Article removed",
+			Message: "This article has been deleted and is not coming back.",
+		},
+	}
+
+	jobs := make([]renderJob, 0, len(pages))
+
+	for _, page := range pages {
+		page.Recent = recent
+		page.SearchSite = site
+
+		jobs = append(jobs, renderJob{
+			filename: filepath.Join(g.OutDir, fmt.Sprintf("%d.html", page.Status)),
+			tmpl:     tmpl,
+			name:     "base",
+			data:     page,
+		})
+	}
+
+	if err := g.render(ctx, jobs); err != nil {
+		return err
+	}
+
+	if g.NginxConf == "" {
+		return nil
+	}
+
+	return writeFileAtomic(g.NginxConf, func(f *os.File) error {
+		_, err := f.WriteString(nginxErrorPages)
+		return err
+	})
+}
+
+// MarkGone removes a deleted article's page and leaves a marker so its
+// URL answers 410 instead of 404. path is the article's URL path, as
+// returned by model.ArticlePath.
+func (g *Generator) MarkGone(path string) error {
+	page := filepath.Join(g.OutDir, filepath.FromSlash(path))
+	if err := os.Remove(page); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return g.writeGoneMarker(path)
+}
+
+func (g *Generator) writeGoneMarker(path string) error {
+	marker := filepath.Join(g.OutDir, goneDir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(marker, func(f *os.File) error {
+		return nil
+	})
+}
+
+// gonePaths returns the URL paths of the gone markers.
+func (g *Generator) gonePaths() ([]string, error) {
+	root := filepath.Join(g.OutDir, goneDir)
+
+	var paths []string
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(p, ".html") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, "/"+filepath.ToSlash(rel))
+		return nil
+	})
+
+	return paths, err
+}
+
+// restoreGone rewrites the markers collected before a full build, except
+// for paths that belong to a live article again.
+func (g *Generator) restoreGone(paths []string) error {
+	live := make(map[string]bool, len(g.Articles))
+	for _, a := range g.Articles {
+		live[a.Path()] = true
+	}
+
+	for _, path := range paths {
+		if live[path] {
+			continue
+		}
+		if err := g.writeGoneMarker(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
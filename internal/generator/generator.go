@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"html/template"
 	"os"
 	"path/filepath"
@@ -15,8 +16,11 @@ import (
 	"blog/internal/model"
 	"blog/internal/utils"
     "blog/internal/db"
+    "blog/internal/templates"
 )
 
+const siteURL = "https://julienlargetpiet.tech"
+
 var defaultSubject = model.Subject{
 	Title: "Default",
 	Slug:  "default",
@@ -69,8 +73,49 @@ func (g *Generator) BuildSubjectMap() map[int64]model.Subject {
 	return m
 }
 
+func (g *Generator) BuildAuthorMap() map[int64]model.Author {
+	m := make(map[int64]model.Author, len(g.Authors))
+	for _, a := range g.Authors {
+		m[a.ID] = a
+	}
+	return m
+}
+
+// BuildTranslationMap groups the articles that have translations by
+// translation group.
+func (g *Generator) BuildTranslationMap() map[int64][]model.Article {
+	m := make(map[int64][]model.Article)
+	for _, a := range g.Articles {
+		if a.TranslationGroup != 0 {
+			m[a.TranslationGroup] = append(m[a.TranslationGroup], a)
+		}
+	}
+	return m
+}
+
+// translationsOf lists the public translations of a, by language.
+func translationsOf(a model.Article, groups map[int64][]model.Article) []model.Translation {
+	if a.TranslationGroup == 0 {
+		return nil
+	}
+
+	var out []model.Translation
+	for _, t := range groups[a.TranslationGroup] {
+		if t.ID == a.ID || !t.IsPublic {
+			continue
+		}
+		out = append(out, model.Translation{Lang: t.Lang, Title: t.Title, Path: t.Path()})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Lang < out[j].Lang })
+
+	return out
+}
+
 func (g *Generator) BuildArticleViews() []model.ArticleView {
 	subjectMap := g.BuildSubjectMap()
+	authorMap := g.BuildAuthorMap()
+	translationMap := g.BuildTranslationMap()
 
 	views := make([]model.ArticleView, 0, len(g.Articles))
 
@@ -88,24 +133,37 @@ func (g *Generator) BuildArticleViews() []model.ArticleView {
             TitleURL:  a.TitleURL,
             SubjectId: a.SubjectId,
             Slug:      subject.Slug,
+            Author:    authorMap[a.AuthorId],
+            Lang:      articleLang(*a),
+            Translations: translationsOf(*a, translationMap),
+            Breadcrumbs: model.SubjectTrail(g.Subjects, a.SubjectId),
             IsPublic:  a.IsPublic,
+            PinnedHome:    a.PinnedHome,
+            PinnedSubject: a.PinnedSubject,
+            Featured:  a.Featured,
             HTML:      template.HTML(a.HTML),
             CreatedAt: a.CreatedAt,
+            UpdatedAt: a.UpdatedAt,
         })
     }
 
 	return views
 }
 
+// BuildArticleViewsForSubject returns the views of the articles filed
+// under subject_id or any of its descendants.
 func (g *Generator) BuildArticleViewsForSubject(subject_id int64) []model.ArticleView {
 	subjectMap := g.BuildSubjectMap()
+	descendants := model.SubjectDescendants(g.Subjects, subject_id)
+	authorMap := g.BuildAuthorMap()
+	translationMap := g.BuildTranslationMap()
 
 	views := make([]model.ArticleView, 0, len(g.Articles))
 
     for i := range g.Articles {
         a := &g.Articles[i]
    
-        if a.SubjectId != subject_id {
+        if !descendants[a.SubjectId] {
             continue
         }
 
@@ -120,9 +178,17 @@ func (g *Generator) BuildArticleViewsForSubject(subject_id int64) []model.Articl
             TitleURL:  a.TitleURL,
             SubjectId: a.SubjectId,
             Slug:      subject.Slug,
+            Author:    authorMap[a.AuthorId],
+            Lang:      articleLang(*a),
+            Translations: translationsOf(*a, translationMap),
+            Breadcrumbs: model.SubjectTrail(g.Subjects, a.SubjectId),
             IsPublic:  a.IsPublic,
+            PinnedHome:    a.PinnedHome,
+            PinnedSubject: a.PinnedSubject,
+            Featured:  a.Featured,
             HTML:      template.HTML(a.HTML),
             CreatedAt: a.CreatedAt,
+            UpdatedAt: a.UpdatedAt,
         })
     }
 
@@ -147,21 +213,56 @@ func excerpt(htmlContent string, words int) string {
 }
 
 type Generator struct {
-    AuthorContent template.HTML
-    ArticleRepo   db.ArticleRepo
-    SubjectRepo   db.SubjectRepo
+    ArticleRepo   db.ArticleStore
+    SubjectRepo   db.SubjectStore
 	Articles      []model.Article
     Subjects      []model.Subject
+    Pages         []model.Page
+    Authors       []model.Author
 	OutDir        string
+	AssetsDir     string
+	StrictLinks   bool
+
+	// Workers bounds the number of pages rendered concurrently.
+	// Zero means one worker per CPU.
+	Workers       int
+
+	Templates     *templates.Loader
+
+	// NginxConf is where BuildErrorPages writes the nginx snippet wiring
+	// up 404.html and 410.html. Empty means no snippet.
+	NginxConf     string
+
+	Plugins       Plugins
+
+	tmpls         map[string]*template.Template
 }
 
 type IndexView struct {
 	Articles []model.ArticleView
 	Subjects []model.Subject
 	ActiveSubject string
+
+	// Subject is the subject of a subject page, zero on the home page.
+	Subject  model.Subject
+
+	// Trail and Children are set on subject pages: the subjects from the
+	// top level down to the active one, and the ones nested right under it.
+	Trail    []model.Subject
+	Children []model.Subject
+
+	// EPUB is the URL of the subject's book, empty when it has no public
+	// article.
+	EPUB     string
 }
 
-func (g *Generator) Build() error {
+func (g *Generator) Build(ctx context.Context) error {
+	// gone markers only live in OutDir, carry them over the wipe
+	gone, err := g.gonePaths()
+	if err != nil {
+		return err
+	}
+
 	if err := os.RemoveAll(g.OutDir); err != nil {
 		return err
 	}
@@ -178,28 +279,50 @@ func (g *Generator) Build() error {
     	return g.Articles[i].ID > g.Articles[j].ID
     })
 
-	if err := g.buildIndex(); err != nil {
+	// index, subject and article pages share one worker pool
+	jobs, err := g.indexJobs()
+	if err != nil {
+		return err
+	}
+
+	subjectJobs, err := g.subjectJobs(g.Subjects)
+	if err != nil {
+		return err
+	}
+
+	articleJobs, err := g.articleJobs(g.BuildArticleViews())
+	if err != nil {
 		return err
 	}
 
-	if err := g.buildSubjects(); err != nil {
+	jobs = append(jobs, subjectJobs...)
+	jobs = append(jobs, articleJobs...)
+
+	if err := g.render(ctx, jobs); err != nil {
 		return err
 	}
 
-	return g.buildArticles()
+	return g.restoreGone(gone)
 }
 
-func (g *Generator) LocalizedBuild(title string, 
+// LocalizedBuild rebuilds what one article change touches. When the
+// article moved from old_subject_id, that subject's pages are rebuilt too;
+// 0 means it did not move.
+func (g *Generator) LocalizedBuild(ctx context.Context, title string, 
+                                   lang string,
                                    subject_id int64,
+                                   old_subject_id int64,
                                    is_deletion bool) error {
 
     title_url := utils.Slugify(title)
 
-    //if is_deletion {
-    //    os.Remove("/dist/articles/" + title_url + ".html")
-    //}
+    if is_deletion {
+        if err := g.MarkGone(model.ArticlePath(lang, title_url)); err != nil {
+            return err
+        }
+    }
 
-    subject_slug, err := g.SubjectRepo.GetSlugByID(subject_id)
+    subject_slug, err := g.SubjectRepo.GetSlugByID(ctx, subject_id)
     if err != nil {
         return err
     }
@@ -208,25 +331,33 @@ func (g *Generator) LocalizedBuild(title string,
     	return g.Articles[i].ID > g.Articles[j].ID
     })
 
-	if err := g.buildIndex(); err != nil {
+	if err := g.buildIndex(ctx); err != nil {
 		return err
 	}
 
-	if err := g.buildSubject(subject_id); err != nil {
+    subject_ids := []int64{subject_id}
+    if old_subject_id != 0 && old_subject_id != subject_id {
+        subject_ids = append(subject_ids, old_subject_id)
+    }
+
+	if err := g.buildSubject(ctx, subject_ids...); err != nil {
 		return err
 	}
 
     if !is_deletion {
-	    return g.buildArticle(title_url, subject_slug)
+	    if err := g.buildArticle(ctx, title_url, subject_slug); err != nil {
+		    return err
+	    }
     }
 
-    return nil
+    // the article may have joined or left any author's listing
+    return g.BuildAuthors(ctx)
 
 }
 
-func (g* Generator) SubjectEventBuild() error {
+func (g* Generator) SubjectEventBuild(ctx context.Context) error {
 	
-    if err := g.buildIndex(); err != nil {
+    if err := g.buildIndex(ctx); err != nil {
 		return err
 	}
 
@@ -234,17 +365,17 @@ func (g* Generator) SubjectEventBuild() error {
 
 }
 
-func (g* Generator) SubjectEditBuild(subject_id int64) error {
+func (g* Generator) SubjectEditBuild(ctx context.Context, subject_id int64) error {
 	
-    if err := g.buildIndex(); err != nil {
+    if err := g.buildIndex(ctx); err != nil {
 		return err
 	}
 
-    if err := g.buildArticlesForSubject(subject_id); err != nil {
+    if err := g.buildArticlesForSubject(ctx, subject_id); err != nil {
 		return err
 	} 
 
-    if err := g.buildSubjects(); err != nil {
+    if err := g.buildSubjects(ctx); err != nil {
 		return err
 	} 
 
@@ -252,74 +383,54 @@ func (g* Generator) SubjectEditBuild(subject_id int64) error {
 
 }
 
-func (g *Generator) buildArticlesForSubject(subject_id int64) error {
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base_article.html",
-		"internal/templates/users/article.html",
-	)
+func (g *Generator) articleJobs(views []model.ArticleView) ([]renderJob, error) {
+	tmpl, err := g.template("article")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	views := g.BuildArticleViewsForSubject(subject_id)
+	jobs := make([]renderJob, 0, len(views))
 
 	for _, view := range views {
 
-		filename := filepath.Join(
-			g.OutDir,
-			"articles",
-			view.TitleURL + ".html",
-		)
+		filename := filepath.Join(g.OutDir, filepath.FromSlash(view.Path()))
 
-        if err := writeFileAtomic(filename, func(f *os.File) error {
-            return tmpl.ExecuteTemplate(f, "base_article", view)
-        }); err != nil {
-            return err
-        }
+		// articles in other languages live under /<lang>/articles/
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, renderJob{
+			filename: filename,
+			tmpl:     tmpl,
+			name:     "base_article",
+			data:     view,
+		})
 	}
 
-	return nil
+	return jobs, nil
 }
 
-func (g *Generator) buildArticles() error {
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base_article.html",
-		"internal/templates/users/article.html",
-	)
+func (g *Generator) buildArticlesForSubject(ctx context.Context, subject_id int64) error {
+	jobs, err := g.articleJobs(g.BuildArticleViewsForSubject(subject_id))
 	if err != nil {
 		return err
 	}
 
-	views := g.BuildArticleViews()
-
-	for _, view := range views {
-
-		filename := filepath.Join(
-			g.OutDir,
-			"articles",
-			view.TitleURL + ".html",
-		)
-
-        if err := writeFileAtomic(filename, func(f *os.File) error {
-            return tmpl.ExecuteTemplate(f, "base_article", view)
-        }); err != nil {
-            return err
-        }
-	}
-
-	return nil
+	return g.render(ctx, jobs)
 }
 
-func (g *Generator) buildArticle(title_url, slug_val string) error {
-	tmpl, err := template.ParseFiles(
-		"internal/templates/base_article.html",
-		"internal/templates/users/article.html",
-	)
+func (g *Generator) buildArticles(ctx context.Context) error {
+	jobs, err := g.articleJobs(g.BuildArticleViews())
 	if err != nil {
 		return err
 	}
 
-    article, err := g.ArticleRepo.GetByTitleURL(title_url)
+	return g.render(ctx, jobs)
+}
+
+func (g *Generator) buildArticle(ctx context.Context, title_url, slug_val string) error {
+    article, err := g.ArticleRepo.GetByTitleURL(ctx, title_url)
 
     if err != nil {
         return err
@@ -331,39 +442,31 @@ func (g *Generator) buildArticle(title_url, slug_val string) error {
             TitleURL:  article.TitleURL,
             SubjectId: article.SubjectId,
             Slug:      slug_val,
+            Author:    g.BuildAuthorMap()[article.AuthorId],
+            Lang:      articleLang(article),
+            Translations: translationsOf(article, g.BuildTranslationMap()),
+            Breadcrumbs: model.SubjectTrail(g.Subjects, article.SubjectId),
             IsPublic:  article.IsPublic,
+            PinnedHome:    article.PinnedHome,
+            PinnedSubject: article.PinnedSubject,
+            Featured:  article.Featured,
             HTML:      template.HTML(article.HTML),
             CreatedAt: article.CreatedAt,
+            UpdatedAt: article.UpdatedAt,
         }
 
-	filename := filepath.Join(
-		g.OutDir,
-		"articles",
-		view.TitleURL + ".html",
-	)
+	jobs, err := g.articleJobs([]model.ArticleView{view})
+	if err != nil {
+		return err
+	}
 
-    return writeFileAtomic(filename, func(f *os.File) error {
-        return tmpl.ExecuteTemplate(f, "base_article", view)
-    })
+	return g.render(ctx, jobs)
 }
 
-func (g *Generator) buildIndex() error {
-	funcs := template.FuncMap{
-		"mod": func(a, b int) int { return a % b },
-		"add": func(a, b int) int { return a + b },
-		"excerpt": func(html template.HTML, n int) string {
-			return excerpt(string(html), n)
-		},
-	}
-
-	tmpl, err := template.New("base").
-		Funcs(funcs).
-		ParseFiles(
-			"internal/templates/base.html",
-			"internal/templates/users/index.html",
-		)
+func (g *Generator) indexJobs() ([]renderJob, error) {
+	tmpl, err := g.template("index")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
     views := g.BuildArticleViews()
@@ -371,6 +474,7 @@ func (g *Generator) buildIndex() error {
     sort.Slice(views, func(i, j int) bool {
     	return views[i].ID > views[j].ID
     })
+    pinnedFirst(views, pinnedHome)
     
     page := IndexView{
     	Articles:      views,
@@ -380,71 +484,91 @@ func (g *Generator) buildIndex() error {
 
 	filename := filepath.Join(g.OutDir, "index.html")
 
-    return writeFileAtomic(filename, func(f *os.File) error {
-        return tmpl.ExecuteTemplate(f, "base", page)
-    })
-
+	return []renderJob{{
+		filename: filename,
+		tmpl:     tmpl,
+		name:     "base",
+		data:     page,
+	}}, nil
 }
 
-func (g *Generator) BuildAuthor() error {
-	tmpl, err := template.New("base").
-		ParseFiles(
-			"internal/templates/base.html",
-			"internal/templates/admin/author.html",
-		)
-	if err != nil {
-		return err
-	}
+// pinnedFirst moves the articles pinned to a listing to the front,
+// keeping the order within pinned and unpinned ones.
+func pinnedFirst(views []model.ArticleView, pinned func(model.ArticleView) bool) {
+	sort.SliceStable(views, func(i, j int) bool {
+		return pinned(views[i]) && !pinned(views[j])
+	})
+}
 
-	filename := filepath.Join(g.OutDir, "author.html")
+func pinnedHome(v model.ArticleView) bool    { return v.PinnedHome }
+func pinnedSubject(v model.ArticleView) bool { return v.PinnedSubject }
 
-	data := struct {
-		Content template.HTML
-	}{
-		Content: g.AuthorContent,
+// Pinned reports whether a is pinned to this listing: the home page or
+// the page of Subject.
+func (p IndexView) Pinned(a model.ArticleView) bool {
+	if p.Subject.Id != 0 {
+		return a.PinnedSubject
 	}
+	return a.PinnedHome
+}
 
-    return writeFileAtomic(filename, func(f *os.File) error {
-        return tmpl.ExecuteTemplate(f, "base", data)
-    })
+func (g *Generator) buildIndex(ctx context.Context) error {
+	jobs, err := g.indexJobs()
+	if err != nil {
+		return err
+	}
 
+	return g.render(ctx, jobs)
 }
 
-func (g *Generator) buildSubjects() error {
-	funcs := template.FuncMap{
-		"mod": func(a, b int) int { return a % b },
-		"add": func(a, b int) int { return a + b },
-		"excerpt": func(html template.HTML, n int) string {
-			return excerpt(string(html), n)
-		},
+func (g *Generator) subjectJobs(subjects []model.Subject) ([]renderJob, error) {
+	tmpl, err := g.template("index")
+	if err != nil {
+		return nil, err
 	}
 
-	tmpl, err := template.New("base").
-		Funcs(funcs).
-		ParseFiles(
-			"internal/templates/base.html",
-			"internal/templates/users/index.html",
-		)
+	css, err := g.epubStylesheet()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	views := g.BuildArticleViews()
 
-	// group by subject id
-	grouped := make(map[int64][]model.ArticleView)
-	for _, v := range views {
-		grouped[v.SubjectId] = append(grouped[v.SubjectId], v)
-	}
+	jobs := make([]renderJob, 0, 2*len(subjects))
 
-	for _, subject := range g.Subjects {
+	for _, subject := range subjects {
 
-		filtered := grouped[subject.Id]
+		// a subject page lists the articles of its whole subtree
+		descendants := model.SubjectDescendants(g.Subjects, subject.Id)
+
+		var filtered []model.ArticleView
+		for _, v := range views {
+			if descendants[v.SubjectId] {
+				filtered = append(filtered, v)
+			}
+		}
+		pinnedFirst(filtered, pinnedSubject)
 
 		page := IndexView{
 			Articles:      filtered,
 			Subjects:      g.Subjects,
 			ActiveSubject: subject.Title,
+			Subject:       subject,
+			Trail:         model.SubjectTrail(g.Subjects, subject.Id),
+			Children:      model.SubjectChildren(g.Subjects, subject.Id),
+		}
+
+		// the book is rebuilt with its page, and removed once the
+		// subject has nothing public left
+		book, ok, err := g.epubJob(subject, filtered, css)
+		if err != nil {
+			return nil, fmt.Errorf("%s.epub: %w", subject.Slug, err)
+		}
+		if ok {
+			page.EPUB = "/sub/" + subject.Slug + ".epub"
+			jobs = append(jobs, book)
+		} else if err := os.Remove(filepath.Join(g.OutDir, "sub", subject.Slug+".epub")); err != nil && !os.IsNotExist(err) {
+			return nil, err
 		}
 
 		filename := filepath.Join(
@@ -453,81 +577,83 @@ func (g *Generator) buildSubjects() error {
 			subject.Slug+".html",
 		)
 
-        if err := writeFileAtomic(filename, func(f *os.File) error {
-            return tmpl.ExecuteTemplate(f, "base", page)
-        }); err != nil {
-            return err
-        }
-
+		jobs = append(jobs, renderJob{
+			filename: filename,
+			tmpl:     tmpl,
+			name:     "base",
+			data:     page,
+		})
 	}
 
-	return nil
+	return jobs, nil
 }
 
-func (g *Generator) buildSubject(subject_id int64) error {
-	funcs := template.FuncMap{
-		"mod": func(a, b int) int { return a % b },
-		"add": func(a, b int) int { return a + b },
-		"excerpt": func(html template.HTML, n int) string {
-			return excerpt(string(html), n)
-		},
-	}
-
-	tmpl, err := template.New("base").
-		Funcs(funcs).
-		ParseFiles(
-			"internal/templates/base.html",
-			"internal/templates/users/index.html",
-		)
+func (g *Generator) buildSubjects(ctx context.Context) error {
+	jobs, err := g.subjectJobs(g.Subjects)
 	if err != nil {
 		return err
 	}
 
-	views := g.BuildArticleViews()
+	return g.render(ctx, jobs)
+}
 
-	// group by subject id
-	grouped := make(map[int64][]model.ArticleView)
-	for _, v := range views {
-		grouped[v.SubjectId] = append(grouped[v.SubjectId], v)
-	}
+// buildSubject rebuilds the pages of subject_ids and of their ancestors,
+// which list their articles too.
+func (g *Generator) buildSubject(ctx context.Context, subject_ids ...int64) error {
+    var subjects []model.Subject
+    seen := make(map[int64]bool)
+
+    for _, subject_id := range subject_ids {
+        trail := model.SubjectTrail(g.Subjects, subject_id)
+        if len(trail) == 0 {
+            subject, err := g.SubjectRepo.GetByID(ctx, subject_id)
+            if err != nil {
+                return err
+            }
+            trail = []model.Subject{subject}
+        }
 
-    subject, err := g.SubjectRepo.GetByID(subject_id)
-    if err != nil {
-        return err
+        for _, subject := range trail {
+            if !seen[subject.Id] {
+                seen[subject.Id] = true
+                subjects = append(subjects, subject)
+            }
+        }
     }
 
-	filtered := grouped[subject.Id]
-
-	page := IndexView{
-		Articles:      filtered,
-		Subjects:      g.Subjects,
-		ActiveSubject: subject.Title,
+	jobs, err := g.subjectJobs(subjects)
+	if err != nil {
+		return err
 	}
 
-	filename := filepath.Join(
-		g.OutDir,
-		"sub",
-		subject.Slug+".html",
-	)
+	return g.render(ctx, jobs)
+}
 
-    return writeFileAtomic(filename, func(f *os.File) error {
-        return tmpl.ExecuteTemplate(f, "base", page)
-    })
+func (g *Generator) BuildSitemap(ctx context.Context) error {
+	return g.BuildPages(ctx, PageProducerFunc((*Generator).sitemapPages))
 }
 
-func (g *Generator) BuildSitemap() error {
+func (g *Generator) sitemapPages() ([]Page, error) {
+    type Link struct {
+        Rel      string `xml:"rel,attr"`
+        Hreflang string `xml:"hreflang,attr"`
+        Href     string `xml:"href,attr"`
+    }
+
     type URL struct {
         Loc     string `xml:"loc"`
         LastMod string `xml:"lastmod,omitempty"`
+        Links   []Link `xml:"xhtml:link"`
     }
 
     type URLSet struct {
         XMLName xml.Name `xml:"urlset"`
         Xmlns   string   `xml:"xmlns,attr"`
+        Xhtml   string   `xml:"xmlns:xhtml,attr"`
         URLs    []URL    `xml:"url"`
     }
 
-    base := "https://julienlargetpiet.tech"
+    base := siteURL
 
     urls := []URL{
         {
@@ -536,11 +662,23 @@ func (g *Generator) BuildSitemap() error {
         },
     }
 
+    translationMap := g.BuildTranslationMap()
+
     for _, a := range g.Articles {
-        urls = append(urls, URL{
-            Loc:     fmt.Sprintf("%s/articles/%s.html", base, a.TitleURL),
-            LastMod: a.CreatedAt.Format("2006-01-02"),
-        })
+        u := URL{
+            Loc:     base + a.Path(),
+            LastMod: lastModified(a).Format("2006-01-02"),
+        }
+
+        // hreflang alternates list every language, the page's own included
+        if ts := translationsOf(a, translationMap); len(ts) > 0 {
+            u.Links = append(u.Links, Link{Rel: "alternate", Hreflang: articleLang(a), Href: u.Loc})
+            for _, t := range ts {
+                u.Links = append(u.Links, Link{Rel: "alternate", Hreflang: t.Lang, Href: base + t.Path})
+            }
+        }
+
+        urls = append(urls, u)
     }
 
     for _, s := range g.Subjects {
@@ -550,38 +688,127 @@ func (g *Generator) BuildSitemap() error {
     	})
     }
 
-    urls = append(urls, URL{
-    	Loc: fmt.Sprintf("%s/author.html", base),
-    	LastMod: time.Now().Format("2006-01-02"),
-    })
+    // an author page changes with the author's latest public article,
+    // and is not worth listing before there is one
+    authorLastMod := make(map[int64]time.Time)
+    for _, a := range g.Articles {
+        if !a.IsPublic {
+            continue
+        }
+        if t := lastModified(a); t.After(authorLastMod[a.AuthorId]) {
+            authorLastMod[a.AuthorId] = t
+        }
+    }
+
+    for _, a := range g.Authors {
+        lastMod, ok := authorLastMod[a.ID]
+        if !ok {
+            continue
+        }
+        urls = append(urls, URL{
+            Loc:     fmt.Sprintf("%s/authors/%s.html", base, a.Slug),
+            LastMod: lastMod.Format("2006-01-02"),
+        })
+    }
+
+    for _, p := range g.Pages {
+        if !p.IsPublic {
+            continue
+        }
+        urls = append(urls, URL{
+            Loc:     fmt.Sprintf("%s/%s.html", base, p.Slug),
+            LastMod: p.UpdatedAt.Format("2006-01-02"),
+        })
+    }
 
     sitemap := URLSet{
         Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+        Xhtml: "http://www.w3.org/1999/xhtml",
         URLs:  urls,
     }
 
     data, err := xml.MarshalIndent(sitemap, "", "  ")
     if err != nil {
-        return err
+        return nil, err
     }
 
     data = append([]byte(xml.Header), data...)
 
-    filename := filepath.Join(g.OutDir, "sitemap.xml")
+    return []Page{{Path: "sitemap.xml", Body: data}}, nil
+}
 
-    return writeFileAtomic(filename, func(f *os.File) error {
-        _, err := f.Write(data)
-        return err
-    })
+func (g *Generator) BuildRSS(ctx context.Context) error {
+	return g.BuildPages(ctx, PageProducerFunc((*Generator).rssPages))
+}
+
+// articleLang is the language of a, defaulting to model.DefaultLang.
+func articleLang(a model.Article) string {
+    if a.Lang == "" {
+        return model.DefaultLang
+    }
+    return a.Lang
+}
+
+// rssPages writes one feed per language: rss.xml for model.DefaultLang and
+// <lang>/rss.xml for the others.
+func (g *Generator) rssPages() ([]Page, error) {
+    byLang := map[string][]model.Article{model.DefaultLang: nil}
+    for _, a := range g.Articles {
+        lang := articleLang(a)
+        byLang[lang] = append(byLang[lang], a)
+    }
 
+    langs := make([]string, 0, len(byLang))
+    for lang := range byLang {
+        langs = append(langs, lang)
+    }
+    sort.Strings(langs)
+
+    pages := make([]Page, 0, len(langs))
+
+    for _, lang := range langs {
+        title := "Julien Larget-Piet Updates"
+        path := "rss.xml"
+        if lang != model.DefaultLang {
+            title += " (" + lang + ")"
+            path = lang + "/rss.xml"
+        }
+
+        data, err := rssFeed(
+            title,
+            siteURL,
+            "Article publication notifications.",
+            byLang[lang],
+        )
+        if err != nil {
+            return nil, err
+        }
+
+        pages = append(pages, Page{Path: path, Body: data})
+    }
+
+    return pages, nil
+}
+
+// lastModified is when an article was last edited, falling back to its
+// creation date for articles that predate updated_at.
+func lastModified(a model.Article) time.Time {
+    if a.UpdatedAt.Before(a.CreatedAt) {
+        return a.CreatedAt
+    }
+    return a.UpdatedAt
 }
 
-func (g *Generator) BuildRSS() error {
+// rssFeed renders an RSS 2.0 channel listing the public articles. Items
+// carry an Atom <updated> next to pubDate so readers can pick up edits,
+// and lastBuildDate is the latest edit rather than the build time.
+func rssFeed(title, link, description string, articles []model.Article) ([]byte, error) {
     type Item struct {
         Title       string `xml:"title"`
         Link        string `xml:"link"`
         GUID        string `xml:"guid"`
         PubDate     string `xml:"pubDate"`
+        Updated     string `xml:"atom:updated"`
         Description string `xml:"description"`
     }
 
@@ -596,52 +823,60 @@ func (g *Generator) BuildRSS() error {
     type RSS struct {
         XMLName xml.Name `xml:"rss"`
         Version string   `xml:"version,attr"`
+        Atom    string   `xml:"xmlns:atom,attr"`
         Channel Channel  `xml:"channel"`
     }
 
-    const base = "https://julienlargetpiet.tech"
+    const base = siteURL
 
-    items := make([]Item, 0, len(g.Articles))
+    items := make([]Item, 0, len(articles))
 
-    for _, a := range g.Articles {
+    var lastBuild time.Time
+
+    for _, a := range articles {
         if !a.IsPublic {
             continue
         }
 
-        link := fmt.Sprintf("%s/articles/%s.html", base, a.TitleURL)
+        updated := lastModified(a)
+        if updated.After(lastBuild) {
+            lastBuild = updated
+        }
+
+        href := base + a.Path()
 
         items = append(items, Item{
             Title:   a.Title,
-            Link:    link,
-            GUID:    link,
+            Link:    href,
+            GUID:    href,
             PubDate: a.CreatedAt.Format(time.RFC1123Z),
+            Updated: updated.Format(time.RFC3339),
             Description: "New article published",
         })
     }
 
+    if lastBuild.IsZero() {
+        lastBuild = time.Now()
+    }
+
     rss := RSS{
         Version: "2.0",
+        Atom:    "http://www.w3.org/2005/Atom",
         Channel: Channel{
-            Title:         "Julien Larget-Piet Updates",
-            Link:          base,
-            Description:   "Article publication notifications.",
-            LastBuildDate: time.Now().Format(time.RFC1123Z),
+            Title:         title,
+            Link:          link,
+            Description:   description,
+            LastBuildDate: lastBuild.Format(time.RFC1123Z),
             Items:         items,
         },
     }
 
     data, err := xml.MarshalIndent(rss, "", "  ")
     if err != nil {
-        return err
+        return nil, err
     }
 
-    data = append([]byte(xml.Header), data...)
-
-    filename := filepath.Join(g.OutDir, "rss.xml")
-    return writeFileAtomic(filename, func(f *os.File) error {
-    	_, err := f.Write(data)
-    	return err
-    })
+    return append([]byte(xml.Header), data...), nil
 }
 
 
@@ -0,0 +1,249 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type LinkIssueKind string
+
+const (
+	BrokenLink     LinkIssueKind = "broken link"
+	MissingImage   LinkIssueKind = "missing image"
+	PrivateArticle LinkIssueKind = "private article"
+)
+
+type LinkIssue struct {
+	Page   string // page path, relative to OutDir
+	Target string // raw href/src value
+	Kind   LinkIssueKind
+}
+
+type LinkReport struct {
+	Pages  int
+	Links  int
+	Issues []LinkIssue
+}
+
+func (r *LinkReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *LinkReport) Count(kind LinkIssueKind) int {
+	n := 0
+	for _, is := range r.Issues {
+		if is.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *LinkReport) WriteText(w io.Writer) error {
+	for _, is := range r.Issues {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", is.Kind, is.Page, is.Target); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w,
+		"%d pages, %d links checked: %d broken links, %d missing images, %d links to private articles\n",
+		r.Pages, r.Links,
+		r.Count(BrokenLink), r.Count(MissingImage), r.Count(PrivateArticle),
+	)
+	return err
+}
+
+func (g *Generator) assetsDir() string {
+	if g.AssetsDir == "" {
+		return "assets"
+	}
+	return g.AssetsDir
+}
+
+// CheckLinks walks every generated HTML page in OutDir and checks each
+// internal href/src against the output tree and the assets directory.
+func (g *Generator) CheckLinks() (*LinkReport, error) {
+	private := make(map[string]bool)
+	for _, a := range g.Articles {
+		if !a.IsPublic {
+			private[a.Path()] = true
+		}
+	}
+
+	report := &LinkReport{}
+
+	err := filepath.WalkDir(g.OutDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != g.OutDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".html") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(g.OutDir, p)
+		if err != nil {
+			return err
+		}
+		page := filepath.ToSlash(rel)
+
+		refs, err := extractRefs(p)
+		if err != nil {
+			return err
+		}
+
+		report.Pages++
+
+		seen := make(map[string]bool)
+
+		for _, ref := range refs {
+			target, ok := internalPath(page, ref.value)
+			if !ok {
+				continue
+			}
+
+			report.Links++
+
+			if seen[ref.value] {
+				continue
+			}
+			seen[ref.value] = true
+
+			if !g.targetExists(target) {
+				kind := BrokenLink
+				if ref.tag == "img" || ref.tag == "source" {
+					kind = MissingImage
+				}
+				report.Issues = append(report.Issues, LinkIssue{Page: page, Target: ref.value, Kind: kind})
+				continue
+			}
+
+			if private[target] && !private["/"+page] {
+				report.Issues = append(report.Issues, LinkIssue{Page: page, Target: ref.value, Kind: PrivateArticle})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Page != report.Issues[j].Page {
+			return report.Issues[i].Page < report.Issues[j].Page
+		}
+		return report.Issues[i].Target < report.Issues[j].Target
+	})
+
+	return report, nil
+}
+
+// VerifyLinks runs CheckLinks and, when StrictLinks is set, turns any
+// reported issue into a build error.
+func (g *Generator) VerifyLinks() (*LinkReport, error) {
+	report, err := g.CheckLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	if g.StrictLinks && !report.OK() {
+		return report, fmt.Errorf("link check failed: %d issues", len(report.Issues))
+	}
+
+	return report, nil
+}
+
+func (g *Generator) targetExists(target string) bool {
+	var file string
+
+	if rest, ok := strings.CutPrefix(target, "/assets/"); ok {
+		file = filepath.Join(g.assetsDir(), filepath.FromSlash(rest))
+	} else {
+		file = filepath.Join(g.OutDir, filepath.FromSlash(target))
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		_, err := os.Stat(filepath.Join(file, "index.html"))
+		return err == nil
+	}
+
+	return true
+}
+
+type pageRef struct {
+	tag   string
+	value string
+}
+
+func extractRefs(filename string) ([]pageRef, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var refs []pageRef
+
+	z := html.NewTokenizer(f)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return refs, nil
+			}
+			return nil, z.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if k := string(key); k == "href" || k == "src" {
+					refs = append(refs, pageRef{tag: string(name), value: string(val)})
+				}
+			}
+		}
+	}
+}
+
+// internalPath resolves a raw href/src found on page to a site-absolute
+// path. External, fragment-only and non-http references are skipped.
+func internalPath(page, raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "#") {
+		return "", false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	p := u.Path
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join("/", path.Dir(page), p)
+	}
+
+	return p, true
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"blog/internal/model"
+)
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "dist")
+	assets := filepath.Join(root, "assets")
+
+	writeTestFile(t, filepath.Join(assets, "common_files", "present.png"), "png")
+	writeTestFile(t, filepath.Join(out, "index.html"), `
+		<a href="/articles/public.html">ok</a>
+		<a href="/articles/gone.html#top">gone</a>
+		<a href="/articles/secret.html">secret</a>
+		<a href="https://example.com/missing.html">external</a>
+		<a href="mailto:me@example.com">mail</a>
+		<a href="#anchor">anchor</a>
+		<img src="/assets/common_files/present.png">
+		<img src="/assets/common_files/absent.png">
+	`)
+	writeTestFile(t, filepath.Join(out, "articles", "public.html"), `
+		<a href="../index.html">home</a>
+		<a href="secret.html">relative secret</a>
+	`)
+	writeTestFile(t, filepath.Join(out, "articles", "secret.html"), `
+		<a href="/articles/public.html">ok</a>
+	`)
+
+	g := Generator{
+		Articles: []model.Article{
+			{TitleURL: "public", IsPublic: true},
+			{TitleURL: "secret", IsPublic: false},
+		},
+		OutDir:    out,
+		AssetsDir: assets,
+	}
+
+	report, err := g.CheckLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LinkIssue{
+		{Page: "articles/public.html", Target: "secret.html", Kind: PrivateArticle},
+		{Page: "index.html", Target: "/articles/gone.html#top", Kind: BrokenLink},
+		{Page: "index.html", Target: "/articles/secret.html", Kind: PrivateArticle},
+		{Page: "index.html", Target: "/assets/common_files/absent.png", Kind: MissingImage},
+	}
+
+	if report.Pages != 3 {
+		t.Errorf("Pages = %d, want 3", report.Pages)
+	}
+
+	if len(report.Issues) != len(want) {
+		t.Fatalf("got %d issues %+v, want %d", len(report.Issues), report.Issues, len(want))
+	}
+
+	for i := range want {
+		if report.Issues[i] != want[i] {
+			t.Errorf("issue %d = %+v, want %+v", i, report.Issues[i], want[i])
+		}
+	}
+
+	g.StrictLinks = true
+	if _, err := g.VerifyLinks(); err == nil {
+		t.Error("VerifyLinks with StrictLinks should fail")
+	}
+}
+
+func TestStrictBuildKeepsLiveSite(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "dist")
+	writeTestFile(t, filepath.Join(out, "index.html"), "previous")
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Broken", TitleURL: "broken", SubjectId: 1, IsPublic: true, HTML: `<a href="/articles/missing.html">missing</a>`},
+		},
+		Subjects:    []model.Subject{{Id: 1, Title: "Go", Slug: "go"}},
+		Pages:       []model.Page{{Slug: "author", Title: "Author", HTML: "<p>me</p>", IsPublic: true}},
+		OutDir:      out,
+		AssetsDir:   filepath.Join("..", "..", "assets"),
+		StrictLinks: true,
+	}
+
+	if err := g.BuildAll(context.Background()); err == nil {
+		t.Fatal("strict build with a broken link succeeded")
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil || string(b) != "previous" {
+		t.Errorf("failed strict build replaced the live site: %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "articles", "broken.html")); !os.IsNotExist(err) {
+		t.Errorf("failed strict build published articles/broken.html: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("staging directory left behind: %v", entries)
+	}
+
+	g.Articles[0].HTML = "<p>fixed</p>"
+	if err := g.BuildAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "articles", "broken.html")); err != nil {
+		t.Errorf("passing strict build was not published: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"blog/internal/model"
+	"blog/internal/utils"
+)
+
+// reservedPageSlugs would make a page overwrite another output file, or
+// share its name with an output directory or feed.
+var reservedPageSlugs = map[string]bool{
+	"index":    true,
+	"404":      true,
+	"410":      true,
+	"articles": true,
+	"sub":      true,
+	"authors":  true,
+	"rss":      true,
+	"sitemap":  true,
+	goneDir:    true,
+}
+
+// ValidPageSlug reports whether a page can be written to /<slug>.html.
+func ValidPageSlug(slug string) error {
+	if slug == "" {
+		return errors.New("page slug is required")
+	}
+	if slug != utils.Slugify(slug) {
+		return fmt.Errorf("page slug %q must be lowercase letters, digits and dashes", slug)
+	}
+	if reservedPageSlugs[slug] {
+		return fmt.Errorf("page slug %q is reserved", slug)
+	}
+	return nil
+}
+
+type PageView struct {
+	Title   string
+	Content template.HTML
+}
+
+// navPages returns the public pages shown in the topbar, in menu order.
+func (g *Generator) navPages() []model.Page {
+	var nav []model.Page
+	for _, p := range g.Pages {
+		if p.IsPublic && p.NavOrder > 0 {
+			nav = append(nav, p)
+		}
+	}
+
+	sort.SliceStable(nav, func(i, j int) bool {
+		return nav[i].NavOrder < nav[j].NavOrder
+	})
+
+	return nav
+}
+
+// pagePages renders the public pages. Private ones are not built.
+func (g *Generator) pagePages() ([]Page, error) {
+	tmpl, err := g.template("page")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]Page, 0, len(g.Pages))
+
+	for _, p := range g.Pages {
+		if err := ValidPageSlug(p.Slug); err != nil {
+			return nil, err
+		}
+		if !p.IsPublic {
+			continue
+		}
+
+		pages = append(pages, Page{
+			Path:     p.Slug + ".html",
+			Template: tmpl,
+			Name:     "base",
+			Data: PageView{
+				Title:   p.Title,
+				Content: template.HTML(p.HTML),
+			},
+		})
+	}
+
+	return pages, nil
+}
+
+// BuildPage renders the standalone page with the given slug, if any. A
+// private page has its output removed instead, from when it was public.
+func (g *Generator) BuildPage(ctx context.Context, slug string) error {
+	for _, p := range g.Pages {
+		if p.Slug == slug && !p.IsPublic {
+			return g.RemovePage(slug)
+		}
+	}
+
+	return g.BuildPages(ctx, PageProducerFunc(func(g *Generator) ([]Page, error) {
+		pages, err := g.pagePages()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range pages {
+			if p.Path == slug+".html" {
+				return []Page{p}, nil
+			}
+		}
+
+		return nil, nil
+	}))
+}
+
+// RemovePage deletes the output of a page that was deleted or renamed.
+func (g *Generator) RemovePage(slug string) error {
+	if err := ValidPageSlug(slug); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(g.OutDir, slug+".html"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
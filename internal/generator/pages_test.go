@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestBuildPagesPrivate(t *testing.T) {
+	ctx := context.Background()
+
+	out := filepath.Join(t.TempDir(), "dist")
+
+	edited := time.Date(2025, 6, 7, 8, 0, 0, 0, time.UTC)
+
+	g := Generator{
+		Pages: []model.Page{
+			{ID: 1, Slug: "about", Title: "About", HTML: "<p>about</p>", IsPublic: true, UpdatedAt: edited},
+			{ID: 2, Slug: "drafts", Title: "Drafts", HTML: "<p>drafts</p>", UpdatedAt: edited},
+		},
+		OutDir: out,
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "about.html")); err != nil {
+		t.Errorf("public page not built: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "drafts.html")); !os.IsNotExist(err) {
+		t.Errorf("private page built: %v", err)
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sitemap), "/about.html</loc>\n    <lastmod>2025-06-07</lastmod>") {
+		t.Errorf("sitemap does not date the page by its last edit:\n%s", sitemap)
+	}
+	if strings.Contains(string(sitemap), "drafts") {
+		t.Error("sitemap lists a private page")
+	}
+
+	// the page went private since it was built
+	writeTestFile(t, filepath.Join(out, "about.html"), "stale")
+	g.Pages[0].IsPublic = false
+
+	if err := g.BuildPage(ctx, "about"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "about.html")); !os.IsNotExist(err) {
+		t.Errorf("output of a page made private was kept: %v", err)
+	}
+}
+
+func TestValidPageSlug(t *testing.T) {
+	for _, slug := range []string{"", "index", "404", "articles", "sub", "authors", "rss", "sitemap", ".gone", "About Me"} {
+		if ValidPageSlug(slug) == nil {
+			t.Errorf("page slug %q accepted", slug)
+		}
+	}
+	if err := ValidPageSlug("about-me"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Page is one file produced by a PageProducer. Templated pages are
+// executed as Name with Data; otherwise Body is written as is.
+type Page struct {
+	Path     string // relative to OutDir, slash separated
+	Template *template.Template
+	Name     string
+	Data     any
+	Body     []byte
+}
+
+// PageProducer contributes pages to every full build, on top of the
+// index, subject and article pages.
+type PageProducer interface {
+	Pages(g *Generator) ([]Page, error)
+}
+
+// PostProcessor rewrites every generated HTML page before it is written.
+// path is relative to OutDir. It may run concurrently for several pages.
+type PostProcessor interface {
+	Process(path string, html []byte) ([]byte, error)
+}
+
+// PostBuildHook runs once a full build has succeeded.
+type PostBuildHook interface {
+	AfterBuild(g *Generator) error
+}
+
+type PageProducerFunc func(g *Generator) ([]Page, error)
+
+func (f PageProducerFunc) Pages(g *Generator) ([]Page, error) { return f(g) }
+
+type PostProcessorFunc func(path string, html []byte) ([]byte, error)
+
+func (f PostProcessorFunc) Process(path string, html []byte) ([]byte, error) { return f(path, html) }
+
+type PostBuildHookFunc func(g *Generator) error
+
+func (f PostBuildHookFunc) AfterBuild(g *Generator) error { return f(g) }
+
+// Plugins holds the build stages registered on a Generator, run in
+// registration order after the built-in ones.
+type Plugins struct {
+	Producers      []PageProducer
+	PostProcessors []PostProcessor
+	Hooks          []PostBuildHook
+}
+
+// builtinProducers are the outputs every full build writes besides the
+// index, subject and article pages.
+var builtinProducers = []PageProducer{
+	PageProducerFunc((*Generator).rssPages),
+	PageProducerFunc((*Generator).pagePages),
+	PageProducerFunc((*Generator).authorPages),
+	PageProducerFunc((*Generator).sitemapPages),
+}
+
+// ParseTemplate parses template files through the generator's loader
+// with the same funcs as the built-in pages, for producers rendering
+// their own templates.
+func (g *Generator) ParseTemplate(names ...string) (*template.Template, error) {
+	return g.parse(names...)
+}
+
+// BuildPages renders the pages of the given producers on one worker pool.
+func (g *Generator) BuildPages(ctx context.Context, producers ...PageProducer) error {
+	var jobs []renderJob
+
+	for _, p := range producers {
+		pages, err := p.Pages(g)
+		if err != nil {
+			return err
+		}
+
+		for _, page := range pages {
+			job, err := g.pageJob(page)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	for _, job := range jobs {
+		if err := os.MkdirAll(filepath.Dir(job.filename), 0o755); err != nil {
+			return err
+		}
+	}
+
+	return g.render(ctx, jobs)
+}
+
+func (g *Generator) pageJob(page Page) (renderJob, error) {
+	rel := filepath.FromSlash(page.Path)
+	if !filepath.IsLocal(rel) {
+		return renderJob{}, fmt.Errorf("page path %q escapes the output directory", page.Path)
+	}
+
+	return renderJob{
+		filename: filepath.Join(g.OutDir, rel),
+		tmpl:     page.Template,
+		name:     page.Name,
+		data:     page.Data,
+		body:     page.Body,
+	}, nil
+}
+
+func (g *Generator) postProcess(filename string, b []byte) ([]byte, error) {
+	if len(g.Plugins.PostProcessors) == 0 || !strings.HasSuffix(filename, ".html") {
+		return b, nil
+	}
+
+	rel, err := filepath.Rel(g.OutDir, filename)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pp := range g.Plugins.PostProcessors {
+		if b, err = pp.Process(rel, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (g *Generator) runHooks() error {
+	for _, h := range g.Plugins.Hooks {
+		if err := h.AfterBuild(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
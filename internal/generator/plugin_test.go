@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestBuildAllPlugins(t *testing.T) {
+	ctx := context.Background()
+
+	out := filepath.Join(t.TempDir(), "dist")
+
+	var hooked bool
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Hello", TitleURL: "hello", SubjectId: 1, AuthorId: 1, IsPublic: true, HTML: "<p>hi</p>", CreatedAt: time.Now()},
+		},
+		Subjects: []model.Subject{{Id: 1, Title: "Go", Slug: "go"}},
+		Pages: []model.Page{
+			{Slug: "author", Title: "Author", HTML: "<p>me</p>", IsPublic: true},
+			{Slug: "projects", Title: "Projects", HTML: "<p>code</p>", NavOrder: 1, IsPublic: true},
+		},
+		Authors: []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}},
+		OutDir:  out,
+		Plugins: Plugins{
+			Producers: []PageProducer{
+				PageProducerFunc(func(g *Generator) ([]Page, error) {
+					return []Page{{Path: "talks/index.html", Body: []byte("<p>talks</p>")}}, nil
+				}),
+			},
+			PostProcessors: []PostProcessor{
+				PostProcessorFunc(func(path string, html []byte) ([]byte, error) {
+					return append(html, "<!-- "+path+" -->"...), nil
+				}),
+			},
+			Hooks: []PostBuildHook{
+				PostBuildHookFunc(func(g *Generator) error {
+					hooked = true
+					return nil
+				}),
+			},
+		},
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !hooked {
+		t.Error("post-build hook did not run")
+	}
+
+	for _, page := range []string{"talks/index.html", "index.html", "articles/hello.html", "author.html", "authors/ada.html"} {
+		b, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(page)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(b, []byte("<!-- "+page+" -->")) {
+			t.Errorf("%s was not post-processed", page)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "articles", "hello.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `href="/projects.html"`) || strings.Contains(string(b), `href="/author.html">Author`) {
+		t.Error("topbar menu should list exactly the pages with a menu position")
+	}
+	if !strings.Contains(string(b), `href="/authors/ada.html"`) {
+		t.Error("article page has no byline")
+	}
+
+	b, err = os.ReadFile(filepath.Join(out, "authors", "ada.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<title>Hello</title>") {
+		t.Error("author feed does not list the author's article")
+	}
+
+	b, err = os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "<!--") {
+		t.Error("sitemap.xml went through the HTML post-processors")
+	}
+}
+
+func TestBuildPagesRejectsEscapingPath(t *testing.T) {
+	ctx := context.Background()
+
+	g := Generator{OutDir: t.TempDir()}
+
+	err := g.BuildPages(ctx, PageProducerFunc(func(g *Generator) ([]Page, error) {
+		return []Page{{Path: "../outside.html", Body: []byte("x")}}, nil
+	}))
+	if err == nil {
+		t.Fatal("expected an error for a path outside OutDir")
+	}
+}
+
+func TestBuildAllCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out := filepath.Join(t.TempDir(), "dist")
+	writeTestFile(t, filepath.Join(out, "index.html"), "previous")
+
+	var hooked bool
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Hello", TitleURL: "hello", SubjectId: 1, AuthorId: 1, IsPublic: true, HTML: "<p>hi</p>", CreatedAt: time.Now()},
+		},
+		Subjects: []model.Subject{{Id: 1, Title: "Go", Slug: "go"}},
+		OutDir:   out,
+		Plugins: Plugins{
+			Hooks: []PostBuildHook{
+				PostBuildHookFunc(func(g *Generator) error {
+					hooked = true
+					return nil
+				}),
+			},
+		},
+	}
+
+	if err := g.BuildAll(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("BuildAll = %v, want context.Canceled", err)
+	}
+
+	if hooked {
+		t.Error("post-build hook ran after a canceled build")
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "articles", "hello.html")); !os.IsNotExist(err) {
+		t.Errorf("canceled build wrote articles/hello.html: %v", err)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(out, "index.html")); err != nil || string(b) != "previous" {
+		t.Errorf("canceled build replaced the previous index.html: %q, %v", b, err)
+	}
+
+	if entries, err := os.ReadDir(filepath.Dir(out)); err != nil || len(entries) != 1 {
+		t.Errorf("canceled build left %d entries next to dist: %v", len(entries), err)
+	}
+}
@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"blog/internal/model"
+	"blog/internal/templates"
+)
+
+// templateSets lists the files parsed together for each kind of page.
+// Each set is parsed at most once per Generator.
+var templateSets = map[string][]string{
+	"article": {
+		"base_article.html",
+		"users/article.html",
+	},
+	"index": {
+		"base.html",
+		"users/index.html",
+	},
+	"page": {
+		"base.html",
+		"users/page.html",
+	},
+	"author": {
+		"base.html",
+		"users/author.html",
+	},
+	"error": {
+		"base.html",
+		"users/error.html",
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"mod": func(a, b int) int { return a % b },
+	"add": func(a, b int) int { return a + b },
+	"excerpt": func(html template.HTML, n int) string {
+		return excerpt(string(html), n)
+	},
+	// absURL turns a site path into an absolute URL, as hreflang
+	// alternates require
+	"absURL":       func(path string) string { return siteURL + path },
+	"breadcrumbLD": breadcrumbLD,
+}
+
+// template returns the parsed template set, parsing it on first use.
+// It must be called from the goroutine driving the build, never from
+// render workers.
+func (g *Generator) template(set string) (*template.Template, error) {
+	if tmpl, ok := g.tmpls[set]; ok {
+		return tmpl, nil
+	}
+
+	files, ok := templateSets[set]
+	if !ok {
+		return nil, fmt.Errorf("unknown template set %q", set)
+	}
+
+	tmpl, err := g.parse(files...)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.tmpls == nil {
+		g.tmpls = make(map[string]*template.Template)
+	}
+	g.tmpls[set] = tmpl
+
+	return tmpl, nil
+}
+
+// parse returns a copy of the loader's cached template bound to this
+// build's data (the topbar menu), leaving the shared original unexecuted.
+func (g *Generator) parse(names ...string) (*template.Template, error) {
+	tmpl, err := g.loader().Parse(templateFuncs, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	nav := g.navPages()
+
+	return tmpl.Funcs(template.FuncMap{
+		"navPages": func() []model.Page { return nav },
+	}), nil
+}
+
+func (g *Generator) loader() *templates.Loader {
+	if g.Templates == nil {
+		return templates.Default
+	}
+	return g.Templates
+}
+
+// renderJob writes one file: tmpl executed as name with data, or body
+// as is when tmpl is nil.
+type renderJob struct {
+	filename string
+	tmpl     *template.Template
+	name     string
+	data     any
+	body     []byte
+}
+
+func (g *Generator) workers() int {
+	if g.Workers > 0 {
+		return g.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// render executes jobs on a bounded pool of workers, passing HTML pages
+// through the registered post-processors. Every job runs even if an
+// earlier one failed; errors are reported in job order so the result
+// does not depend on scheduling. Once ctx is done no further job starts
+// and its error is returned: the files already written stay.
+func (g *Generator) render(ctx context.Context, jobs []renderJob) error {
+	// partial builds can be the first to write into a fresh OutDir
+	dirs := make(map[string]bool)
+	for _, job := range jobs {
+		dir := filepath.Dir(job.filename)
+		if dirs[dir] {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		dirs[dir] = true
+	}
+
+	errs := make([]error, len(jobs))
+
+	workers := min(g.workers(), len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := g.renderOne(jobs[i]); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", jobs[i].filename, err)
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+func (g *Generator) renderOne(job renderJob) error {
+	b := job.body
+
+	if job.tmpl != nil {
+		var buf bytes.Buffer
+		if err := job.tmpl.ExecuteTemplate(&buf, job.name, job.data); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	}
+
+	b, err := g.postProcess(job.filename, b)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(job.filename, func(f *os.File) error {
+		_, err := f.Write(b)
+		return err
+	})
+}
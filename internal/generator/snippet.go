@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"blog/internal/utils"
+)
+
+// Snippet returns about width characters of the text of an HTML fragment
+// around the first word starting with one of terms, escaped, with the
+// matches marked. Without such a word it starts at the beginning.
+func Snippet(body string, terms []string, width int) template.HTML {
+	text := []rune(utils.PlainText(body))
+
+	first := 0
+	for i := 0; i < len(text); {
+		j := wordEnd(text, i)
+		if j > i && matchLen(text[i:j], terms) > 0 {
+			first = i
+			break
+		}
+		i = max(j, i+1)
+	}
+
+	// keep a third of the width before the match, from a word start
+	start := max(0, first-width/3)
+	for start > 0 && start < first && !unicode.IsSpace(text[start-1]) {
+		start++
+	}
+
+	end := min(len(text), start+width)
+	for end < len(text) && end > first && !unicode.IsSpace(text[end]) {
+		end--
+	}
+
+	out := string(Highlight(string(text[start:end]), terms))
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+
+	return template.HTML(out)
+}
+
+// Highlight escapes text and marks the start of every word beginning with
+// one of terms, which are lowercase.
+func Highlight(text string, terms []string) template.HTML {
+	var b strings.Builder
+
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		j := wordEnd(runes, i)
+		if j == i {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+
+		word := runes[i:j]
+		if n := matchLen(word, terms); n > 0 {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(string(word[:n])))
+			b.WriteString("</mark>")
+			word = word[n:]
+		}
+		b.WriteString(html.EscapeString(string(word)))
+
+		i = j
+	}
+
+	return template.HTML(b.String())
+}
+
+// wordEnd returns the end of the word of letters and digits starting at
+// i, i itself when there is none.
+func wordEnd(runes []rune, i int) int {
+	for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+		i++
+	}
+	return i
+}
+
+// matchLen returns the length of the longest term word starts with, 0
+// when none does.
+func matchLen(word []rune, terms []string) int {
+	lower := make([]rune, len(word))
+	for i, r := range word {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	n := 0
+	for _, t := range terms {
+		tr := []rune(t)
+		if len(tr) > n && len(tr) <= len(lower) && string(lower[:len(tr)]) == t {
+			n = len(tr)
+		}
+	}
+	return n
+}
@@ -0,0 +1,27 @@
+package generator
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	got := Highlight("Gophers <3 GO", []string{"go", "gophe"})
+	if want := "<mark>Gophe</mark>rs &lt;3 <mark>GO</mark>"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	body := "<p>one two three four five six seven eight nine ten</p>"
+
+	for _, tc := range []struct {
+		terms []string
+		want  string
+	}{
+		{[]string{"six"}, "…five <mark>six</mark> seven eight…"},
+		{[]string{"one"}, "<mark>one</mark> two three four…"},
+		{[]string{"missing"}, "one two three four…"},
+	} {
+		if got := Snippet(body, tc.terms, 22); string(got) != tc.want {
+			t.Errorf("snippet of %q: got %q, want %q", tc.terms, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestBuildAllNestedSubjects(t *testing.T) {
+	ctx := context.Background()
+
+	out := filepath.Join(t.TempDir(), "dist")
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Channels", TitleURL: "channels", SubjectId: 3, AuthorId: 1, IsPublic: true, HTML: "<p>chan</p>", CreatedAt: time.Now()},
+		},
+		Subjects: []model.Subject{
+			{Id: 1, Title: "Programming", Slug: "programming", Description: "<p>All about code.</p>", Cover: "covers/code.png"},
+			{Id: 2, Title: "Go", Slug: "go", ParentId: 1},
+			{Id: 3, Title: "Concurrency", Slug: "concurrency", ParentId: 2},
+		},
+		Authors: []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}},
+		OutDir:  out,
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "sub", "programming.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Channels") {
+		t.Error("subject page does not list the articles of its descendants")
+	}
+	if !strings.Contains(string(b), `href="/sub/go.html" class="subject-pill"`) {
+		t.Error("subject page does not link to its children")
+	}
+	if !strings.Contains(string(b), "<p>All about code.</p>") || !strings.Contains(string(b), `src="/assets/common_files/covers/code.png"`) {
+		t.Error("subject page has no description header")
+	}
+
+	b, err = os.ReadFile(filepath.Join(out, "articles", "channels.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"@type":"BreadcrumbList"`) {
+		t.Error("article page has no BreadcrumbList")
+	}
+	if !strings.Contains(string(b), `"position":5,"name":"Channels"`) {
+		t.Error("breadcrumbs should run Home > Programming > Go > Concurrency > article")
+	}
+}
+
+func TestBuildAllPinnedFirst(t *testing.T) {
+	ctx := context.Background()
+
+	out := filepath.Join(t.TempDir(), "dist")
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Home", TitleURL: "home", SubjectId: 1, AuthorId: 1, IsPublic: true, PinnedHome: true, HTML: "<p>home</p>", CreatedAt: time.Now()},
+			{ID: 2, Title: "Sub", TitleURL: "sub", SubjectId: 1, AuthorId: 1, IsPublic: true, PinnedSubject: true, HTML: "<p>sub</p>", CreatedAt: time.Now()},
+			{ID: 3, Title: "New", TitleURL: "new", SubjectId: 1, AuthorId: 1, IsPublic: true, Featured: true, HTML: "<p>new</p>", CreatedAt: time.Now()},
+		},
+		Subjects: []model.Subject{{Id: 1, Title: "Go", Slug: "go"}},
+		Authors:  []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}},
+		OutDir:   out,
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// each listing leads with the article pinned to it, the rest newest first
+	for page, order := range map[string][]string{
+		"index.html":  {"home", "new", "sub"},
+		"sub/go.html": {"sub", "new", "home"},
+	} {
+		b, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(page)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		html := string(b)
+
+		last := -1
+		for _, slug := range order {
+			i := strings.Index(html, `href="/articles/`+slug+`.html"`)
+			if i < last {
+				t.Errorf("%s: articles are not listed as %v", page, order)
+			}
+			last = i
+		}
+		if n := strings.Count(html, `class="card-pin"`); n != 1 {
+			t.Errorf("%s: %d pinned cards, want 1", page, n)
+		}
+		if !strings.Contains(html, "doc-card-featured") {
+			t.Errorf("%s: featured article has no featured card", page)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/internal/model"
+)
+
+func TestBuildAllTranslations(t *testing.T) {
+	ctx := context.Background()
+
+	out := filepath.Join(t.TempDir(), "dist")
+
+	g := Generator{
+		Articles: []model.Article{
+			{ID: 1, Title: "Hello", TitleURL: "hello", SubjectId: 1, AuthorId: 1, Lang: "en", TranslationGroup: 1, IsPublic: true, HTML: "<p>hi</p>", CreatedAt: time.Now()},
+			{ID: 2, Title: "Bonjour", TitleURL: "bonjour", SubjectId: 1, AuthorId: 1, Lang: "fr", TranslationGroup: 1, IsPublic: true, HTML: "<p>salut</p>", CreatedAt: time.Now()},
+		},
+		Subjects: []model.Subject{{Id: 1, Title: "Go", Slug: "go"}},
+		Authors:  []model.Author{{ID: 1, Name: "Ada", Slug: "ada"}},
+		OutDir:   out,
+	}
+
+	if err := g.BuildAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(out, "fr", "articles", "bonjour.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `<html lang="fr">`) {
+		t.Error("translated page does not declare its language")
+	}
+	if !strings.Contains(string(b), `hreflang="en" href="https://`) || !strings.Contains(string(b), `href="/articles/hello.html"`) {
+		t.Error("translated page does not link to the original")
+	}
+
+	b, err = os.ReadFile(filepath.Join(out, "fr", "rss.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<title>Bonjour</title>") || strings.Contains(string(b), "<title>Hello</title>") {
+		t.Error("fr/rss.xml should list only the French articles")
+	}
+
+	b, err = os.ReadFile(filepath.Join(out, "sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `hreflang="fr"`) {
+		t.Error("sitemap has no hreflang alternates")
+	}
+}
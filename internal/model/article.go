@@ -2,17 +2,68 @@ package model
 
 import (
     "time"
+    "regexp"
     "html/template"
 )
 
+// DefaultLang is the language of articles served without a URL prefix.
+// Articles in other languages live under /<lang>/articles/.
+const DefaultLang = "en"
+
+var langPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]+)?$`)
+
+// ValidLang reports whether lang is a lowercase language tag such as
+// "fr" or "pt-br". The pattern matches the nginx snippet's locations.
+func ValidLang(lang string) bool {
+    return langPattern.MatchString(lang)
+}
+
+// ArticlePath is the URL path of an article page.
+func ArticlePath(lang, titleURL string) string {
+    if lang == "" || lang == DefaultLang {
+        return "/articles/" + titleURL + ".html"
+    }
+    return "/" + lang + "/articles/" + titleURL + ".html"
+}
+
 type Article struct {
 	ID        int64
 	Title     string
     TitleURL  string
     SubjectId int64
+    AuthorId  int64
+    Lang      string
+
+    // TranslationGroup links translations of the same article: they share
+    // the id of the first one. 0 means no translations.
+    TranslationGroup int64
+
     IsPublic  bool
+    PinnedHome    bool // listed first on the index
+    PinnedSubject bool // listed first on the pages of its subject and its parents
+    Featured  bool // rendered as a larger card
 	HTML      string
 	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// PublishAt is when the scheduler makes a private article public;
+	// nil when nothing is scheduled.
+	PublishAt *time.Time
+
+	// DeletedAt is when the article was moved to the trash; nil for a
+	// live article. Only ArticleStore.ListTrash returns trashed ones.
+	DeletedAt *time.Time
+}
+
+func (a Article) Path() string {
+    return ArticlePath(a.Lang, a.TitleURL)
+}
+
+// Translation is a link from an article page to one of its translations.
+type Translation struct {
+    Lang  string
+    Title string
+    Path  string
 }
 
 type ArticleView struct {
@@ -21,7 +72,27 @@ type ArticleView struct {
     TitleURL    string
     SubjectId   int64
     Slug        string
+    Author      Author
+    Lang        string
+    Translations []Translation // the other languages, public only
+    Breadcrumbs []Subject      // the subject trail, top level first
     IsPublic    bool
+    PinnedHome    bool
+    PinnedSubject bool
+    Featured    bool
 	HTML        template.HTML
 	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (a ArticleView) Path() string {
+    return ArticlePath(a.Lang, a.TitleURL)
+}
+
+// WasUpdated reports whether the article was edited on a later day than
+// it was published, which is when pages show "Updated on ...".
+func (a ArticleView) WasUpdated() bool {
+    y1, m1, d1 := a.CreatedAt.Date()
+    y2, m2, d2 := a.UpdatedAt.Date()
+    return a.UpdatedAt.After(a.CreatedAt) && (y1 != y2 || m1 != m2 || d1 != d2)
 }
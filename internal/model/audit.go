@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AuditEntry is one authenticated admin or API request that changed, or
+// exported, something.
+type AuditEntry struct {
+	ID        int64
+	CreatedAt time.Time
+
+	// Actor is who sent it, "admin" or "stx" as for revisions, and Auth
+	// how they signed in: "session" or "token". Changes the scheduler
+	// makes have the actor "scheduler" and no auth, method, route or IP.
+	Actor string
+	Auth  string
+
+	// Method and Route are the HTTP method and path; Status is the
+	// response status code.
+	Method string
+	Route  string
+	Status int
+
+	// Target names the entity acted on, e.g. "article 12", and Summary
+	// what changed, e.g. "title: Old → New".
+	Target  string
+	Summary string
+
+	IP string
+}
@@ -1,6 +1,58 @@
 package model
 
+import "strings"
+
+type AuthorLink struct {
+    Label string
+    URL   string
+}
+
 type Author struct {
-    id      int64
-	Content string
+    ID     int64
+    Name   string
+    Slug   string
+    Bio    string // HTML
+    Avatar string // image URL, usually under /assets/common_files/
+    Links  []AuthorLink
+}
+
+// ParseAuthorLinks reads one link per line, "Label URL" or a bare URL.
+func ParseAuthorLinks(s string) []AuthorLink {
+    var links []AuthorLink
+
+    for _, line := range strings.Split(s, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+
+        i := strings.LastIndexAny(line, " \t")
+        if i < 0 {
+            links = append(links, AuthorLink{Label: line, URL: line})
+            continue
+        }
+
+        links = append(links, AuthorLink{
+            Label: strings.TrimSpace(line[:i]),
+            URL:   line[i+1:],
+        })
+    }
+
+    return links
+}
+
+// FormatAuthorLinks is the inverse of ParseAuthorLinks.
+func FormatAuthorLinks(links []AuthorLink) string {
+    var b strings.Builder
+
+    for _, l := range links {
+        if l.Label != "" && l.Label != l.URL {
+            b.WriteString(l.Label)
+            b.WriteByte(' ')
+        }
+        b.WriteString(l.URL)
+        b.WriteByte('\n')
+    }
+
+    return b.String()
 }
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type Page struct {
+	ID        int64
+	Slug      string
+	Title     string
+	HTML      string
+	NavOrder  int  // position in the topbar menu, 0 keeps the page out of it
+	IsPublic  bool // private pages are not built, nor in the menu and sitemap
+	UpdatedAt time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Revision is an article as one save left it: every create and update
+// records one, so the latest revision matches the article.
+type Revision struct {
+	ID        int64
+	ArticleID int64
+	Title     string
+	SubjectId int64
+	IsPublic  bool
+	HTML      string
+	CreatedAt time.Time
+
+	// Actor is who saved: "admin" from the editor, "stx" from the CLI,
+	// "scheduler" when a scheduled article was published.
+	Actor string
+}
@@ -1,9 +1,92 @@
 package model
 
+import "html/template"
+
+// CommonFilesURL is where files uploaded to common_files are served.
+const CommonFilesURL = "/assets/common_files/"
+
 type Subject struct {
 	Title     string
     Slug      string
     Id        int64
+
+    // ParentId is the subject this one is nested under, 0 for a
+    // top-level subject.
+    ParentId  int64
+
+    Description string // HTML shown at the top of the subject page
+    Cover       string // file name under common_files, "" for none
+    Position    int    // sort key of the subject bar, ties by title
+}
+
+func (s Subject) DescriptionHTML() template.HTML {
+    return template.HTML(s.Description)
+}
+
+func (s Subject) CoverURL() string {
+    if s.Cover == "" {
+        return ""
+    }
+    return CommonFilesURL + s.Cover
+}
+
+// SubjectTrail returns the subjects from the top level down to id, id
+// included. It is empty when id is unknown.
+func SubjectTrail(subjects []Subject, id int64) []Subject {
+    byID := make(map[int64]Subject, len(subjects))
+    for _, s := range subjects {
+        byID[s.Id] = s
+    }
+
+    var trail []Subject
+    seen := make(map[int64]bool)
+
+    for id != 0 && !seen[id] {
+        s, ok := byID[id]
+        if !ok {
+            break
+        }
+        seen[id] = true
+        trail = append([]Subject{s}, trail...)
+        id = s.ParentId
+    }
+
+    return trail
 }
 
+// SubjectDescendants returns the ids of id and of every subject nested
+// under it, at any depth.
+func SubjectDescendants(subjects []Subject, id int64) map[int64]bool {
+    children := make(map[int64][]int64)
+    for _, s := range subjects {
+        children[s.ParentId] = append(children[s.ParentId], s.Id)
+    }
+
+    out := map[int64]bool{id: true}
+    queue := []int64{id}
 
+    for len(queue) > 0 {
+        cur := queue[0]
+        queue = queue[1:]
+        for _, c := range children[cur] {
+            if !out[c] {
+                out[c] = true
+                queue = append(queue, c)
+            }
+        }
+    }
+
+    return out
+}
+
+// SubjectChildren returns the subjects directly under id, in order; id 0
+// gives the top-level subjects.
+func SubjectChildren(subjects []Subject, id int64) []Subject {
+    var out []Subject
+    for _, s := range subjects {
+        if s.ParentId == id {
+            out = append(out, s)
+        }
+    }
+    return out
+}
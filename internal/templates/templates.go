@@ -0,0 +1,119 @@
+package templates
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"blog/internal/model"
+)
+
+// SourceDir is where the templates live in the repository. Dev mode
+// reads from it so edits show up without rebuilding the binary.
+const SourceDir = "internal/templates"
+
+//go:embed *.html admin users
+var embedded embed.FS
+
+// Loader resolves template files by name ("base.html", "admin/index.html")
+// and caches the parsed result.
+//
+// A file present in Dir overrides the embedded default of the same name,
+// so a site only needs to copy the templates it customises.
+type Loader struct {
+	Dir string
+	Dev bool
+
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// Funcs are available to every template. The generator rebinds them per
+// build; pages rendered by the admin get these empty defaults.
+var Funcs = template.FuncMap{
+	"navPages": func() []model.Page { return nil },
+}
+
+// Default is used when no Loader is configured: embedded templates, no
+// override directory.
+var Default = &Loader{}
+
+// Parse parses names together, the first one giving its name to the
+// returned template. Results are cached by file list, so callers must
+// always pass the same funcs for a given list. In Dev mode nothing is
+// cached and files are re-read on every call.
+func (l *Loader) Parse(funcs template.FuncMap, names ...string) (*template.Template, error) {
+	if len(names) == 0 {
+		return nil, errors.New("templates: no files named")
+	}
+
+	key := strings.Join(names, "\x00")
+
+	if !l.Dev {
+		l.mu.Lock()
+		tmpl, ok := l.cache[key]
+		l.mu.Unlock()
+
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	var tmpl *template.Template
+
+	for _, name := range names {
+		b, err := l.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var t *template.Template
+		if tmpl == nil {
+			tmpl = template.New(path.Base(name)).Funcs(Funcs).Funcs(funcs)
+			t = tmpl
+		} else {
+			t = tmpl.New(path.Base(name))
+		}
+
+		if _, err := t.Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	if !l.Dev {
+		l.mu.Lock()
+		if l.cache == nil {
+			l.cache = make(map[string]*template.Template)
+		}
+		l.cache[key] = tmpl
+		l.mu.Unlock()
+	}
+
+	return tmpl, nil
+}
+
+// ReadFile returns the raw content of a template file, honouring the
+// override directory and Dev mode.
+func (l *Loader) ReadFile(name string) ([]byte, error) {
+	if l.Dir != "" {
+		b, err := os.ReadFile(filepath.Join(l.Dir, filepath.FromSlash(name)))
+		if err == nil {
+			return b, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if l.Dev {
+		return os.ReadFile(filepath.Join(SourceDir, filepath.FromSlash(name)))
+	}
+
+	return embedded.ReadFile(name)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inlineTags do not break words: "<b>Go</b>pher" reads "Gopher".
+var inlineTags = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Code: true,
+	atom.Em: true, atom.I: true, atom.Kbd: true, atom.Mark: true,
+	atom.S: true, atom.Small: true, atom.Span: true, atom.Strong: true,
+	atom.Sub: true, atom.Sup: true, atom.U: true,
+}
+
+// PlainText returns the text of an HTML fragment, without scripts and
+// styles, its whitespace collapsed.
+func PlainText(body string) string {
+	var b strings.Builder
+
+	z := nethtml.NewTokenizer(strings.NewReader(body))
+	skip := 0
+
+	for {
+		tt := z.Next()
+
+		switch tt {
+		case nethtml.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+
+		case nethtml.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+
+		case nethtml.StartTagToken, nethtml.EndTagToken, nethtml.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+
+			if a == atom.Script || a == atom.Style {
+				if tt == nethtml.StartTagToken {
+					skip++
+				} else if skip > 0 {
+					skip--
+				}
+			}
+
+			if !inlineTags[a] {
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	got := PlainText("<h1>Go</h1><p>A <b>go</b>pher &amp; friends</p><script>alert(1)</script><p>bye</p>")
+	if want := "Go A gopher & friends bye"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -239,18 +239,12 @@ GRANT ALL PRIVILEGES ON \`${DB_NAME}\`.* TO '${DB_USER}'@'localhost';
 FLUSH PRIVILEGES;
 EOF
 
-# Check if tables exist
-TABLE_COUNT=$(mysql -u "$DB_USER" -p"$DB_PASS" -Nse "
-SELECT COUNT(*) FROM information_schema.tables
-WHERE table_schema='${DB_NAME}';
-")
-
-if [[ "$TABLE_COUNT" -eq 0 ]]; then
-  log "Importing database schema..."
-  mysql -u "$DB_USER" -p"$DB_PASS" "$DB_NAME" < "$APP_DIR/database.sql"
-else
-  warn "Database already contains tables. Skipping schema import."
-fi
+log "Applying schema migrations..."
+sudo -u "$APP_USER" \
+  env BLOG_DB_USER="$DB_USER" \
+      BLOG_DB_PASSWORD="$DB_PASS" \
+      BLOG_DB_NAME="$DB_NAME" \
+      "$APP_DIR/go_blog_admin" migrate up
 
 ########################################
 # systemd (always overwrite safely)
@@ -497,9 +491,12 @@ server {
     index index.html;
 
     location / {
-        try_files \$uri \$uri/ /index.html;
+        try_files \$uri \$uri/ =404;
     }
 
+    # --- 404/410 pages (snippet written by the generator on Build All) ---
+    include ${APP_DIR}/nginx_error_pages*.conf;
+
     # --- Assets ---
     location /assets/ {
         alias ${APP_DIR}/assets/;
@@ -579,9 +576,12 @@ server {
     index index.html;
 
     location / {
-        try_files \$uri \$uri/ /index.html;
+        try_files \$uri \$uri/ =404;
     }
 
+    # --- 404/410 pages (snippet written by the generator on Build All) ---
+    include ${APP_DIR}/nginx_error_pages*.conf;
+
     # --- Assets ---
     location /assets/ {
         alias ${APP_DIR}/assets/;